@@ -11,6 +11,9 @@ import (
 
 	"github.com/KalebAsratemedhi/stock-dashboard/backend/internal/api"
 	"github.com/KalebAsratemedhi/stock-dashboard/backend/internal/database"
+	"github.com/KalebAsratemedhi/stock-dashboard/backend/internal/enrich"
+	"github.com/KalebAsratemedhi/stock-dashboard/backend/internal/fiscal"
+	"github.com/KalebAsratemedhi/stock-dashboard/backend/internal/queue"
 	"github.com/KalebAsratemedhi/stock-dashboard/backend/internal/redis"
 	"github.com/KalebAsratemedhi/stock-dashboard/backend/internal/repository"
 	"github.com/KalebAsratemedhi/stock-dashboard/backend/internal/services"
@@ -23,26 +26,99 @@ func main() {
 	}
 	defer database.Close()
 
+	redisClient, err := redis.NewClient()
+	if err != nil {
+		log.Printf("Warning: Failed to connect to Redis: %v", err)
+	} else {
+		defer redisClient.Close()
+	}
+
+	// The API only publishes to RabbitMQ for event collection, so it can run
+	// without it.
+	var publisher services.EventPublisher
+	rmq, err := queue.NewRabbitMQ()
+	if err != nil {
+		log.Printf("Warning: Failed to connect to RabbitMQ: %v", err)
+	} else {
+		defer rmq.Close()
+		publisher = queue.NewPublisher(rmq)
+	}
+
+	geoDB, err := enrich.NewGeoDB()
+	if err != nil {
+		log.Fatalf("Failed to load GeoIP table: %v", err)
+	}
+
+	botDetector, err := enrich.NewBotDetector()
+	if err != nil {
+		log.Fatalf("Failed to load datacenter ranges: %v", err)
+	}
+
+	pseudonymizer, err := enrich.NewPseudonymizer()
+	if err != nil {
+		log.Fatalf("Failed to load pseudonym keys: %v", err)
+	}
+
+	calendar, err := fiscal.NewCalendarFromEnv()
+	if err != nil {
+		log.Fatalf("Invalid fiscal calendar: %v", err)
+	}
+
 	stockRepo := repository.NewStockRepository(database.DB)
 	saleRepo := repository.NewSaleRepository(database.DB)
 	userEventRepo := repository.NewUserEventRepository(database.DB)
 	financialRepo := repository.NewFinancialMetricRepository(database.DB)
+	salesTargetRepo := repository.NewSalesTargetRepository(database.DB)
+	sessionRepo := repository.NewSessionRepository(database.DB)
+	attributionRepo := repository.NewAttributionRepository(database.DB)
+	experimentRepo := repository.NewExperimentRepository(database.DB)
+	privacyRepo := repository.NewPrivacyRepository(database.DB)
+	budgetRepo := repository.NewBudgetRepository(database.DB)
+	costCenterRepo := repository.NewCostCenterRepository(database.DB)
+	periodCloseRepo := repository.NewPeriodCloseRepository(database.DB)
 
 	stockService := services.NewStockService(stockRepo)
 	saleService := services.NewSaleService(saleRepo)
 	userEventService := services.NewUserEventService(userEventRepo)
-	financialService := services.NewFinancialMetricService(financialRepo)
-
-	handler := api.NewHandler(stockService, saleService, userEventService, financialService)
+	financialService := services.NewFinancialMetricService(financialRepo, calendar)
+	salesTargetService := services.NewSalesTargetService(salesTargetRepo)
+	salesAnalyticsService := services.NewSalesAnalyticsService(saleRepo)
+	uniqueUserService := services.NewUniqueUserService(userEventRepo, redisClient)
+	eventAnalyticsService := services.NewEventAnalyticsService(userEventRepo)
+	sessionService := services.NewSessionService(sessionRepo)
+	collectService := services.NewCollectService(publisher, queue.UserEventsQueue, geoDB, botDetector)
+	attributionService := services.NewAttributionService(attributionRepo)
+	experimentService := services.NewExperimentService(experimentRepo)
+	privacyService := services.NewPrivacyService(privacyRepo, pseudonymizer, services.NewRealtimeService(redisClient))
+	budgetService := services.NewBudgetService(budgetRepo, calendar)
+	incomeStatementService := services.NewIncomeStatementService(financialRepo, calendar)
+	costCenterService := services.NewCostCenterService(costCenterRepo)
+	periodCloseService := services.NewPeriodCloseService(periodCloseRepo, calendar)
+
+	handler := api.NewHandler(
+		stockService,
+		saleService,
+		userEventService,
+		financialService,
+		salesTargetService,
+		salesAnalyticsService,
+		uniqueUserService,
+		eventAnalyticsService,
+		sessionService,
+		collectService,
+		attributionService,
+		experimentService,
+		privacyService,
+		budgetService,
+		incomeStatementService,
+		costCenterService,
+		periodCloseService,
+	)
 	
 	wsHub := websocket.NewHub()
 	go wsHub.Run()
 
-	redisClient, err := redis.NewClient()
-	if err != nil {
-		log.Printf("Warning: Failed to connect to Redis: %v", err)
-	} else {
-		defer redisClient.Close()
+	if redisClient != nil {
 		redisBridge := websocket.NewRedisBridge(wsHub, redisClient)
 		go redisBridge.Start()
 	}
@@ -5,6 +5,8 @@ import (
 	"time"
 
 	"github.com/KalebAsratemedhi/stock-dashboard/backend/internal/database"
+	"github.com/KalebAsratemedhi/stock-dashboard/backend/internal/enrich"
+	"github.com/KalebAsratemedhi/stock-dashboard/backend/internal/fiscal"
 	"github.com/KalebAsratemedhi/stock-dashboard/backend/internal/models"
 	"github.com/KalebAsratemedhi/stock-dashboard/backend/internal/repository"
 	"github.com/KalebAsratemedhi/stock-dashboard/backend/internal/services"
@@ -17,21 +19,38 @@ func main() {
 	}
 	defer database.Close()
 
+	pseudonymizer, err := enrich.NewPseudonymizer()
+	if err != nil {
+		log.Fatalf("Failed to load pseudonym keys: %v", err)
+	}
+
+	calendar, err := fiscal.NewCalendarFromEnv()
+	if err != nil {
+		log.Fatalf("Invalid fiscal calendar: %v", err)
+	}
+
 	symbols := []string{"AAPL", "GOOGL", "MSFT", "AMZN", "TSLA", "META", "NVDA", "NFLX", "AMD", "INTC"}
 
 	stockRepo := repository.NewStockRepository(database.DB)
 	saleRepo := repository.NewSaleRepository(database.DB)
 	userEventRepo := repository.NewUserEventRepository(database.DB)
 	financialRepo := repository.NewFinancialMetricRepository(database.DB)
+	privacyRepo := repository.NewPrivacyRepository(database.DB)
 
 	stockService := services.NewStockService(stockRepo)
 	saleService := services.NewSaleService(saleRepo)
 	userEventService := services.NewUserEventService(userEventRepo)
-	financialService := services.NewFinancialMetricService(financialRepo)
+	financialService := services.NewFinancialMetricService(financialRepo, calendar)
+	privacyService := services.NewPrivacyService(privacyRepo, pseudonymizer, services.NewRealtimeService(nil))
 
 	stockGen := generator.NewStockGenerator(symbols)
 	salesGen := generator.NewSalesGenerator()
 	userEventGen := generator.NewUserEventGenerator()
+	experiment, err := generator.ExperimentFromEnv()
+	if err != nil {
+		log.Fatalf("Invalid simulated experiment: %v", err)
+	}
+	userEventGen.SetExperiment(experiment)
 	financialGen := generator.NewFinancialGenerator()
 
 	months := 6
@@ -40,8 +59,8 @@ func main() {
 	log.Printf("Generating %d months of historical data starting from %s", months, startDate.Format("2006-01-02"))
 
 	generateStockData(stockGen, stockService, symbols, startDate)
-	generateSalesData(salesGen, saleService, startDate)
-	generateUserEventData(userEventGen, userEventService, startDate)
+	generateSalesData(salesGen, saleService, privacyService, startDate)
+	generateUserEventData(userEventGen, userEventService, privacyService, startDate)
 	generateFinancialData(financialGen, financialService, startDate)
 
 	log.Println("Historical data generation completed!")
@@ -84,7 +103,7 @@ func generateStockData(gen *generator.StockGenerator, service services.StockServ
 	log.Println("Stock data generation completed")
 }
 
-func generateSalesData(gen *generator.SalesGenerator, service services.SaleService, startDate time.Time) {
+func generateSalesData(gen *generator.SalesGenerator, service services.SaleService, privacy services.PrivacyService, startDate time.Time) {
 	log.Println("Generating sales data...")
 	
 	current := startDate
@@ -96,6 +115,7 @@ func generateSalesData(gen *generator.SalesGenerator, service services.SaleServi
 		for i := 0; i < salesPerDay; i++ {
 			timestamp := current.Add(time.Duration(i*86400/salesPerDay) * time.Second)
 			sale := gen.GenerateSale(timestamp)
+			privacy.PseudonymizeSale(sale)
 			batch = append(batch, sale)
 
 			if len(batch) >= 1000 {
@@ -118,7 +138,7 @@ func generateSalesData(gen *generator.SalesGenerator, service services.SaleServi
 	log.Println("Sales data generation completed")
 }
 
-func generateUserEventData(gen *generator.UserEventGenerator, service services.UserEventService, startDate time.Time) {
+func generateUserEventData(gen *generator.UserEventGenerator, service services.UserEventService, privacy services.PrivacyService, startDate time.Time) {
 	log.Println("Generating user event data...")
 	
 	current := startDate
@@ -130,6 +150,8 @@ func generateUserEventData(gen *generator.UserEventGenerator, service services.U
 		for i := 0; i < eventsPerDay; i++ {
 			timestamp := current.Add(time.Duration(i*86400/eventsPerDay) * time.Second)
 			event := gen.GenerateEvent(timestamp)
+			services.ApplyUserAgent(event)
+			privacy.PseudonymizeEvent(event)
 			batch = append(batch, event)
 
 			if len(batch) >= 1000 {
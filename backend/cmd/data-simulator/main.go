@@ -26,6 +26,11 @@ func main() {
 	stockGen := generator.NewStockGenerator(symbols)
 	salesGen := generator.NewSalesGenerator()
 	userEventGen := generator.NewUserEventGenerator()
+	experiment, err := generator.ExperimentFromEnv()
+	if err != nil {
+		log.Fatalf("Invalid simulated experiment: %v", err)
+	}
+	userEventGen.SetExperiment(experiment)
 	financialGen := generator.NewFinancialGenerator()
 
 	prevQuotes := make(map[string]*models.StockQuote)
@@ -7,6 +7,8 @@ import (
 	"syscall"
 
 	"github.com/KalebAsratemedhi/stock-dashboard/backend/internal/database"
+	"github.com/KalebAsratemedhi/stock-dashboard/backend/internal/enrich"
+	"github.com/KalebAsratemedhi/stock-dashboard/backend/internal/fiscal"
 	"github.com/KalebAsratemedhi/stock-dashboard/backend/internal/queue"
 	"github.com/KalebAsratemedhi/stock-dashboard/backend/internal/redis"
 	"github.com/KalebAsratemedhi/stock-dashboard/backend/internal/repository"
@@ -25,15 +27,34 @@ func main() {
 	}
 	defer rmq.Close()
 
+	pseudonymizer, err := enrich.NewPseudonymizer()
+	if err != nil {
+		log.Fatalf("Failed to load pseudonym keys: %v", err)
+	}
+
+	calendar, err := fiscal.NewCalendarFromEnv()
+	if err != nil {
+		log.Fatalf("Invalid fiscal calendar: %v", err)
+	}
+
 	stockRepo := repository.NewStockRepository(database.DB)
 	saleRepo := repository.NewSaleRepository(database.DB)
 	userEventRepo := repository.NewUserEventRepository(database.DB)
 	financialRepo := repository.NewFinancialMetricRepository(database.DB)
+	salesTargetRepo := repository.NewSalesTargetRepository(database.DB)
+	sessionRepo := repository.NewSessionRepository(database.DB)
+	attributionRepo := repository.NewAttributionRepository(database.DB)
+	privacyRepo := repository.NewPrivacyRepository(database.DB)
+	periodCloseRepo := repository.NewPeriodCloseRepository(database.DB)
 
 	stockService := services.NewStockService(stockRepo)
 	saleService := services.NewSaleService(saleRepo)
 	userEventService := services.NewUserEventService(userEventRepo)
-	financialService := services.NewFinancialMetricService(financialRepo)
+	financialService := services.NewFinancialMetricService(financialRepo, calendar)
+	salesTargetService := services.NewSalesTargetService(salesTargetRepo)
+	sessionService := services.NewSessionService(sessionRepo)
+	attributionService := services.NewAttributionService(attributionRepo)
+	periodCloseService := services.NewPeriodCloseService(periodCloseRepo, calendar)
 
 	redisClient, err := redis.NewClient()
 	if err != nil {
@@ -42,6 +63,10 @@ func main() {
 		defer redisClient.Close()
 	}
 
+	uniqueUserService := services.NewUniqueUserService(userEventRepo, redisClient)
+	realtimeService := services.NewRealtimeService(redisClient)
+	privacyService := services.NewPrivacyService(privacyRepo, pseudonymizer, realtimeService)
+
 	consumer := queue.NewConsumer(rmq)
 	worker := queue.NewWorker(
 		consumer,
@@ -49,6 +74,14 @@ func main() {
 		saleService,
 		userEventService,
 		financialService,
+		salesTargetService,
+		uniqueUserService,
+		sessionService,
+		realtimeService,
+		attributionService,
+		privacyService,
+		periodCloseService,
+		queue.NewPublisher(rmq),
 		redisClient,
 	)
 
@@ -0,0 +1,80 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/KalebAsratemedhi/stock-dashboard/backend/internal/services"
+)
+
+// GetAttribution credits sales revenue to the channels of the linked users'
+// visits under the requested attribution model(s).
+func (h *Handler) GetAttribution(w http.ResponseWriter, r *http.Request) {
+	h, err := h.withBotTraffic(r)
+	if err != nil {
+		jsonError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	start, end, err := parseTimeRange(r)
+	if err != nil {
+		jsonError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	query := services.AttributionQuery{
+		Model:   r.URL.Query().Get("model"),
+		GroupBy: r.URL.Query().Get("group_by"),
+	}
+	if value := r.URL.Query().Get("lookback_days"); value != "" {
+		days, err := strconv.Atoi(value)
+		if err != nil || days <= 0 {
+			jsonError(w, http.StatusBadRequest, "Invalid lookback_days parameter")
+			return
+		}
+		query.Lookback = time.Duration(days) * 24 * time.Hour
+	}
+
+	report, err := h.attributionService.GetAttribution(start, end, query)
+	if err != nil {
+		serviceError(w, err)
+		return
+	}
+
+	jsonResponse(w, http.StatusOK, report)
+}
+
+type identityRequestBody struct {
+	UserID     string    `json:"user_id"`
+	CustomerID string    `json:"customer_id"`
+	SeenAt     time.Time `json:"seen_at"`
+}
+
+// LinkIdentity records that a tracked user is a sales customer, for links
+// that do not arrive as customer_id in event metadata.
+func (h *Handler) LinkIdentity(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		jsonError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	var body identityRequestBody
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		jsonError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	body.UserID = h.privacyService.PseudonymizeID(body.UserID)
+	body.CustomerID = h.privacyService.PseudonymizeID(body.CustomerID)
+	if err := h.attributionService.LinkIdentity(body.UserID, body.CustomerID, body.SeenAt); err != nil {
+		serviceError(w, err)
+		return
+	}
+
+	jsonResponse(w, http.StatusCreated, map[string]string{
+		"user_id":     body.UserID,
+		"customer_id": body.CustomerID,
+	})
+}
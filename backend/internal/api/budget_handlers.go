@@ -0,0 +1,80 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/KalebAsratemedhi/stock-dashboard/backend/internal/models"
+	"github.com/KalebAsratemedhi/stock-dashboard/backend/internal/repository"
+)
+
+func (h *Handler) Budgets(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		query := r.URL.Query()
+		budgets, err := h.budgetService.ListBudgets(repository.BudgetFilter{
+			Department: query.Get("department"),
+			Category:   query.Get("category"),
+			MetricType: query.Get("type"),
+			Period:     query.Get("period"),
+			Version:    query.Get("version"),
+		})
+		if err != nil {
+			serviceError(w, err)
+			return
+		}
+		jsonResponse(w, http.StatusOK, budgets)
+	case http.MethodPost:
+		var budget models.Budget
+		if err := json.NewDecoder(r.Body).Decode(&budget); err != nil {
+			jsonError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+		budget.ID = 0
+		if err := h.budgetService.CreateBudget(&budget); err != nil {
+			serviceError(w, err)
+			return
+		}
+		jsonResponse(w, http.StatusCreated, budget)
+	default:
+		jsonError(w, http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
+
+func (h *Handler) Budget(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r)
+	if err != nil {
+		jsonError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		budget, err := h.budgetService.GetBudgetByID(id)
+		if err != nil {
+			serviceError(w, err)
+			return
+		}
+		jsonResponse(w, http.StatusOK, budget)
+	case http.MethodPut:
+		var budget models.Budget
+		if err := json.NewDecoder(r.Body).Decode(&budget); err != nil {
+			jsonError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+		budget.ID = id
+		if err := h.budgetService.UpdateBudget(&budget); err != nil {
+			serviceError(w, err)
+			return
+		}
+		jsonResponse(w, http.StatusOK, budget)
+	case http.MethodDelete:
+		if err := h.budgetService.DeleteBudget(id); err != nil {
+			serviceError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		jsonError(w, http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
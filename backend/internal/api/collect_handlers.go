@@ -0,0 +1,163 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/netip"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/KalebAsratemedhi/stock-dashboard/backend/internal/services"
+)
+
+const maxCollectBodyBytes = 256 << 10
+
+// trustedProxies are the networks in TRUSTED_PROXIES, a comma separated list
+// of addresses and CIDR ranges, whose X-Forwarded-For headers are honoured.
+var trustedProxies = parseTrustedProxies(os.Getenv("TRUSTED_PROXIES"))
+
+// Collect ingests events from browsers and apps. The body is a single event,
+// an array of events or {"events": [...]}, sent either as application/json
+// or as text/plain, which is what navigator.sendBeacon uses. The response
+// reports how many events were accepted; when queueing fails part way an
+// error response still carries that count, and the client should only
+// resend the events after it.
+func (h *Handler) Collect(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		jsonError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxCollectBodyBytes))
+	if err != nil {
+		jsonError(w, http.StatusRequestEntityTooLarge, "Request body too large")
+		return
+	}
+	events, err := decodeCollectedEvents(body)
+	if err != nil {
+		jsonError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	accepted, err := h.collectService.Collect(events, services.CollectContext{
+		RemoteIP:   clientIP(r),
+		UserAgent:  r.UserAgent(),
+		ReceivedAt: time.Now(),
+	})
+	if err != nil && accepted > 0 {
+		// Part of the batch is already queued; tell the client how much so
+		// that it only resends the rest.
+		status := http.StatusInternalServerError
+		if errors.Is(err, services.ErrUnavailable) {
+			status = http.StatusServiceUnavailable
+		}
+		jsonResponse(w, status, map[string]interface{}{"error": err.Error(), "accepted": accepted})
+		return
+	}
+	if err != nil {
+		serviceError(w, err)
+		return
+	}
+
+	jsonResponse(w, http.StatusAccepted, map[string]int{"accepted": accepted})
+}
+
+func decodeCollectedEvents(body []byte) ([]*services.CollectedEvent, error) {
+	body = bytes.TrimSpace(body)
+	if len(body) > 0 && body[0] == '[' {
+		var events []*services.CollectedEvent
+		err := json.Unmarshal(body, &events)
+		return events, err
+	}
+
+	var envelope struct {
+		Events []*services.CollectedEvent `json:"events"`
+	}
+	if err := json.Unmarshal(body, &envelope); err != nil {
+		return nil, err
+	}
+	if envelope.Events != nil {
+		return envelope.Events, nil
+	}
+
+	var event services.CollectedEvent
+	if err := json.Unmarshal(body, &event); err != nil {
+		return nil, err
+	}
+	return []*services.CollectedEvent{&event}, nil
+}
+
+// clientIP returns the connection address, or, when the connection comes
+// from a trusted proxy, the nearest X-Forwarded-For hop that is not itself a
+// trusted proxy. Hops added by anyone else are client-supplied and ignored.
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	if !isTrustedProxy(host) {
+		return host
+	}
+
+	hops := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := strings.TrimSpace(hops[i])
+		if _, err := netip.ParseAddr(hop); err != nil {
+			break
+		}
+		host = hop
+		if !isTrustedProxy(hop) {
+			break
+		}
+	}
+	return host
+}
+
+func isTrustedProxy(addr string) bool {
+	ip, err := netip.ParseAddr(addr)
+	if err != nil {
+		return false
+	}
+	ip = ip.Unmap()
+	for _, prefix := range trustedProxies {
+		if prefix.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseTrustedProxies parses a comma separated list of addresses and CIDR
+// ranges, skipping invalid entries.
+func parseTrustedProxies(spec string) []netip.Prefix {
+	var prefixes []netip.Prefix
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if !strings.Contains(entry, "/") {
+			addr, err := netip.ParseAddr(entry)
+			if err != nil {
+				log.Printf("Ignoring invalid trusted proxy %q", entry)
+				continue
+			}
+			addr = addr.Unmap()
+			prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+			continue
+		}
+		prefix, err := netip.ParsePrefix(entry)
+		if err != nil {
+			log.Printf("Ignoring invalid trusted proxy %q", entry)
+			continue
+		}
+		prefixes = append(prefixes, prefix.Masked())
+	}
+	return prefixes
+}
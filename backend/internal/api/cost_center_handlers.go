@@ -0,0 +1,184 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/KalebAsratemedhi/stock-dashboard/backend/internal/models"
+	"github.com/KalebAsratemedhi/stock-dashboard/backend/internal/services"
+)
+
+// costCenterRequest is the body of a cost center creation: the cost center
+// and where it first sits in the tree.
+type costCenterRequest struct {
+	models.CostCenter
+	ParentID      *uint      `json:"parent_id"`
+	EffectiveFrom *time.Time `json:"effective_from"`
+}
+
+// costCenterMove is the body of a cost center move.
+type costCenterMove struct {
+	ParentID      uint      `json:"parent_id"`
+	EffectiveFrom time.Time `json:"effective_from"`
+}
+
+// CostCenters lists the cost center tree as of as_of (default now) or adds
+// a cost center.
+func (h *Handler) CostCenters(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		asOf, ok := parseAsOf(w, r, time.Now())
+		if !ok {
+			return
+		}
+		nodes, err := h.costCenterService.GetTree(asOf)
+		if err != nil {
+			serviceError(w, err)
+			return
+		}
+		jsonResponse(w, http.StatusOK, nodes)
+	case http.MethodPost:
+		var request costCenterRequest
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			jsonError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+		center := request.CostCenter
+		center.ID = 0
+		if err := h.costCenterService.CreateCostCenter(&center, request.ParentID, request.EffectiveFrom); err != nil {
+			serviceError(w, err)
+			return
+		}
+		detail, err := h.costCenterService.GetCostCenter(center.ID)
+		if err != nil {
+			serviceError(w, err)
+			return
+		}
+		jsonResponse(w, http.StatusCreated, detail)
+	default:
+		jsonError(w, http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
+
+// CostCenter returns a cost center with its parent link history, updates
+// its code, name or department, or deletes a cost center without children.
+func (h *Handler) CostCenter(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r)
+	if err != nil {
+		jsonError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		detail, err := h.costCenterService.GetCostCenter(id)
+		if err != nil {
+			serviceError(w, err)
+			return
+		}
+		jsonResponse(w, http.StatusOK, detail)
+	case http.MethodPut:
+		var center models.CostCenter
+		if err := json.NewDecoder(r.Body).Decode(&center); err != nil {
+			jsonError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+		center.ID = id
+		if err := h.costCenterService.UpdateCostCenter(&center); err != nil {
+			serviceError(w, err)
+			return
+		}
+		jsonResponse(w, http.StatusOK, center)
+	case http.MethodDelete:
+		if err := h.costCenterService.DeleteCostCenter(id); err != nil {
+			serviceError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		jsonError(w, http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
+
+// MoveCostCenter reparents a cost center from effective_from on, keeping
+// earlier metrics under the old parent.
+func (h *Handler) MoveCostCenter(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		jsonError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+	id, err := pathID(r)
+	if err != nil {
+		jsonError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	var move costCenterMove
+	if err := json.NewDecoder(r.Body).Decode(&move); err != nil {
+		jsonError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	link, err := h.costCenterService.MoveCostCenter(id, move.ParentID, move.EffectiveFrom)
+	if err != nil {
+		serviceError(w, err)
+		return
+	}
+
+	jsonResponse(w, http.StatusCreated, link)
+}
+
+// GetCostCenterRollup totals financial metrics over start and end or a
+// period up the cost center tree as of as_of (default the end of the range).
+// restate=true rolls all metrics up along the as_of tree instead of the tree
+// in effect when they were booked; type filters by metric type.
+func (h *Handler) GetCostCenterRollup(w http.ResponseWriter, r *http.Request) {
+	start, end, err := h.parsePeriodRange(r)
+	if err != nil {
+		jsonError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	asOf, ok := parseAsOf(w, r, end)
+	if !ok {
+		return
+	}
+	query := r.URL.Query()
+	restate := false
+	if value := query.Get("restate"); value != "" {
+		restate, err = strconv.ParseBool(value)
+		if err != nil {
+			jsonError(w, http.StatusBadRequest, "Invalid restate parameter")
+			return
+		}
+	}
+
+	rollup, err := h.costCenterService.GetRollup(services.CostCenterRollupQuery{
+		Start:      start,
+		End:        end,
+		AsOf:       asOf,
+		Restate:    restate,
+		MetricType: query.Get("type"),
+	})
+	if err != nil {
+		serviceError(w, err)
+		return
+	}
+
+	jsonResponse(w, http.StatusOK, rollup)
+}
+
+// parseAsOf reads the as_of parameter, writing a 400 response when it is
+// invalid.
+func parseAsOf(w http.ResponseWriter, r *http.Request, fallback time.Time) (time.Time, bool) {
+	asOfStr := r.URL.Query().Get("as_of")
+	if asOfStr == "" {
+		return fallback, true
+	}
+	asOf, err := time.Parse(time.RFC3339, asOfStr)
+	if err != nil {
+		jsonError(w, http.StatusBadRequest, "Invalid as_of time format")
+		return time.Time{}, false
+	}
+	return asOf, true
+}
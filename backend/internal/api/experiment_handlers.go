@@ -0,0 +1,116 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/KalebAsratemedhi/stock-dashboard/backend/internal/models"
+	"github.com/KalebAsratemedhi/stock-dashboard/backend/internal/services"
+)
+
+func (h *Handler) Experiments(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		experiments, err := h.experimentService.ListExperiments()
+		if err != nil {
+			serviceError(w, err)
+			return
+		}
+		jsonResponse(w, http.StatusOK, experiments)
+	case http.MethodPost:
+		var experiment models.Experiment
+		if err := json.NewDecoder(r.Body).Decode(&experiment); err != nil {
+			jsonError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+		experiment.ID = 0
+		if err := h.experimentService.CreateExperiment(&experiment); err != nil {
+			serviceError(w, err)
+			return
+		}
+		jsonResponse(w, http.StatusCreated, experiment)
+	default:
+		jsonError(w, http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
+
+func (h *Handler) Experiment(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r)
+	if err != nil {
+		jsonError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		experiment, err := h.experimentService.GetExperimentByID(id)
+		if err != nil {
+			serviceError(w, err)
+			return
+		}
+		jsonResponse(w, http.StatusOK, experiment)
+	case http.MethodPut:
+		var experiment models.Experiment
+		if err := json.NewDecoder(r.Body).Decode(&experiment); err != nil {
+			jsonError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+		experiment.ID = id
+		if err := h.experimentService.UpdateExperiment(&experiment); err != nil {
+			serviceError(w, err)
+			return
+		}
+		jsonResponse(w, http.StatusOK, experiment)
+	case http.MethodDelete:
+		if err := h.experimentService.DeleteExperiment(id); err != nil {
+			serviceError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		jsonError(w, http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
+
+// GetExperimentResults compares each variant's conversion to the goal event
+// with control. method=sequential adds an always-valid test that can be
+// checked repeatedly while the experiment runs.
+func (h *Handler) GetExperimentResults(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r)
+	if err != nil {
+		jsonError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	h, err = h.withBotTraffic(r)
+	if err != nil {
+		jsonError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var query services.ExperimentResultsQuery
+	switch r.URL.Query().Get("method") {
+	case "", "fixed":
+	case "sequential":
+		query.Sequential = true
+	default:
+		jsonError(w, http.StatusBadRequest, "Invalid method parameter")
+		return
+	}
+	if value := r.URL.Query().Get("confidence"); value != "" {
+		query.Confidence, err = strconv.ParseFloat(value, 64)
+		if err != nil || query.Confidence <= 0 || query.Confidence >= 1 {
+			jsonError(w, http.StatusBadRequest, "Invalid confidence parameter")
+			return
+		}
+	}
+
+	results, err := h.experimentService.GetResults(id, time.Now(), query)
+	if err != nil {
+		serviceError(w, err)
+		return
+	}
+
+	jsonResponse(w, http.StatusOK, results)
+}
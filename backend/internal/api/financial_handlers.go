@@ -0,0 +1,77 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/KalebAsratemedhi/stock-dashboard/backend/internal/services"
+)
+
+// GetFinancialTimeSeries totals financial metrics per interval bucket,
+// grouped by metric_type, department or category and filtered by type and
+// department. Without a type, series are grouped by metric_type, the only
+// grouping allowed. interval may be fiscal_week, fiscal_month,
+// fiscal_quarter or fiscal_year to bucket by fiscal periods.
+func (h *Handler) GetFinancialTimeSeries(w http.ResponseWriter, r *http.Request) {
+	start, end, err := h.parsePeriodRange(r)
+	if err != nil {
+		jsonError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	query := r.URL.Query()
+	series, err := h.financialService.GetMetricsByTimeRangeAggregated(start, end, services.FinancialSeriesQuery{
+		Interval:   query.Get("interval"),
+		GroupBy:    query.Get("group_by"),
+		MetricType: query.Get("type"),
+		Department: query.Get("department"),
+	})
+	if err != nil {
+		serviceError(w, err)
+		return
+	}
+
+	jsonResponse(w, http.StatusOK, series)
+}
+
+// GetBudgetVsActual compares actuals with budget lines over start and end or
+// a period such as 2026-Q3 or FY2026-Q3. version=original ignores budget
+// revisions.
+func (h *Handler) GetBudgetVsActual(w http.ResponseWriter, r *http.Request) {
+	start, end, err := h.parsePeriodRange(r)
+	if err != nil {
+		jsonError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	rows, err := h.financialService.GetBudgetVsActual(start, end, r.URL.Query().Get("version"))
+	if err != nil {
+		serviceError(w, err)
+		return
+	}
+
+	jsonResponse(w, http.StatusOK, rows)
+}
+
+// parsePeriodRange reads a fiscal_period or period parameter, or start and
+// end when both are absent. Periods end where the next begins, so the last
+// microsecond is dropped to match the inclusive end of start/end ranges.
+func (h *Handler) parsePeriodRange(r *http.Request) (time.Time, time.Time, error) {
+	if label := periodLabel(r); label != "" {
+		period, err := h.financialService.ResolvePeriod(label)
+		if err != nil {
+			return time.Time{}, time.Time{}, err
+		}
+		return period.Start, period.End.Add(-time.Microsecond), nil
+	}
+	return parseTimeRange(r)
+}
+
+// periodLabel returns the fiscal_period parameter, such as FY2026-Q3, or
+// else the period parameter, which accepts calendar and fiscal labels.
+func periodLabel(r *http.Request) string {
+	if label := r.URL.Query().Get("fiscal_period"); label != "" {
+		return label
+	}
+	return r.URL.Query().Get("period")
+}
@@ -0,0 +1,122 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/KalebAsratemedhi/stock-dashboard/backend/internal/services"
+)
+
+// parseGeoFormat reads the optional format parameter: json (default) or geojson.
+func parseGeoFormat(r *http.Request) (bool, bool) {
+	switch r.URL.Query().Get("format") {
+	case "", "json":
+		return false, true
+	case "geojson":
+		return true, true
+	default:
+		return false, false
+	}
+}
+
+// GetEventGeo aggregates events by country, or by city when country is set.
+// GeoJSON is only available by country, since cities have no coordinates.
+func (h *Handler) GetEventGeo(w http.ResponseWriter, r *http.Request) {
+	h, err := h.withBotTraffic(r)
+	if err != nil {
+		jsonError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	start, end, err := parseTimeRange(r)
+	if err != nil {
+		jsonError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	geoJSON, ok := parseGeoFormat(r)
+	if !ok {
+		jsonError(w, http.StatusBadRequest, "Invalid format parameter, expected json or geojson")
+		return
+	}
+
+	country := r.URL.Query().Get("country")
+	if country != "" && geoJSON {
+		jsonError(w, http.StatusBadRequest, "GeoJSON is only available by country")
+		return
+	}
+	level := "country"
+	var rows interface{}
+	if country != "" {
+		level = "city"
+		cities, err := h.userEventService.GetEventsByCity(start, end, country)
+		if err != nil {
+			serviceError(w, err)
+			return
+		}
+		rows = cities
+	} else {
+		countries, err := h.userEventService.GetEventsByCountry(start, end)
+		if err != nil {
+			serviceError(w, err)
+			return
+		}
+		rows = countries
+		if geoJSON {
+			jsonResponse(w, http.StatusOK, services.EventGeoJSON(countries))
+			return
+		}
+	}
+
+	jsonResponse(w, http.StatusOK, map[string]interface{}{
+		"level":     level,
+		"country":   country,
+		"locations": rows,
+	})
+}
+
+// GetSalesGeo aggregates sales by region, or by country when region is set.
+func (h *Handler) GetSalesGeo(w http.ResponseWriter, r *http.Request) {
+	start, end, err := parseTimeRange(r)
+	if err != nil {
+		jsonError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	geoJSON, ok := parseGeoFormat(r)
+	if !ok {
+		jsonError(w, http.StatusBadRequest, "Invalid format parameter, expected json or geojson")
+		return
+	}
+
+	region := r.URL.Query().Get("region")
+	level := "region"
+	var rows interface{}
+	if region != "" {
+		level = "country"
+		countries, err := h.saleService.GetRevenueByCountry(start, end, region)
+		if err != nil {
+			serviceError(w, err)
+			return
+		}
+		rows = countries
+		if geoJSON {
+			jsonResponse(w, http.StatusOK, services.SalesGeoJSON(countries, region))
+			return
+		}
+	} else {
+		regions, err := h.saleService.GetRevenueByRegion(start, end)
+		if err != nil {
+			serviceError(w, err)
+			return
+		}
+		rows = regions
+		if geoJSON {
+			jsonResponse(w, http.StatusOK, services.SalesGeoJSON(regions, ""))
+			return
+		}
+	}
+
+	jsonResponse(w, http.StatusOK, map[string]interface{}{
+		"level":     level,
+		"region":    region,
+		"locations": rows,
+	})
+}
@@ -2,6 +2,7 @@ package api
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strconv"
 	"time"
@@ -14,6 +15,19 @@ type Handler struct {
 	saleService     services.SaleService
 	userEventService services.UserEventService
 	financialService services.FinancialMetricService
+	salesTargetService services.SalesTargetService
+	salesAnalyticsService services.SalesAnalyticsService
+	uniqueUserService services.UniqueUserService
+	eventAnalyticsService services.EventAnalyticsService
+	sessionService services.SessionService
+	collectService services.CollectService
+	attributionService services.AttributionService
+	experimentService services.ExperimentService
+	privacyService services.PrivacyService
+	budgetService services.BudgetService
+	incomeStatementService services.IncomeStatementService
+	costCenterService services.CostCenterService
+	periodCloseService services.PeriodCloseService
 }
 
 func NewHandler(
@@ -21,12 +35,38 @@ func NewHandler(
 	saleService services.SaleService,
 	userEventService services.UserEventService,
 	financialService services.FinancialMetricService,
+	salesTargetService services.SalesTargetService,
+	salesAnalyticsService services.SalesAnalyticsService,
+	uniqueUserService services.UniqueUserService,
+	eventAnalyticsService services.EventAnalyticsService,
+	sessionService services.SessionService,
+	collectService services.CollectService,
+	attributionService services.AttributionService,
+	experimentService services.ExperimentService,
+	privacyService services.PrivacyService,
+	budgetService services.BudgetService,
+	incomeStatementService services.IncomeStatementService,
+	costCenterService services.CostCenterService,
+	periodCloseService services.PeriodCloseService,
 ) *Handler {
 	return &Handler{
 		stockService:    stockService,
 		saleService:     saleService,
 		userEventService: userEventService,
 		financialService: financialService,
+		salesTargetService: salesTargetService,
+		salesAnalyticsService: salesAnalyticsService,
+		uniqueUserService: uniqueUserService,
+		eventAnalyticsService: eventAnalyticsService,
+		sessionService: sessionService,
+		collectService: collectService,
+		attributionService: attributionService,
+		experimentService: experimentService,
+		privacyService: privacyService,
+		budgetService: budgetService,
+		incomeStatementService: incomeStatementService,
+		costCenterService: costCenterService,
+		periodCloseService: periodCloseService,
 	}
 }
 
@@ -150,6 +190,24 @@ func (h *Handler) GetSalesRevenue(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	mode, err := services.ParseCompareMode(r.URL.Query().Get("compare"))
+	if err != nil {
+		jsonError(w, http.StatusBadRequest, "Invalid compare parameter")
+		return
+	}
+	if mode != "" {
+		comparison, err := h.saleService.CompareTotalRevenue(start, end, mode)
+		if err != nil {
+			serviceError(w, err)
+			return
+		}
+		jsonResponse(w, http.StatusOK, map[string]interface{}{
+			"revenue":    comparison.Total.Current,
+			"comparison": comparison,
+		})
+		return
+	}
+
 	revenue, err := h.saleService.GetTotalRevenue(start, end)
 	if err != nil {
 		jsonError(w, http.StatusInternalServerError, err.Error())
@@ -159,13 +217,54 @@ func (h *Handler) GetSalesRevenue(w http.ResponseWriter, r *http.Request) {
 	jsonResponse(w, http.StatusOK, map[string]interface{}{"revenue": revenue})
 }
 
+func (h *Handler) GetSalesRevenueByCategory(w http.ResponseWriter, r *http.Request) {
+	start, end, err := parseTimeRange(r)
+	if err != nil {
+		jsonError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	mode, err := services.ParseCompareMode(r.URL.Query().Get("compare"))
+	if err != nil {
+		jsonError(w, http.StatusBadRequest, "Invalid compare parameter")
+		return
+	}
+
+	var result interface{}
+	if mode != "" {
+		result, err = h.saleService.CompareRevenueByCategory(start, end, mode)
+	} else {
+		result, err = h.saleService.GetRevenueByCategory(start, end)
+	}
+	if err != nil {
+		serviceError(w, err)
+		return
+	}
+
+	jsonResponse(w, http.StatusOK, result)
+}
+
 func (h *Handler) GetUserEvents(w http.ResponseWriter, r *http.Request) {
+	h, err := h.withBotTraffic(r)
+	if err != nil {
+		jsonError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	predicates, err := metadataPredicates(r)
+	if err != nil {
+		serviceError(w, err)
+		return
+	}
+	if len(predicates) > 0 || r.URL.Query().Get("group_by") != "" {
+		h.queryUserEvents(w, r, predicates)
+		return
+	}
+
 	startStr := r.URL.Query().Get("start")
 	endStr := r.URL.Query().Get("end")
 	eventType := r.URL.Query().Get("type")
 
 	var events interface{}
-	var err error
 
 	if startStr != "" && endStr != "" {
 		start, err1 := time.Parse(time.RFC3339, startStr)
@@ -196,6 +295,38 @@ func (h *Handler) GetUserEvents(w http.ResponseWriter, r *http.Request) {
 	jsonResponse(w, http.StatusOK, events)
 }
 
+func (h *Handler) GetEventCounts(w http.ResponseWriter, r *http.Request) {
+	h, err := h.withBotTraffic(r)
+	if err != nil {
+		jsonError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	start, end, err := parseTimeRange(r)
+	if err != nil {
+		jsonError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	mode, err := services.ParseCompareMode(r.URL.Query().Get("compare"))
+	if err != nil {
+		jsonError(w, http.StatusBadRequest, "Invalid compare parameter")
+		return
+	}
+
+	var result interface{}
+	if mode != "" {
+		result, err = h.userEventService.CompareEventCountsByType(start, end, mode)
+	} else {
+		result, err = h.userEventService.GetEventCountsByType(start, end)
+	}
+	if err != nil {
+		serviceError(w, err)
+		return
+	}
+
+	jsonResponse(w, http.StatusOK, result)
+}
+
 func (h *Handler) GetFinancialMetrics(w http.ResponseWriter, r *http.Request) {
 	startStr := r.URL.Query().Get("start")
 	endStr := r.URL.Query().Get("end")
@@ -205,7 +336,14 @@ func (h *Handler) GetFinancialMetrics(w http.ResponseWriter, r *http.Request) {
 	var metrics interface{}
 	var err error
 
-	if startStr != "" && endStr != "" {
+	if periodLabel(r) != "" {
+		start, end, perr := h.parsePeriodRange(r)
+		if perr != nil {
+			jsonError(w, http.StatusBadRequest, perr.Error())
+			return
+		}
+		metrics, err = h.financialService.GetMetricsByTimeRange(start, end)
+	} else if startStr != "" && endStr != "" {
 		start, err1 := time.Parse(time.RFC3339, startStr)
 		end, err2 := time.Parse(time.RFC3339, endStr)
 		if err1 != nil || err2 != nil {
@@ -236,6 +374,32 @@ func (h *Handler) GetFinancialMetrics(w http.ResponseWriter, r *http.Request) {
 	jsonResponse(w, http.StatusOK, metrics)
 }
 
+func (h *Handler) GetDepartmentMetrics(w http.ResponseWriter, r *http.Request) {
+	start, end, err := h.parsePeriodRange(r)
+	if err != nil {
+		jsonError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	mode, err := services.ParseCompareMode(r.URL.Query().Get("compare"))
+	if err != nil {
+		jsonError(w, http.StatusBadRequest, "Invalid compare parameter")
+		return
+	}
+
+	var result interface{}
+	if mode != "" {
+		result, err = h.financialService.CompareMetricsGroupedByDepartment(start, end, mode)
+	} else {
+		result, err = h.financialService.GetMetricsGroupedByDepartment(start, end)
+	}
+	if err != nil {
+		serviceError(w, err)
+		return
+	}
+
+	jsonResponse(w, http.StatusOK, result)
+}
+
 func jsonResponse(w http.ResponseWriter, status int, data interface{}) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
@@ -246,3 +410,69 @@ func jsonError(w http.ResponseWriter, status int, message string) {
 	jsonResponse(w, status, map[string]string{"error": message})
 }
 
+// parseTimeRange reads the required start and end query parameters.
+func parseTimeRange(r *http.Request) (time.Time, time.Time, error) {
+	startStr := r.URL.Query().Get("start")
+	endStr := r.URL.Query().Get("end")
+	if startStr == "" || endStr == "" {
+		return time.Time{}, time.Time{}, errors.New("Missing required parameters: start, end")
+	}
+	start, err := time.Parse(time.RFC3339, startStr)
+	if err != nil {
+		return time.Time{}, time.Time{}, errors.New("Invalid start time format")
+	}
+	end, err := time.Parse(time.RFC3339, endStr)
+	if err != nil {
+		return time.Time{}, time.Time{}, errors.New("Invalid end time format")
+	}
+	return start, end, nil
+}
+
+// pathID reads a numeric {id} path value.
+func pathID(r *http.Request) (uint, error) {
+	id, err := strconv.ParseUint(r.PathValue("id"), 10, 64)
+	if err != nil {
+		return 0, errors.New("Invalid id")
+	}
+	return uint(id), nil
+}
+
+// withBotTraffic returns the handler to serve r with: a copy whose user event
+// services also count bot traffic when include_bots=true, or h itself.
+func (h *Handler) withBotTraffic(r *http.Request) (*Handler, error) {
+	value := r.URL.Query().Get("include_bots")
+	if value == "" {
+		return h, nil
+	}
+	include, err := strconv.ParseBool(value)
+	if err != nil {
+		return nil, errors.New("Invalid include_bots parameter")
+	}
+	if !include {
+		return h, nil
+	}
+	scoped := *h
+	scoped.userEventService = h.userEventService.IncludeBots()
+	scoped.uniqueUserService = h.uniqueUserService.IncludeBots()
+	scoped.eventAnalyticsService = h.eventAnalyticsService.IncludeBots()
+	scoped.sessionService = h.sessionService.IncludeBots()
+	scoped.attributionService = h.attributionService.IncludeBots()
+	scoped.experimentService = h.experimentService.IncludeBots()
+	return &scoped, nil
+}
+
+// serviceError maps service errors onto HTTP status codes.
+func serviceError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, services.ErrInvalidInput):
+		jsonError(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, services.ErrNotFound):
+		jsonError(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, services.ErrUnavailable):
+		jsonError(w, http.StatusServiceUnavailable, err.Error())
+	case errors.Is(err, services.ErrPeriodClosed):
+		jsonError(w, http.StatusConflict, err.Error())
+	default:
+		jsonError(w, http.StatusInternalServerError, err.Error())
+	}
+}
@@ -0,0 +1,216 @@
+package api
+
+import (
+	"bytes"
+	"encoding/csv"
+	"html/template"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/KalebAsratemedhi/stock-dashboard/backend/internal/services"
+)
+
+// GetIncomeStatement returns the profit and loss statement for a period, such
+// as 2026-Q3 or FY2026-Q3, or a start/end range, with budget and prior period columns.
+// format is json (default), csv or html, a printable page.
+func (h *Handler) GetIncomeStatement(w http.ResponseWriter, r *http.Request) {
+	format := r.URL.Query().Get("format")
+	if format != "" && format != "json" && format != "csv" && format != "html" {
+		jsonError(w, http.StatusBadRequest, "Invalid format parameter, expected json, csv or html")
+		return
+	}
+	query, ok := h.parseStatementQuery(w, r)
+	if !ok {
+		return
+	}
+
+	statement, err := h.incomeStatementService.GetIncomeStatement(query)
+	if err != nil {
+		serviceError(w, err)
+		return
+	}
+
+	switch format {
+	case "csv":
+		writeStatementCSV(w, statement)
+	case "html":
+		// Render into a buffer so that a template error can still be
+		// reported as an error response.
+		var page bytes.Buffer
+		if err := statementTemplate.Execute(&page, statement); err != nil {
+			log.Printf("Error rendering income statement: %v", err)
+			jsonError(w, http.StatusInternalServerError, "Failed to render income statement")
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		page.WriteTo(w)
+	default:
+		jsonResponse(w, http.StatusOK, statement)
+	}
+}
+
+// GetIncomeStatementRows drills into a statement line item, returning the
+// financial metrics it totals. line is a line item ID such as expense:R&D.
+func (h *Handler) GetIncomeStatementRows(w http.ResponseWriter, r *http.Request) {
+	query, ok := h.parseStatementQuery(w, r)
+	if !ok {
+		return
+	}
+	line := r.URL.Query().Get("line")
+	if line == "" {
+		jsonError(w, http.StatusBadRequest, "Missing required parameter: line")
+		return
+	}
+	limit := 0
+	if value := r.URL.Query().Get("limit"); value != "" {
+		var err error
+		limit, err = strconv.Atoi(value)
+		if err != nil || limit <= 0 {
+			jsonError(w, http.StatusBadRequest, "Invalid limit parameter")
+			return
+		}
+	}
+
+	rows, err := h.incomeStatementService.GetStatementRows(query, line, limit)
+	if err != nil {
+		serviceError(w, err)
+		return
+	}
+
+	jsonResponse(w, http.StatusOK, map[string]interface{}{
+		"line": line,
+		"rows": rows,
+	})
+}
+
+// parseStatementQuery reads the range, department, compare and
+// budget_version parameters, writing a 400 response when they are invalid.
+func (h *Handler) parseStatementQuery(w http.ResponseWriter, r *http.Request) (services.StatementQuery, bool) {
+	start, end, err := h.parsePeriodRange(r)
+	if err != nil {
+		jsonError(w, http.StatusBadRequest, err.Error())
+		return services.StatementQuery{}, false
+	}
+	mode, err := services.ParseCompareMode(r.URL.Query().Get("compare"))
+	if err != nil {
+		jsonError(w, http.StatusBadRequest, "Invalid compare parameter")
+		return services.StatementQuery{}, false
+	}
+	return services.StatementQuery{
+		Period:        periodLabel(r),
+		Start:         start,
+		End:           end,
+		Department:    r.URL.Query().Get("department"),
+		Compare:       mode,
+		BudgetVersion: r.URL.Query().Get("budget_version"),
+	}, true
+}
+
+func writeStatementCSV(w http.ResponseWriter, statement *services.IncomeStatement) {
+	name := "pnl"
+	if statement.Period != "" {
+		name += "-" + statement.Period
+	}
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", `attachment; filename="`+name+`.csv"`)
+	w.WriteHeader(http.StatusOK)
+
+	out := csv.NewWriter(w)
+	out.Write([]string{
+		"id", "label", "kind", "level",
+		"actual", "budget", "budget_variance", "budget_variance_pct",
+		"prior", "prior_change", "prior_change_pct",
+	})
+	for _, line := range statement.Flatten() {
+		out.Write([]string{
+			line.ID, line.Label, line.Kind, strconv.Itoa(line.Level),
+			csvNumber(line.Actual), csvNumber(line.Budget), csvNumber(line.BudgetVariance), csvPercent(line.BudgetVariancePct),
+			csvNumber(line.Prior), csvNumber(line.PriorChange), csvPercent(line.PriorChangePct),
+		})
+	}
+	out.Flush()
+}
+
+func csvNumber(v float64) string {
+	return strconv.FormatFloat(v, 'f', 2, 64)
+}
+
+func csvPercent(v *float64) string {
+	if v == nil {
+		return ""
+	}
+	return csvNumber(*v)
+}
+
+// formatAmount renders v with two decimals and thousands separators.
+func formatAmount(v float64) string {
+	s := strconv.FormatFloat(v, 'f', 2, 64)
+	sign := ""
+	if strings.HasPrefix(s, "-") {
+		sign, s = "-", s[1:]
+	}
+	whole, fraction, _ := strings.Cut(s, ".")
+	var b strings.Builder
+	for i, digit := range whole {
+		if i > 0 && (len(whole)-i)%3 == 0 {
+			b.WriteByte(',')
+		}
+		b.WriteRune(digit)
+	}
+	return sign + b.String() + "." + fraction
+}
+
+var statementTemplate = template.Must(template.New("pnl").Funcs(template.FuncMap{
+	"amount": formatAmount,
+	"percent": func(v *float64) string {
+		if v == nil {
+			return "–"
+		}
+		return strconv.FormatFloat(*v, 'f', 1, 64) + "%"
+	},
+	"points": func(v float64) string {
+		return strconv.FormatFloat(v, 'f', 1, 64) + " pts"
+	},
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Income statement{{with .Period}} {{.}}{{end}}</title>
+<style>
+	body { font-family: Georgia, serif; margin: 2em; color: #111; }
+	h1 { font-size: 1.4em; margin-bottom: 0; }
+	p.meta { color: #555; margin-top: 0.3em; }
+	table { border-collapse: collapse; width: 100%; font-size: 0.9em; }
+	th, td { padding: 0.3em 0.6em; text-align: right; white-space: nowrap; }
+	th:first-child, td:first-child { text-align: left; }
+	thead th { border-bottom: 2px solid #111; }
+	tr.section td, tr.subtotal td { font-weight: bold; }
+	tr.subtotal td { border-top: 1px solid #111; }
+	tr.ratio td { font-style: italic; color: #444; }
+	tr.line td:first-child { padding-left: 2em; }
+	@media print { body { margin: 0; } }
+</style>
+</head>
+<body>
+<h1>Income statement{{with .Period}} {{.}}{{end}}{{with .Department}} &middot; {{.}}{{end}}</h1>
+<p class="meta">{{.Range.Start.Format "2006-01-02"}} to {{.Range.End.Format "2006-01-02"}}, prior {{.PriorRange.Start.Format "2006-01-02"}} to {{.PriorRange.End.Format "2006-01-02"}}, {{.BudgetVersion}} budget</p>
+<table>
+<thead>
+<tr><th></th><th>Actual</th><th>Budget</th><th>Variance</th><th>%</th><th>Prior</th><th>Change</th><th>%</th></tr>
+</thead>
+<tbody>
+{{- range .Flatten}}
+{{- if eq .Kind "ratio"}}
+<tr class="ratio"><td>{{.Label}}</td><td>{{printf "%.1f%%" .Actual}}</td><td>{{printf "%.1f%%" .Budget}}</td><td>{{points .BudgetVariance}}</td><td></td><td>{{printf "%.1f%%" .Prior}}</td><td>{{points .PriorChange}}</td><td></td></tr>
+{{- else}}
+<tr class="{{.Kind}}"><td>{{.Label}}</td><td>{{amount .Actual}}</td><td>{{amount .Budget}}</td><td>{{amount .BudgetVariance}}</td><td>{{percent .BudgetVariancePct}}</td><td>{{amount .Prior}}</td><td>{{amount .PriorChange}}</td><td>{{percent .PriorChangePct}}</td></tr>
+{{- end}}
+{{- end}}
+</tbody>
+</table>
+</body>
+</html>
+`))
@@ -2,8 +2,10 @@ package api
 
 import (
 	"compress/gzip"
+	"crypto/subtle"
 	"log"
 	"net/http"
+	"os"
 	"strings"
 	"time"
 )
@@ -77,3 +79,33 @@ func ErrorHandler(next http.Handler) http.Handler {
 	})
 }
 
+// AdminOnly requires the bearer token in ADMIN_API_TOKEN. The wrapped
+// endpoints are disabled when no token is configured.
+func AdminOnly(next http.HandlerFunc) http.HandlerFunc {
+	token := os.Getenv("ADMIN_API_TOKEN")
+	return func(w http.ResponseWriter, r *http.Request) {
+		if token == "" {
+			jsonError(w, http.StatusServiceUnavailable, "Admin endpoints are disabled")
+			return
+		}
+		given := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(given), []byte(token)) != 1 {
+			jsonError(w, http.StatusUnauthorized, "Unauthorized")
+			return
+		}
+		next(w, r)
+	}
+}
+
+// AdminWrites serves GET and HEAD requests to anyone and requires the
+// AdminOnly token for every other method.
+func AdminWrites(next http.HandlerFunc) http.HandlerFunc {
+	admin := AdminOnly(next)
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet || r.Method == http.MethodHead {
+			next(w, r)
+			return
+		}
+		admin(w, r)
+	}
+}
@@ -0,0 +1,57 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAdminWrites(t *testing.T) {
+	t.Setenv("ADMIN_API_TOKEN", "secret")
+	handler := AdminWrites(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+	tests := []struct {
+		method string
+		token  string
+		want   int
+	}{
+		{http.MethodGet, "", http.StatusNoContent},
+		{http.MethodHead, "", http.StatusNoContent},
+		{http.MethodPost, "", http.StatusUnauthorized},
+		{http.MethodPut, "wrong", http.StatusUnauthorized},
+		{http.MethodDelete, "", http.StatusUnauthorized},
+		{http.MethodPost, "secret", http.StatusNoContent},
+		{http.MethodDelete, "secret", http.StatusNoContent},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.token, func(t *testing.T) {
+			r := httptest.NewRequest(tt.method, "/api/budgets", nil)
+			if tt.token != "" {
+				r.Header.Set("Authorization", "Bearer "+tt.token)
+			}
+			w := httptest.NewRecorder()
+			handler(w, r)
+			if w.Code != tt.want {
+				t.Errorf("status = %d, want %d", w.Code, tt.want)
+			}
+		})
+	}
+}
+
+func TestAdminWritesWithoutToken(t *testing.T) {
+	t.Setenv("ADMIN_API_TOKEN", "")
+	handler := AdminWrites(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+	for method, want := range map[string]int{
+		http.MethodGet:  http.StatusNoContent,
+		http.MethodPost: http.StatusServiceUnavailable,
+	} {
+		w := httptest.NewRecorder()
+		handler(w, httptest.NewRequest(method, "/api/budgets", nil))
+		if w.Code != want {
+			t.Errorf("%s status = %d, want %d", method, w.Code, want)
+		}
+	}
+}
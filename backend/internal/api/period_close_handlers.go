@@ -0,0 +1,92 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/KalebAsratemedhi/stock-dashboard/backend/internal/services"
+)
+
+// PeriodCloses lists period closes, reopened ones included and optionally of
+// one period, or closes a period. Closing takes the period, actor and reason.
+func (h *Handler) PeriodCloses(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		closes, err := h.periodCloseService.ListCloses(r.URL.Query().Get("period"))
+		if err != nil {
+			serviceError(w, err)
+			return
+		}
+		jsonResponse(w, http.StatusOK, closes)
+	case http.MethodPost:
+		var input services.PeriodCloseInput
+		if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+			jsonError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+		periodClose, err := h.periodCloseService.ClosePeriod(input)
+		if err != nil {
+			serviceError(w, err)
+			return
+		}
+		jsonResponse(w, http.StatusCreated, periodClose)
+	default:
+		jsonError(w, http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
+
+// ReopenPeriod lifts the close of a period, taking the period, actor and
+// reason.
+func (h *Handler) ReopenPeriod(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		jsonError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	var input services.PeriodCloseInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		jsonError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	periodClose, err := h.periodCloseService.ReopenPeriod(input)
+	if err != nil {
+		serviceError(w, err)
+		return
+	}
+
+	jsonResponse(w, http.StatusOK, periodClose)
+}
+
+// GetAdjustmentReport lists the metrics that arrived for closed periods over
+// start and end or a period, optionally for one department, with totals per
+// period and metric type.
+func (h *Handler) GetAdjustmentReport(w http.ResponseWriter, r *http.Request) {
+	start, end, err := h.parsePeriodRange(r)
+	if err != nil {
+		jsonError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	limit := 0
+	if value := r.URL.Query().Get("limit"); value != "" {
+		limit, err = strconv.Atoi(value)
+		if err != nil || limit <= 0 {
+			jsonError(w, http.StatusBadRequest, "Invalid limit parameter")
+			return
+		}
+	}
+
+	report, err := h.periodCloseService.GetAdjustmentReport(services.AdjustmentQuery{
+		Start:      start,
+		End:        end,
+		Department: r.URL.Query().Get("department"),
+		Limit:      limit,
+	})
+	if err != nil {
+		serviceError(w, err)
+		return
+	}
+
+	jsonResponse(w, http.StatusOK, report)
+}
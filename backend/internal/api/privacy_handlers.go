@@ -0,0 +1,91 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/KalebAsratemedhi/stock-dashboard/backend/internal/services"
+)
+
+// ExportPersonalData returns every row held about a user or customer and
+// records the export in the privacy audit log.
+func (h *Handler) ExportPersonalData(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		jsonError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	var input services.PrivacyRequestInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		jsonError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	export, err := h.privacyService.Export(input)
+	if err != nil {
+		serviceError(w, err)
+		return
+	}
+
+	jsonResponse(w, http.StatusOK, export)
+}
+
+// RequestErasure queues the deletion of a user's or customer's data. The
+// worker runs the erasure; poll the returned request for its outcome.
+func (h *Handler) RequestErasure(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		jsonError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	var input services.PrivacyRequestInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+		jsonError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	request, err := h.privacyService.RequestErasure(input)
+	if err != nil {
+		serviceError(w, err)
+		return
+	}
+
+	jsonResponse(w, http.StatusAccepted, request)
+}
+
+func (h *Handler) GetPrivacyRequests(w http.ResponseWriter, r *http.Request) {
+	limit := 0
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		var err error
+		limit, err = strconv.Atoi(limitStr)
+		if err != nil || limit <= 0 {
+			jsonError(w, http.StatusBadRequest, "Invalid limit parameter")
+			return
+		}
+	}
+
+	requests, err := h.privacyService.ListRequests(r.URL.Query().Get("kind"), r.URL.Query().Get("status"), limit)
+	if err != nil {
+		serviceError(w, err)
+		return
+	}
+
+	jsonResponse(w, http.StatusOK, requests)
+}
+
+func (h *Handler) GetPrivacyRequest(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r)
+	if err != nil {
+		jsonError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	request, err := h.privacyService.GetRequestByID(id)
+	if err != nil {
+		serviceError(w, err)
+		return
+	}
+
+	jsonResponse(w, http.StatusOK, request)
+}
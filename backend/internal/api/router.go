@@ -10,12 +10,53 @@ func NewRouter(h *Handler, wsHub *websocket.Hub) http.Handler {
 	mux := http.NewServeMux()
 
 	mux.HandleFunc("/health", h.HealthCheck)
+	mux.HandleFunc("/collect", h.Collect)
 	mux.HandleFunc("/api/stocks", h.GetStocks)
 	mux.HandleFunc("/api/stocks/range", h.GetStocksByTimeRange)
 	mux.HandleFunc("/api/sales", h.GetSales)
 	mux.HandleFunc("/api/sales/revenue", h.GetSalesRevenue)
+	mux.HandleFunc("/api/sales/categories", h.GetSalesRevenueByCategory)
+	mux.HandleFunc("/api/sales/targets", AdminWrites(h.SalesTargets))
+	mux.HandleFunc("/api/sales/targets/{id}", AdminWrites(h.SalesTarget))
+	mux.HandleFunc("/api/sales/attainment", h.GetSalesAttainment)
+	mux.HandleFunc("/api/sales/discounts", h.GetSalesDiscounts)
+	mux.HandleFunc("/api/sales/drivers", h.GetSalesDrivers)
+	mux.HandleFunc("/api/sales/geo", h.GetSalesGeo)
 	mux.HandleFunc("/api/events", h.GetUserEvents)
+	mux.HandleFunc("/api/events/counts", h.GetEventCounts)
+	mux.HandleFunc("/api/events/users", h.GetUniqueUsers)
+	mux.HandleFunc("/api/events/funnel", h.GetEventFunnel)
+	mux.HandleFunc("/api/events/sessions", h.GetEventSessions)
+	mux.HandleFunc("/api/events/paths", h.GetEventPaths)
+	mux.HandleFunc("/api/events/retention", h.GetEventRetention)
+	mux.HandleFunc("/api/events/geo", h.GetEventGeo)
+	mux.HandleFunc("/api/events/pages", h.GetEventPages)
+	mux.HandleFunc("/api/events/breakdown", h.GetEventBreakdown)
+	mux.HandleFunc("/api/events/schemas", h.GetEventSchemas)
+	mux.HandleFunc("/api/attribution", h.GetAttribution)
+	mux.HandleFunc("/api/attribution/identities", AdminOnly(h.LinkIdentity))
+	mux.HandleFunc("/api/experiments", AdminWrites(h.Experiments))
+	mux.HandleFunc("/api/experiments/{id}", AdminWrites(h.Experiment))
+	mux.HandleFunc("/api/experiments/{id}/results", h.GetExperimentResults)
+	mux.HandleFunc("/api/privacy/exports", AdminOnly(h.ExportPersonalData))
+	mux.HandleFunc("/api/privacy/erasures", AdminOnly(h.RequestErasure))
+	mux.HandleFunc("/api/privacy/requests", AdminOnly(h.GetPrivacyRequests))
+	mux.HandleFunc("/api/privacy/requests/{id}", AdminOnly(h.GetPrivacyRequest))
 	mux.HandleFunc("/api/metrics", h.GetFinancialMetrics)
+	mux.HandleFunc("/api/metrics/departments", h.GetDepartmentMetrics)
+	mux.HandleFunc("/api/metrics/timeseries", h.GetFinancialTimeSeries)
+	mux.HandleFunc("/api/metrics/budget-vs-actual", h.GetBudgetVsActual)
+	mux.HandleFunc("/api/metrics/rollup", h.GetCostCenterRollup)
+	mux.HandleFunc("/api/budgets", AdminWrites(h.Budgets))
+	mux.HandleFunc("/api/budgets/{id}", AdminWrites(h.Budget))
+	mux.HandleFunc("/api/financials/pnl", h.GetIncomeStatement)
+	mux.HandleFunc("/api/financials/pnl/rows", h.GetIncomeStatementRows)
+	mux.HandleFunc("/api/financials/closes", AdminOnly(h.PeriodCloses))
+	mux.HandleFunc("/api/financials/closes/reopen", AdminOnly(h.ReopenPeriod))
+	mux.HandleFunc("/api/financials/adjustments", h.GetAdjustmentReport)
+	mux.HandleFunc("/api/cost-centers", AdminOnly(h.CostCenters))
+	mux.HandleFunc("/api/cost-centers/{id}", AdminOnly(h.CostCenter))
+	mux.HandleFunc("/api/cost-centers/{id}/moves", AdminOnly(h.MoveCostCenter))
 	mux.HandleFunc("/ws", func(w http.ResponseWriter, r *http.Request) {
 		websocket.HandleWebSocket(wsHub, w, r)
 	})
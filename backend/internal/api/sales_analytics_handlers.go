@@ -0,0 +1,74 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/KalebAsratemedhi/stock-dashboard/backend/internal/services"
+)
+
+func (h *Handler) GetSalesDiscounts(w http.ResponseWriter, r *http.Request) {
+	start, end, err := parseTimeRange(r)
+	if err != nil {
+		jsonError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	analysis, err := h.salesAnalyticsService.GetDiscountAnalysis(start, end, r.URL.Query().Get("group_by"))
+	if err != nil {
+		serviceError(w, err)
+		return
+	}
+
+	jsonResponse(w, http.StatusOK, analysis)
+}
+
+func (h *Handler) GetSalesDrivers(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	var bounds [4]time.Time
+	for i, name := range []string{"a_start", "a_end", "b_start", "b_end"} {
+		value := query.Get(name)
+		if value == "" {
+			jsonError(w, http.StatusBadRequest, "Missing required parameters: a_start, a_end, b_start, b_end")
+			return
+		}
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			jsonError(w, http.StatusBadRequest, "Invalid "+name+" time format")
+			return
+		}
+		bounds[i] = t
+	}
+
+	var dimensions []string
+	for _, dimension := range strings.Split(query.Get("dimensions"), ",") {
+		if dimension = strings.TrimSpace(dimension); dimension != "" {
+			dimensions = append(dimensions, dimension)
+		}
+	}
+
+	limit := 0
+	if limitStr := query.Get("limit"); limitStr != "" {
+		var err error
+		limit, err = strconv.Atoi(limitStr)
+		if err != nil {
+			jsonError(w, http.StatusBadRequest, "Invalid limit parameter")
+			return
+		}
+	}
+
+	drivers, err := h.salesAnalyticsService.GetRevenueDrivers(
+		services.TimeRange{Start: bounds[0], End: bounds[1]},
+		services.TimeRange{Start: bounds[2], End: bounds[3]},
+		dimensions,
+		limit,
+	)
+	if err != nil {
+		serviceError(w, err)
+		return
+	}
+
+	jsonResponse(w, http.StatusOK, drivers)
+}
@@ -0,0 +1,93 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/KalebAsratemedhi/stock-dashboard/backend/internal/models"
+)
+
+func (h *Handler) SalesTargets(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		targets, err := h.salesTargetService.ListTargets(r.URL.Query().Get("region"), r.URL.Query().Get("category"))
+		if err != nil {
+			serviceError(w, err)
+			return
+		}
+		jsonResponse(w, http.StatusOK, targets)
+	case http.MethodPost:
+		var target models.SalesTarget
+		if err := json.NewDecoder(r.Body).Decode(&target); err != nil {
+			jsonError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+		target.ID = 0
+		if err := h.salesTargetService.CreateTarget(&target); err != nil {
+			serviceError(w, err)
+			return
+		}
+		jsonResponse(w, http.StatusCreated, target)
+	default:
+		jsonError(w, http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
+
+func (h *Handler) SalesTarget(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r)
+	if err != nil {
+		jsonError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		target, err := h.salesTargetService.GetTargetByID(id)
+		if err != nil {
+			serviceError(w, err)
+			return
+		}
+		jsonResponse(w, http.StatusOK, target)
+	case http.MethodPut:
+		var target models.SalesTarget
+		if err := json.NewDecoder(r.Body).Decode(&target); err != nil {
+			jsonError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+		target.ID = id
+		if err := h.salesTargetService.UpdateTarget(&target); err != nil {
+			serviceError(w, err)
+			return
+		}
+		jsonResponse(w, http.StatusOK, target)
+	case http.MethodDelete:
+		if err := h.salesTargetService.DeleteTarget(id); err != nil {
+			serviceError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		jsonError(w, http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
+
+func (h *Handler) GetSalesAttainment(w http.ResponseWriter, r *http.Request) {
+	at := time.Now()
+	if atStr := r.URL.Query().Get("at"); atStr != "" {
+		var err error
+		at, err = time.Parse(time.RFC3339, atStr)
+		if err != nil {
+			jsonError(w, http.StatusBadRequest, "Invalid at time format")
+			return
+		}
+	}
+
+	attainment, err := h.salesTargetService.GetAttainment(at, r.URL.Query().Get("region"), r.URL.Query().Get("category"))
+	if err != nil {
+		serviceError(w, err)
+		return
+	}
+
+	jsonResponse(w, http.StatusOK, attainment)
+}
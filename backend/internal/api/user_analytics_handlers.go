@@ -0,0 +1,392 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/KalebAsratemedhi/stock-dashboard/backend/internal/eventschema"
+	"github.com/KalebAsratemedhi/stock-dashboard/backend/internal/repository"
+	"github.com/KalebAsratemedhi/stock-dashboard/backend/internal/services"
+)
+
+func (h *Handler) GetUniqueUsers(w http.ResponseWriter, r *http.Request) {
+	h, err := h.withBotTraffic(r)
+	if err != nil {
+		jsonError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	query := r.URL.Query()
+
+	at := time.Now()
+	if atStr := query.Get("at"); atStr != "" {
+		at, err = time.Parse(time.RFC3339, atStr)
+		if err != nil {
+			jsonError(w, http.StatusBadRequest, "Invalid at time format")
+			return
+		}
+	}
+
+	active, err := h.uniqueUserService.GetActiveUsers(at)
+	if err != nil {
+		serviceError(w, err)
+		return
+	}
+	response := map[string]interface{}{"active": active}
+
+	if query.Get("start") != "" || query.Get("end") != "" {
+		start, end, err := parseTimeRange(r)
+		if err != nil {
+			jsonError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		counts, err := h.uniqueUserService.CountUnique(start, end)
+		if err != nil {
+			serviceError(w, err)
+			return
+		}
+		response["range"] = counts
+
+		if interval := query.Get("interval"); interval != "" {
+			buckets, err := h.uniqueUserService.CountUniqueByBucket(start, end, interval)
+			if err != nil {
+				serviceError(w, err)
+				return
+			}
+			response["buckets"] = buckets
+		}
+	}
+
+	jsonResponse(w, http.StatusOK, response)
+}
+
+type funnelRequestBody struct {
+	Start     time.Time                `json:"start"`
+	End       time.Time                `json:"end"`
+	Steps     []repository.EventFilter `json:"steps"`
+	Window    string                   `json:"window"`
+	Identity  string                   `json:"identity"`
+	Breakdown string                   `json:"breakdown"`
+}
+
+func (h *Handler) GetEventFunnel(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		jsonError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	h, err := h.withBotTraffic(r)
+	if err != nil {
+		jsonError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var body funnelRequestBody
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		jsonError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	window := 24 * time.Hour
+	if body.Window != "" {
+		window, err = time.ParseDuration(body.Window)
+		if err != nil {
+			jsonError(w, http.StatusBadRequest, "Invalid window duration")
+			return
+		}
+	}
+
+	funnel, err := h.eventAnalyticsService.GetFunnel(&services.FunnelRequest{
+		Start:     body.Start,
+		End:       body.End,
+		Steps:     body.Steps,
+		Window:    window,
+		Identity:  body.Identity,
+		Breakdown: body.Breakdown,
+	})
+	if err != nil {
+		serviceError(w, err)
+		return
+	}
+
+	jsonResponse(w, http.StatusOK, funnel)
+}
+
+func (h *Handler) GetEventSessions(w http.ResponseWriter, r *http.Request) {
+	h, err := h.withBotTraffic(r)
+	if err != nil {
+		jsonError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	start, end, err := parseTimeRange(r)
+	if err != nil {
+		jsonError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	limit := 0
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		limit, err = strconv.Atoi(limitStr)
+		if err != nil {
+			jsonError(w, http.StatusBadRequest, "Invalid limit parameter")
+			return
+		}
+	}
+
+	report, err := h.sessionService.GetSessionReport(
+		start,
+		end,
+		r.URL.Query().Get("interval"),
+		r.URL.Query().Get("page"),
+		limit,
+	)
+	if err != nil {
+		serviceError(w, err)
+		return
+	}
+
+	jsonResponse(w, http.StatusOK, report)
+}
+
+func (h *Handler) GetEventPaths(w http.ResponseWriter, r *http.Request) {
+	h, err := h.withBotTraffic(r)
+	if err != nil {
+		jsonError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	start, end, err := parseTimeRange(r)
+	if err != nil {
+		jsonError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var steps, limit int
+	for name, target := range map[string]*int{"steps": &steps, "limit": &limit} {
+		if value := r.URL.Query().Get(name); value != "" {
+			*target, err = strconv.Atoi(value)
+			if err != nil {
+				jsonError(w, http.StatusBadRequest, "Invalid "+name+" parameter")
+				return
+			}
+		}
+	}
+
+	report, err := h.eventAnalyticsService.GetPaths(
+		start,
+		end,
+		r.URL.Query().Get("page"),
+		r.URL.Query().Get("direction"),
+		steps,
+		limit,
+	)
+	if err != nil {
+		serviceError(w, err)
+		return
+	}
+
+	jsonResponse(w, http.StatusOK, report)
+}
+
+func (h *Handler) GetEventRetention(w http.ResponseWriter, r *http.Request) {
+	h, err := h.withBotTraffic(r)
+	if err != nil {
+		jsonError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	start, end, err := parseTimeRange(r)
+	if err != nil {
+		jsonError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	periods := 0
+	if value := r.URL.Query().Get("periods"); value != "" {
+		periods, err = strconv.Atoi(value)
+		if err != nil {
+			jsonError(w, http.StatusBadRequest, "Invalid periods parameter")
+			return
+		}
+	}
+
+	report, err := h.eventAnalyticsService.GetRetention(
+		start,
+		end,
+		r.URL.Query().Get("period"),
+		r.URL.Query().Get("cohort_event"),
+		r.URL.Query().Get("return_event"),
+		periods,
+	)
+	if err != nil {
+		serviceError(w, err)
+		return
+	}
+
+	jsonResponse(w, http.StatusOK, report)
+}
+
+func (h *Handler) GetEventPages(w http.ResponseWriter, r *http.Request) {
+	h, err := h.withBotTraffic(r)
+	if err != nil {
+		jsonError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	start, end, err := parseTimeRange(r)
+	if err != nil {
+		jsonError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	query := services.PageReportQuery{
+		Sort:     r.URL.Query().Get("sort"),
+		Interval: r.URL.Query().Get("interval"),
+	}
+	switch r.URL.Query().Get("order") {
+	case "", "desc":
+	case "asc":
+		query.Ascending = true
+	default:
+		jsonError(w, http.StatusBadRequest, "Invalid order parameter, expected asc or desc")
+		return
+	}
+	for name, target := range map[string]*int{"limit": &query.Limit, "offset": &query.Offset} {
+		if value := r.URL.Query().Get(name); value != "" {
+			*target, err = strconv.Atoi(value)
+			if err != nil {
+				jsonError(w, http.StatusBadRequest, "Invalid "+name+" parameter")
+				return
+			}
+		}
+	}
+
+	report, err := h.userEventService.GetTopPages(start, end, query)
+	if err != nil {
+		serviceError(w, err)
+		return
+	}
+
+	jsonResponse(w, http.StatusOK, report)
+}
+
+// GetEventBreakdown aggregates events by a device, browser, OS or country
+// dimension, optionally for one event type.
+func (h *Handler) GetEventBreakdown(w http.ResponseWriter, r *http.Request) {
+	h, err := h.withBotTraffic(r)
+	if err != nil {
+		jsonError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	start, end, err := parseTimeRange(r)
+	if err != nil {
+		jsonError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	dimension := r.URL.Query().Get("dimension")
+	if dimension == "" {
+		jsonError(w, http.StatusBadRequest, "Missing required parameter: dimension")
+		return
+	}
+	limit := 0
+	if value := r.URL.Query().Get("limit"); value != "" {
+		limit, err = strconv.Atoi(value)
+		if err != nil || limit <= 0 {
+			jsonError(w, http.StatusBadRequest, "Invalid limit parameter")
+			return
+		}
+	}
+
+	rows, err := h.userEventService.GetEventsByDimension(start, end, dimension, r.URL.Query().Get("type"), limit)
+	if err != nil {
+		serviceError(w, err)
+		return
+	}
+
+	jsonResponse(w, http.StatusOK, map[string]interface{}{
+		"dimension": dimension,
+		"start":     start,
+		"end":       end,
+		"values":    rows,
+	})
+}
+
+// metadataPredicates parses the meta.* parameters of a request, such as
+// meta.plan=pro or meta.amount>100. The raw query is split by hand since
+// url.Values would read meta.amount>100 as a key without a value.
+func metadataPredicates(r *http.Request) ([]repository.MetadataPredicate, error) {
+	var predicates []repository.MetadataPredicate
+	for _, part := range strings.Split(r.URL.RawQuery, "&") {
+		expr, err := url.QueryUnescape(part)
+		if err != nil || !strings.HasPrefix(expr, services.MetadataPrefix) {
+			continue
+		}
+		predicate, err := services.ParseMetadataPredicate(expr)
+		if err != nil {
+			return nil, err
+		}
+		predicates = append(predicates, predicate)
+	}
+	return predicates, nil
+}
+
+// queryUserEvents serves /api/events requests filtering on metadata. With
+// group_by=meta.<key> it returns aggregates per value of the key, summing
+// value=meta.<key> when given, instead of the events themselves.
+func (h *Handler) queryUserEvents(w http.ResponseWriter, r *http.Request, predicates []repository.MetadataPredicate) {
+	query := repository.EventQuery{
+		EventType: r.URL.Query().Get("type"),
+		Metadata:  predicates,
+	}
+	if r.URL.Query().Get("start") != "" || r.URL.Query().Get("end") != "" {
+		start, end, err := parseTimeRange(r)
+		if err != nil {
+			jsonError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		query.Start, query.End = start, end
+	}
+	if value := r.URL.Query().Get("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit <= 0 {
+			jsonError(w, http.StatusBadRequest, "Invalid limit parameter")
+			return
+		}
+		query.Limit = limit
+	}
+
+	groupBy := r.URL.Query().Get("group_by")
+	if groupBy == "" {
+		events, err := h.userEventService.QueryEvents(query)
+		if err != nil {
+			serviceError(w, err)
+			return
+		}
+		jsonResponse(w, http.StatusOK, events)
+		return
+	}
+
+	value := r.URL.Query().Get("value")
+	groups, err := h.userEventService.AggregateByMetadata(query, groupBy, value)
+	if err != nil {
+		serviceError(w, err)
+		return
+	}
+	jsonResponse(w, http.StatusOK, map[string]interface{}{
+		"group_by": groupBy,
+		"value":    value,
+		"groups":   groups,
+	})
+}
+
+// GetEventSchemas lists the metadata schemas events are validated against,
+// keyed by event type.
+func (h *Handler) GetEventSchemas(w http.ResponseWriter, r *http.Request) {
+	jsonResponse(w, http.StatusOK, eventschema.All())
+}
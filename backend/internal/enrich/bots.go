@@ -0,0 +1,155 @@
+package enrich
+
+import (
+	"bufio"
+	_ "embed"
+	"fmt"
+	"io"
+	"net/netip"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+//go:embed datacenters.csv
+var bundledDatacenterRanges string
+
+const (
+	// botRateWindow and botRateLimit bound how many requests one session may
+	// send before it is treated as automated for botFlagTTL.
+	botRateWindow = 10 * time.Second
+	botRateLimit  = 30
+	botFlagTTL    = 30 * time.Minute
+)
+
+// BotDetector flags invalid traffic by user agent, datacenter source address
+// and per-session request rate. The rate state is kept in memory, so each API
+// instance judges the sessions it receives.
+type BotDetector struct {
+	datacenters []netip.Prefix
+
+	mu       sync.Mutex
+	sessions map[string]*sessionRate
+	swept    time.Time
+}
+
+type sessionRate struct {
+	requests     []time.Time
+	last         time.Time
+	flaggedUntil time.Time
+}
+
+// NewBotDetector loads the ranges at DATACENTER_RANGES_PATH, falling back to
+// the bundled sample list when the variable is unset.
+func NewBotDetector() (*BotDetector, error) {
+	if path := os.Getenv("DATACENTER_RANGES_PATH"); path != "" {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open datacenter ranges: %w", err)
+		}
+		defer f.Close()
+		return LoadBotDetector(f)
+	}
+	return LoadBotDetector(strings.NewReader(bundledDatacenterRanges))
+}
+
+// LoadBotDetector parses a network[,provider] list. Blank lines and lines
+// starting with # are ignored.
+func LoadBotDetector(r io.Reader) (*BotDetector, error) {
+	d := &BotDetector{sessions: make(map[string]*sessionRate)}
+	scanner := bufio.NewScanner(r)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		network, _, _ := strings.Cut(text, ",")
+		prefix, err := netip.ParsePrefix(strings.TrimSpace(network))
+		if err != nil {
+			return nil, fmt.Errorf("datacenter ranges line %d: %w", line, err)
+		}
+		d.datacenters = append(d.datacenters, prefix.Masked())
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// IsBot reports whether an event looks automated by its user agent or
+// source address. addr must be the address the event was received from, so
+// that clients cannot dodge the datacenter check by forging forwarding
+// headers.
+func (d *BotDetector) IsBot(userAgent, addr string) bool {
+	if d == nil {
+		return false
+	}
+	return IsBotUserAgent(userAgent) || d.isDatacenter(addr)
+}
+
+func (d *BotDetector) isDatacenter(addr string) bool {
+	ip, err := netip.ParseAddr(addr)
+	if err != nil {
+		return false
+	}
+	ip = ip.Unmap()
+	for _, prefix := range d.datacenters {
+		if prefix.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// RecordRequest counts one request from a session and reports whether the
+// session has sent more than botRateLimit requests within botRateWindow, now
+// or within the last botFlagTTL. at must be the time the server received the
+// request rather than a client-supplied timestamp, and a request carrying
+// several events of the session counts once.
+func (d *BotDetector) RecordRequest(sessionID string, at time.Time) bool {
+	if d == nil || sessionID == "" {
+		return false
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.sweep(at)
+	rate, ok := d.sessions[sessionID]
+	if !ok {
+		rate = &sessionRate{}
+		d.sessions[sessionID] = rate
+	}
+
+	// Concurrent requests may be recorded slightly out of order, so keep
+	// every request within the window on either side of at.
+	kept := rate.requests[:0]
+	for _, t := range rate.requests {
+		if at.Sub(t) < botRateWindow && t.Sub(at) < botRateWindow {
+			kept = append(kept, t)
+		}
+	}
+	rate.requests = append(kept, at)
+	if at.After(rate.last) {
+		rate.last = at
+	}
+	if len(rate.requests) > botRateLimit {
+		rate.flaggedUntil = at.Add(botFlagTTL)
+	}
+	return at.Before(rate.flaggedUntil)
+}
+
+// sweep drops idle sessions at most once per rate window to bound memory.
+func (d *BotDetector) sweep(now time.Time) {
+	if now.Sub(d.swept) < botRateWindow {
+		return
+	}
+	d.swept = now
+	for id, rate := range d.sessions {
+		if now.Sub(rate.last) >= botRateWindow && !now.Before(rate.flaggedUntil) {
+			delete(d.sessions, id)
+		}
+	}
+}
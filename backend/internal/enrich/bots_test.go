@@ -0,0 +1,119 @@
+package enrich
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRecordRequest(t *testing.T) {
+	start := time.Date(2026, time.March, 1, 12, 0, 0, 0, time.UTC)
+	at := func(seconds float64) time.Time {
+		return start.Add(time.Duration(seconds * float64(time.Second)))
+	}
+	repeat := func(n int, seconds float64) []time.Time {
+		times := make([]time.Time, n)
+		for i := range times {
+			times[i] = at(seconds)
+		}
+		return times
+	}
+	type request struct {
+		session string
+		at      time.Time
+	}
+	session := func(id string, times ...time.Time) []request {
+		requests := make([]request, len(times))
+		for i, t := range times {
+			requests[i] = request{id, t}
+		}
+		return requests
+	}
+	concat := func(parts ...[]request) []request {
+		var all []request
+		for _, part := range parts {
+			all = append(all, part...)
+		}
+		return all
+	}
+
+	tests := []struct {
+		name     string
+		requests []request
+		want     bool
+	}{
+		{"at the limit", session("a", repeat(30, 1)...), false},
+		{"over the limit", session("a", repeat(31, 1)...), true},
+		{"spread beyond the window", concat(
+			session("a", repeat(30, 0)...),
+			session("a", at(10)),
+		), false},
+		{"flag outlives the window", concat(
+			session("a", repeat(31, 0)...),
+			session("a", at(20*60)),
+		), true},
+		{"flag expires", concat(
+			session("a", repeat(31, 0)...),
+			session("a", at(30*60)),
+		), false},
+		{"sessions counted apart", concat(
+			session("a", repeat(20, 1)...),
+			session("b", repeat(20, 1)...),
+		), false},
+		{"out of order within the window", concat(
+			session("a", repeat(15, 9)...),
+			session("a", repeat(16, 1)...),
+		), true},
+		{"sweep keeps sessions whose latest request is recent", concat(
+			session("b", at(0)),
+			session("a", repeat(29, 9.5)...),
+			session("a", at(0.2)),
+			session("c", at(10.5)),
+			session("a", at(11), at(11.1)),
+		), true},
+		{"anonymous requests are not counted", session("", repeat(31, 1)...), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, err := LoadBotDetector(strings.NewReader(""))
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got bool
+			for _, r := range tt.requests {
+				got = d.RecordRequest(r.session, r.at)
+			}
+			if got != tt.want {
+				t.Errorf("RecordRequest() = %v after the last request, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsBot(t *testing.T) {
+	d, err := LoadBotDetector(strings.NewReader("# test ranges\n203.0.113.0/24,example\n\n2001:db8::/32\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	browser := "Mozilla/5.0 (X11; Linux x86_64; rv:121.0) Gecko/20100101 Firefox/121.0"
+	tests := []struct {
+		name      string
+		userAgent string
+		addr      string
+		want      bool
+	}{
+		{"browser", browser, "198.51.100.7", false},
+		{"crawler", "Googlebot/2.1 (+http://www.google.com/bot.html)", "198.51.100.7", true},
+		{"datacenter", browser, "203.0.113.9", true},
+		{"mapped datacenter address", browser, "::ffff:203.0.113.9", true},
+		{"datacenter IPv6", browser, "2001:db8::1", true},
+		{"unparseable address", browser, "unknown", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := d.IsBot(tt.userAgent, tt.addr); got != tt.want {
+				t.Errorf("IsBot(%q, %q) = %v, want %v", tt.userAgent, tt.addr, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,101 @@
+package enrich
+
+import (
+	"bufio"
+	_ "embed"
+	"fmt"
+	"io"
+	"net/netip"
+	"os"
+	"sort"
+	"strings"
+)
+
+//go:embed geoip.csv
+var bundledGeoTable string
+
+// GeoLocation is the result of an IP lookup.
+type GeoLocation struct {
+	Country string
+	City    string
+}
+
+type geoRange struct {
+	prefix   netip.Prefix
+	location GeoLocation
+}
+
+// GeoDB resolves IP addresses to a country and city using a local
+// network,country,city table. It never makes network calls.
+type GeoDB struct {
+	ranges []geoRange
+}
+
+// NewGeoDB loads the table at GEOIP_TABLE_PATH, falling back to the bundled
+// sample table when the variable is unset.
+func NewGeoDB() (*GeoDB, error) {
+	if path := os.Getenv("GEOIP_TABLE_PATH"); path != "" {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open GeoIP table: %w", err)
+		}
+		defer f.Close()
+		return LoadGeoDB(f)
+	}
+	return LoadGeoDB(strings.NewReader(bundledGeoTable))
+}
+
+// LoadGeoDB parses a network,country,city table. Blank lines and lines
+// starting with # are ignored.
+func LoadGeoDB(r io.Reader) (*GeoDB, error) {
+	db := &GeoDB{}
+	scanner := bufio.NewScanner(r)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		fields := strings.Split(text, ",")
+		if len(fields) < 2 {
+			return nil, fmt.Errorf("GeoIP table line %d: expected network,country[,city]", line)
+		}
+		prefix, err := netip.ParsePrefix(strings.TrimSpace(fields[0]))
+		if err != nil {
+			return nil, fmt.Errorf("GeoIP table line %d: %w", line, err)
+		}
+		location := GeoLocation{Country: strings.TrimSpace(fields[1])}
+		if len(fields) > 2 {
+			location.City = strings.TrimSpace(fields[2])
+		}
+		db.ranges = append(db.ranges, geoRange{prefix: prefix.Masked(), location: location})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	// Most specific networks first, so the first match is the best one.
+	sort.SliceStable(db.ranges, func(i, j int) bool {
+		return db.ranges[i].prefix.Bits() > db.ranges[j].prefix.Bits()
+	})
+	return db, nil
+}
+
+// Lookup returns the location of addr, if the table covers it.
+func (db *GeoDB) Lookup(addr string) (GeoLocation, bool) {
+	if db == nil {
+		return GeoLocation{}, false
+	}
+	ip, err := netip.ParseAddr(addr)
+	if err != nil {
+		return GeoLocation{}, false
+	}
+	ip = ip.Unmap()
+	for _, r := range db.ranges {
+		if r.prefix.Contains(ip) {
+			return r.location, true
+		}
+	}
+	return GeoLocation{}, false
+}
@@ -0,0 +1,153 @@
+package enrich
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// pseudonymHexLength is the number of hex digits of the HMAC kept in a
+// pseudonym; with the key version it fits the 50 character user_id columns.
+const pseudonymHexLength = 32
+
+var pseudonymVersionPattern = regexp.MustCompile(`^[a-z0-9]{1,8}$`)
+
+type pseudonymKey struct {
+	version string
+	secret  []byte
+}
+
+// Pseudonymizer replaces IDs with keyed HMAC-SHA256 pseudonyms of the form
+// "<version>.<hex>". New IDs are pseudonymized with the current key; retired
+// keys are kept so that privacy requests can still find rows written under
+// them. Dropping a retired key leaves its pseudonyms unlinkable.
+type Pseudonymizer struct {
+	keys []pseudonymKey
+}
+
+// NewPseudonymizer reads PSEUDONYM_KEYS, which is required: without it user
+// and customer IDs would be stored as received.
+func NewPseudonymizer() (*Pseudonymizer, error) {
+	spec := os.Getenv("PSEUDONYM_KEYS")
+	if spec == "" {
+		return nil, errors.New("PSEUDONYM_KEYS is not set")
+	}
+	return ParsePseudonymKeys(spec)
+}
+
+// ParsePseudonymKeys parses a comma separated list of version:secret pairs,
+// current key first, e.g. "k2:new-secret,k1:old-secret".
+func ParsePseudonymKeys(spec string) (*Pseudonymizer, error) {
+	p := &Pseudonymizer{}
+	seen := make(map[string]bool)
+	for _, entry := range strings.Split(spec, ",") {
+		version, secret, ok := strings.Cut(strings.TrimSpace(entry), ":")
+		if !ok || !pseudonymVersionPattern.MatchString(version) || secret == "" {
+			return nil, fmt.Errorf("invalid pseudonym key %q: want version:secret", version)
+		}
+		if seen[version] {
+			return nil, fmt.Errorf("duplicate pseudonym key version %q", version)
+		}
+		seen[version] = true
+		p.keys = append(p.keys, pseudonymKey{version: version, secret: []byte(secret)})
+	}
+	return p, nil
+}
+
+// Pseudonymize returns the pseudonym of id under the current key. Empty IDs,
+// and all IDs on a nil Pseudonymizer, are returned unchanged.
+func (p *Pseudonymizer) Pseudonymize(id string) string {
+	if p == nil || id == "" {
+		return id
+	}
+	return p.keys[0].pseudonym(id)
+}
+
+// Candidates returns every form id may be stored under: the ID itself and its
+// pseudonym under each known key.
+func (p *Pseudonymizer) Candidates(id string) []string {
+	candidates := []string{id}
+	if p == nil || id == "" {
+		return candidates
+	}
+	for _, key := range p.keys {
+		candidates = append(candidates, key.pseudonym(id))
+	}
+	return candidates
+}
+
+// Seal encrypts id under the current key as "<version>.<base64>", for IDs
+// that must be kept in recoverable form for a while, such as the subject of
+// a pending erasure. Open reverses it, with retired keys as well.
+func (p *Pseudonymizer) Seal(id string) (string, error) {
+	if p == nil {
+		return "", errors.New("no pseudonym keys configured")
+	}
+	key := p.keys[0]
+	aead, err := key.aead()
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := aead.Seal(nonce, nonce, []byte(id), []byte(key.version))
+	return key.version + "." + base64.RawURLEncoding.EncodeToString(sealed), nil
+}
+
+// Open decrypts a value produced by Seal.
+func (p *Pseudonymizer) Open(sealed string) (string, error) {
+	version, encoded, ok := strings.Cut(sealed, ".")
+	if p == nil || !ok {
+		return "", errors.New("invalid sealed value")
+	}
+	for _, key := range p.keys {
+		if key.version != version {
+			continue
+		}
+		data, err := base64.RawURLEncoding.DecodeString(encoded)
+		if err != nil {
+			return "", fmt.Errorf("invalid sealed value: %w", err)
+		}
+		aead, err := key.aead()
+		if err != nil {
+			return "", err
+		}
+		if len(data) < aead.NonceSize() {
+			return "", errors.New("invalid sealed value")
+		}
+		id, err := aead.Open(nil, data[:aead.NonceSize()], data[aead.NonceSize():], []byte(version))
+		if err != nil {
+			return "", fmt.Errorf("invalid sealed value: %w", err)
+		}
+		return string(id), nil
+	}
+	return "", fmt.Errorf("unknown pseudonym key version %q", version)
+}
+
+// aead derives an AES-256-GCM cipher from the key's secret. The key is a
+// plain hash rather than an HMAC so that no pseudonym can ever reveal it.
+func (k pseudonymKey) aead() (cipher.AEAD, error) {
+	key := sha256.Sum256(append([]byte("seal:"), k.secret...))
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func (k pseudonymKey) pseudonym(id string) string {
+	mac := hmac.New(sha256.New, k.secret)
+	mac.Write([]byte(id))
+	return k.version + "." + hex.EncodeToString(mac.Sum(nil))[:pseudonymHexLength]
+}
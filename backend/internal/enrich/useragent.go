@@ -0,0 +1,140 @@
+package enrich
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+//go:embed useragents.json
+var bundledUserAgentRules []byte
+
+const (
+	// browserVersionParts and osVersionParts are how many dot-separated
+	// version components are kept, which bounds breakdown cardinality.
+	browserVersionParts = 1
+	osVersionParts      = 2
+
+	unknownUserAgentValue = "other"
+)
+
+// UserAgent is what the bundled rule set derives from a User-Agent header.
+type UserAgent struct {
+	Device         string
+	Browser        string
+	BrowserVersion string
+	OS             string
+	OSVersion      string
+	IsBot          bool
+}
+
+// userAgentRule matches a lower-cased User-Agent. The first capture group of
+// Pattern, if any, is the version; Versions renames versions, such as Windows
+// NT kernel versions to release names.
+type userAgentRule struct {
+	Name     string            `json:"name"`
+	Class    string            `json:"class"`
+	Pattern  string            `json:"pattern"`
+	Unless   string            `json:"unless"`
+	Versions map[string]string `json:"versions"`
+
+	pattern *regexp.Regexp
+	unless  *regexp.Regexp
+}
+
+// userAgentRules are evaluated in file order; the first matching rule of each
+// list wins, so more specific rules come first.
+type userAgentRules struct {
+	Bots     []string         `json:"bots"`
+	Devices  []*userAgentRule `json:"devices"`
+	Browsers []*userAgentRule `json:"browsers"`
+	OS       []*userAgentRule `json:"os"`
+}
+
+var defaultUserAgentRules = mustLoadUserAgentRules(bundledUserAgentRules)
+
+func mustLoadUserAgentRules(data []byte) *userAgentRules {
+	rules := &userAgentRules{}
+	if err := json.Unmarshal(data, rules); err != nil {
+		panic(fmt.Sprintf("invalid bundled user agent rules: %v", err))
+	}
+	for _, list := range [][]*userAgentRule{rules.Devices, rules.Browsers, rules.OS} {
+		for _, rule := range list {
+			rule.pattern = regexp.MustCompile(rule.Pattern)
+			if rule.Unless != "" {
+				rule.unless = regexp.MustCompile(rule.Unless)
+			}
+		}
+	}
+	return rules
+}
+
+// ParseUserAgent classifies a User-Agent string with the bundled rule set.
+// Unrecognised browsers and operating systems yield "other" and unrecognised
+// devices "desktop"; an empty string yields an empty result.
+func ParseUserAgent(ua string) UserAgent {
+	if ua == "" {
+		return UserAgent{}
+	}
+	lower := strings.ToLower(ua)
+	rules := defaultUserAgentRules
+
+	result := UserAgent{Device: "desktop", Browser: unknownUserAgentValue, OS: unknownUserAgentValue}
+	if rule, _ := matchUserAgentRule(rules.Devices, lower); rule != nil {
+		result.Device = rule.Class
+	}
+	if rule, version := matchUserAgentRule(rules.Browsers, lower); rule != nil {
+		result.Browser = rule.Name
+		result.BrowserVersion = truncateVersion(version, browserVersionParts)
+	}
+	if rule, version := matchUserAgentRule(rules.OS, lower); rule != nil {
+		result.OS = rule.Name
+		if name, ok := rule.Versions[version]; ok {
+			version = name
+		}
+		result.OSVersion = truncateVersion(version, osVersionParts)
+	}
+	result.IsBot = rules.isBot(lower)
+	return result
+}
+
+// IsBotUserAgent reports whether ua identifies a crawler or HTTP library.
+func IsBotUserAgent(ua string) bool {
+	return defaultUserAgentRules.isBot(strings.ToLower(ua))
+}
+
+func (r *userAgentRules) isBot(lower string) bool {
+	for _, token := range r.Bots {
+		if strings.Contains(lower, token) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchUserAgentRule(rules []*userAgentRule, lower string) (*userAgentRule, string) {
+	for _, rule := range rules {
+		match := rule.pattern.FindStringSubmatch(lower)
+		if match == nil || rule.unless != nil && rule.unless.MatchString(lower) {
+			continue
+		}
+		version := ""
+		if len(match) > 1 {
+			version = strings.ReplaceAll(match[1], "_", ".")
+		}
+		return rule, version
+	}
+	return nil, ""
+}
+
+// truncateVersion keeps the first parts components of a dotted version.
+// Non-numeric versions, such as release names, are returned unchanged.
+func truncateVersion(version string, parts int) string {
+	fields := strings.Split(strings.Trim(version, "."), ".")
+	if len(fields) > parts {
+		fields = fields[:parts]
+	}
+	return strings.Join(fields, ".")
+}
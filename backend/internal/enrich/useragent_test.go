@@ -0,0 +1,114 @@
+package enrich
+
+import "testing"
+
+func TestParseUserAgent(t *testing.T) {
+	tests := []struct {
+		name string
+		ua   string
+		want UserAgent
+	}{
+		{
+			name: "empty",
+			ua:   "",
+			want: UserAgent{},
+		},
+		{
+			name: "chrome on windows",
+			ua:   "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.6099.130 Safari/537.36",
+			want: UserAgent{Device: "desktop", Browser: "Chrome", BrowserVersion: "120", OS: "Windows", OSVersion: "10"},
+		},
+		{
+			name: "edge before chrome",
+			ua:   "Mozilla/5.0 (Windows NT 6.1; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36 Edg/120.0.2210.91",
+			want: UserAgent{Device: "desktop", Browser: "Edge", BrowserVersion: "120", OS: "Windows", OSVersion: "7"},
+		},
+		{
+			name: "firefox on linux",
+			ua:   "Mozilla/5.0 (X11; Ubuntu; Linux x86_64; rv:121.0) Gecko/20100101 Firefox/121.0",
+			want: UserAgent{Device: "desktop", Browser: "Firefox", BrowserVersion: "121", OS: "Linux"},
+		},
+		{
+			name: "safari on macos",
+			ua:   "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.1 Safari/605.1.15",
+			want: UserAgent{Device: "desktop", Browser: "Safari", BrowserVersion: "17", OS: "macOS", OSVersion: "10.15"},
+		},
+		{
+			name: "safari on iphone",
+			ua:   "Mozilla/5.0 (iPhone; CPU iPhone OS 17_2 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.2 Mobile/15E148 Safari/604.1",
+			want: UserAgent{Device: "mobile", Browser: "Safari", BrowserVersion: "17", OS: "iOS", OSVersion: "17.2"},
+		},
+		{
+			name: "chrome on iphone",
+			ua:   "Mozilla/5.0 (iPhone; CPU iPhone OS 17_2 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) CriOS/120.0.6099.119 Mobile/15E148 Safari/604.1",
+			want: UserAgent{Device: "mobile", Browser: "Chrome", BrowserVersion: "120", OS: "iOS", OSVersion: "17.2"},
+		},
+		{
+			name: "ipad is a tablet despite mobile",
+			ua:   "Mozilla/5.0 (iPad; CPU OS 17_2 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.2 Mobile/15E148 Safari/604.1",
+			want: UserAgent{Device: "tablet", Browser: "Safari", BrowserVersion: "17", OS: "iOS", OSVersion: "17.2"},
+		},
+		{
+			name: "samsung internet on android phone",
+			ua:   "Mozilla/5.0 (Linux; Android 13; SM-S911B) AppleWebKit/537.36 (KHTML, like Gecko) SamsungBrowser/23.0 Chrome/115.0.0.0 Mobile Safari/537.36",
+			want: UserAgent{Device: "mobile", Browser: "Samsung Internet", BrowserVersion: "23", OS: "Android", OSVersion: "13"},
+		},
+		{
+			name: "android without mobile is a tablet",
+			ua:   "Mozilla/5.0 (Linux; Android 13; SM-X710) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36",
+			want: UserAgent{Device: "tablet", Browser: "Chrome", BrowserVersion: "120", OS: "Android", OSVersion: "13"},
+		},
+		{
+			name: "console",
+			ua:   "Mozilla/5.0 (Windows NT 10.0; Win64; x64; Xbox; Xbox One) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/70.0.3538.102 Safari/537.36 Edge/18.19041",
+			want: UserAgent{Device: "console", Browser: "Edge", BrowserVersion: "18", OS: "Xbox"},
+		},
+		{
+			name: "crawler",
+			ua:   "Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)",
+			want: UserAgent{Device: "desktop", Browser: "other", OS: "other", IsBot: true},
+		},
+		{
+			name: "http library",
+			ua:   "curl/8.4.0",
+			want: UserAgent{Device: "desktop", Browser: "other", OS: "other", IsBot: true},
+		},
+		{
+			name: "headless browser",
+			ua:   "Mozilla/5.0 (X11; Linux x86_64) AppleWebKit/537.36 (KHTML, like Gecko) HeadlessChrome/120.0.0.0 Safari/537.36",
+			want: UserAgent{Device: "desktop", Browser: "Chrome", BrowserVersion: "120", OS: "Linux", IsBot: true},
+		},
+		{
+			name: "unknown",
+			ua:   "SomeApp/1.0",
+			want: UserAgent{Device: "desktop", Browser: "other", OS: "other"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ParseUserAgent(tt.ua); got != tt.want {
+				t.Errorf("ParseUserAgent(%q) = %+v, want %+v", tt.ua, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTruncateVersion(t *testing.T) {
+	tests := []struct {
+		version string
+		parts   int
+		want    string
+	}{
+		{"120.0.6099.130", 1, "120"},
+		{"10.15.7", 2, "10.15"},
+		{"17", 2, "17"},
+		{"17.2.", 2, "17.2"},
+		{"Vista", 2, "Vista"},
+		{"", 1, ""},
+	}
+	for _, tt := range tests {
+		if got := truncateVersion(tt.version, tt.parts); got != tt.want {
+			t.Errorf("truncateVersion(%q, %d) = %q, want %q", tt.version, tt.parts, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package enrich
+
+import (
+	"net/url"
+	"strings"
+)
+
+// Touch is the marketing source of a visit: UTM parameters when present,
+// otherwise what the referrer implies.
+type Touch struct {
+	Source   string `json:"source"`
+	Medium   string `json:"medium"`
+	Campaign string `json:"campaign"`
+	Channel  string `json:"channel"`
+}
+
+var searchEngines = []string{"google.", "bing.", "yahoo.", "duckduckgo.", "baidu.", "yandex.", "ecosia."}
+
+var socialNetworks = []string{
+	"facebook.", "fb.com", "instagram.", "twitter.", "t.co", "x.com",
+	"linkedin.", "lnkd.in", "reddit.", "youtube.", "tiktok.", "pinterest.",
+}
+
+// ParseTouch resolves the source of a visit from its landing page, referrer
+// and event metadata. utm_* keys in metadata take precedence over those in
+// the page URL.
+func ParseTouch(page, referrer string, metadata map[string]interface{}) Touch {
+	touch := Touch{
+		Source:   utmValue("utm_source", page, metadata),
+		Medium:   strings.ToLower(utmValue("utm_medium", page, metadata)),
+		Campaign: utmValue("utm_campaign", page, metadata),
+	}
+	if touch.Source != "" || touch.Medium != "" {
+		touch.Channel = campaignChannel(touch.Medium)
+		return touch
+	}
+
+	host := referrerHost(referrer)
+	switch {
+	case host == "" || host == "direct":
+		touch.Source, touch.Medium, touch.Channel = "(direct)", "(none)", "direct"
+	case hostMatches(host, searchEngines):
+		touch.Source, touch.Medium, touch.Channel = host, "organic", "organic_search"
+	case hostMatches(host, socialNetworks):
+		touch.Source, touch.Medium, touch.Channel = host, "social", "social"
+	default:
+		touch.Source, touch.Medium, touch.Channel = host, "referral", "referral"
+	}
+	return touch
+}
+
+func utmValue(key, page string, metadata map[string]interface{}) string {
+	if value, ok := metadata[key].(string); ok && value != "" {
+		return value
+	}
+	if _, query, ok := strings.Cut(page, "?"); ok {
+		if values, err := url.ParseQuery(query); err == nil {
+			return values.Get(key)
+		}
+	}
+	return ""
+}
+
+func campaignChannel(medium string) string {
+	switch medium {
+	case "cpc", "ppc", "paid", "paidsearch", "paid_search":
+		return "paid_search"
+	case "email", "e-mail", "newsletter":
+		return "email"
+	case "social", "social-network", "sm":
+		return "social"
+	case "paid_social", "paidsocial":
+		return "paid_social"
+	case "display", "banner", "cpm":
+		return "display"
+	case "affiliate":
+		return "affiliate"
+	case "referral":
+		return "referral"
+	default:
+		return "other_campaign"
+	}
+}
+
+// referrerHost returns the lower-case host of a referrer without "www.".
+// Values without a scheme are treated as bare hosts.
+func referrerHost(referrer string) string {
+	if referrer == "" {
+		return ""
+	}
+	if !strings.Contains(referrer, "://") {
+		referrer = "https://" + referrer
+	}
+	u, err := url.Parse(referrer)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+}
+
+// hostMatches checks host against patterns, which are either a domain label
+// followed by a dot ("google.", any TLD) or a full domain.
+func hostMatches(host string, patterns []string) bool {
+	for _, p := range patterns {
+		if strings.HasSuffix(p, ".") {
+			if strings.HasPrefix(host, p) || strings.Contains(host, "."+p) {
+				return true
+			}
+		} else if host == p || strings.HasSuffix(host, "."+p) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,204 @@
+// Package eventschema validates user event metadata against per-event-type
+// schemas. The schemas are a small subset of JSON Schema: typed top-level
+// properties with optional length, range and enum constraints. Event types
+// without a schema accept any metadata object.
+package eventschema
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+	"unicode/utf8"
+)
+
+//go:embed schemas.json
+var bundledSchemas []byte
+
+// Property constrains one metadata key. Type is string, number, integer,
+// boolean, object or array.
+type Property struct {
+	Type      string   `json:"type"`
+	Enum      []string `json:"enum,omitempty"`
+	MaxLength int      `json:"maxLength,omitempty"`
+	Minimum   *float64 `json:"minimum,omitempty"`
+	Maximum   *float64 `json:"maximum,omitempty"`
+}
+
+// Schema describes the metadata of one event type. Keys not listed in
+// Properties are accepted unless AdditionalProperties is false.
+type Schema struct {
+	Properties           map[string]*Property `json:"properties"`
+	Required             []string             `json:"required,omitempty"`
+	AdditionalProperties *bool                `json:"additionalProperties,omitempty"`
+}
+
+// ValidationError reports the first metadata key violating a schema.
+type ValidationError struct {
+	EventType string
+	Key       string
+	Message   string
+}
+
+func (e *ValidationError) Error() string {
+	if e.Key == "" {
+		return fmt.Sprintf("%s metadata %s", e.EventType, e.Message)
+	}
+	return fmt.Sprintf("%s metadata.%s %s", e.EventType, e.Key, e.Message)
+}
+
+var propertyTypes = map[string]bool{
+	"string": true, "number": true, "integer": true, "boolean": true, "object": true, "array": true,
+}
+
+var (
+	mu      sync.RWMutex
+	schemas = make(map[string]*Schema)
+)
+
+func init() {
+	var bundled map[string]*Schema
+	if err := json.Unmarshal(bundledSchemas, &bundled); err != nil {
+		panic("eventschema: invalid bundled schemas: " + err.Error())
+	}
+	for eventType, schema := range bundled {
+		if err := Register(eventType, schema); err != nil {
+			panic("eventschema: " + err.Error())
+		}
+	}
+}
+
+// Register sets the schema of an event type, replacing any previous one.
+func Register(eventType string, schema *Schema) error {
+	if eventType == "" || schema == nil {
+		return fmt.Errorf("schema for %q is empty", eventType)
+	}
+	for key, property := range schema.Properties {
+		if property == nil || !propertyTypes[property.Type] {
+			return fmt.Errorf("schema for %q: property %q has an unsupported type", eventType, key)
+		}
+	}
+	for _, key := range schema.Required {
+		if _, ok := schema.Properties[key]; !ok {
+			return fmt.Errorf("schema for %q: required property %q is not defined", eventType, key)
+		}
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	schemas[eventType] = schema
+	return nil
+}
+
+// Lookup returns the schema registered for an event type.
+func Lookup(eventType string) (*Schema, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+	schema, ok := schemas[eventType]
+	return schema, ok
+}
+
+// All returns the registered schemas keyed by event type.
+func All() map[string]*Schema {
+	mu.RLock()
+	defer mu.RUnlock()
+	result := make(map[string]*Schema, len(schemas))
+	for eventType, schema := range schemas {
+		result[eventType] = schema
+	}
+	return result
+}
+
+// Validate checks that metadata is a JSON object matching the event type's
+// schema. Empty metadata is treated as {}.
+func Validate(eventType, metadata string) error {
+	if metadata == "" {
+		metadata = "{}"
+	}
+	var object map[string]interface{}
+	if err := json.Unmarshal([]byte(metadata), &object); err != nil || object == nil {
+		return &ValidationError{EventType: eventType, Message: "must be a JSON object"}
+	}
+	schema, ok := Lookup(eventType)
+	if !ok {
+		return nil
+	}
+
+	for _, key := range schema.Required {
+		if _, ok := object[key]; !ok {
+			return &ValidationError{EventType: eventType, Key: key, Message: "is required"}
+		}
+	}
+	// Check keys in order so that the reported error is deterministic.
+	keys := make([]string, 0, len(object))
+	for key := range object {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		property, ok := schema.Properties[key]
+		if !ok {
+			if schema.AdditionalProperties != nil && !*schema.AdditionalProperties {
+				return &ValidationError{EventType: eventType, Key: key, Message: "is not allowed"}
+			}
+			continue
+		}
+		if message := property.check(object[key]); message != "" {
+			return &ValidationError{EventType: eventType, Key: key, Message: message}
+		}
+	}
+	return nil
+}
+
+// check returns why value violates the property, or "" if it does not.
+func (p *Property) check(value interface{}) string {
+	switch p.Type {
+	case "string":
+		s, ok := value.(string)
+		if !ok {
+			return "must be a string"
+		}
+		if p.MaxLength > 0 && utf8.RuneCountInString(s) > p.MaxLength {
+			return fmt.Sprintf("must be at most %d characters", p.MaxLength)
+		}
+		if len(p.Enum) > 0 && !contains(p.Enum, s) {
+			return fmt.Sprintf("must be one of %v", p.Enum)
+		}
+	case "number", "integer":
+		n, ok := value.(float64)
+		if !ok {
+			return "must be a " + p.Type
+		}
+		if p.Type == "integer" && n != float64(int64(n)) {
+			return "must be an integer"
+		}
+		if p.Minimum != nil && n < *p.Minimum {
+			return fmt.Sprintf("must be at least %v", *p.Minimum)
+		}
+		if p.Maximum != nil && n > *p.Maximum {
+			return fmt.Sprintf("must be at most %v", *p.Maximum)
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			return "must be a boolean"
+		}
+	case "object":
+		if _, ok := value.(map[string]interface{}); !ok {
+			return "must be an object"
+		}
+	case "array":
+		if _, ok := value.([]interface{}); !ok {
+			return "must be an array"
+		}
+	}
+	return ""
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
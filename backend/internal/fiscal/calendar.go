@@ -0,0 +1,277 @@
+// Package fiscal maps dates onto fiscal years, quarters, months and weeks.
+//
+// Fiscal years are named after the calendar year they end in, so with a July
+// start FY2026 runs from July 2025 to June 2026. Calendars either follow
+// calendar months or a 4-4-5 style retail pattern, in which every fiscal month
+// is a whole number of weeks, years have 52 or 53 weeks and start on the week
+// start day nearest to the first of the start month. All dates are in UTC.
+package fiscal
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Granularity is the length of a fiscal period.
+type Granularity string
+
+const (
+	Week    Granularity = "week"
+	Month   Granularity = "month"
+	Quarter Granularity = "quarter"
+	Year    Granularity = "year"
+)
+
+// Patterns are the supported month patterns. "calendar" uses calendar months;
+// the others give the weeks in each month of a quarter.
+var Patterns = map[string][3]int{
+	"calendar": {},
+	"4-4-5":    {4, 4, 5},
+	"4-5-4":    {4, 5, 4},
+	"5-4-4":    {5, 4, 4},
+}
+
+// Period is one fiscal period. Index is the quarter, month or week number
+// within the fiscal year, and 0 for years. End is the start of the next
+// period.
+type Period struct {
+	Label       string      `json:"label"`
+	Granularity Granularity `json:"granularity"`
+	Year        int         `json:"year"`
+	Index       int         `json:"index,omitempty"`
+	Start       time.Time   `json:"start"`
+	End         time.Time   `json:"end"`
+}
+
+// Calendar is a fiscal calendar configuration.
+type Calendar struct {
+	startMonth time.Month
+	pattern    string
+	weekStart  time.Weekday
+}
+
+var labelPattern = regexp.MustCompile(`^FY(\d{4})(?:-([QMW])(\d{1,2}))?$`)
+
+var weekdays = map[string]time.Weekday{
+	"sunday": time.Sunday, "monday": time.Monday, "tuesday": time.Tuesday, "wednesday": time.Wednesday,
+	"thursday": time.Thursday, "friday": time.Friday, "saturday": time.Saturday,
+}
+
+// NewCalendar creates a calendar whose years start in startMonth, with
+// months following pattern, a key of Patterns, and weeks starting on
+// weekStart.
+func NewCalendar(startMonth time.Month, pattern string, weekStart time.Weekday) (*Calendar, error) {
+	if startMonth < time.January || startMonth > time.December {
+		return nil, fmt.Errorf("invalid fiscal year start month: %d", startMonth)
+	}
+	if _, ok := Patterns[pattern]; !ok {
+		return nil, fmt.Errorf("invalid fiscal pattern: %q", pattern)
+	}
+	if weekStart < time.Sunday || weekStart > time.Saturday {
+		return nil, fmt.Errorf("invalid fiscal week start: %d", weekStart)
+	}
+	return &Calendar{startMonth: startMonth, pattern: pattern, weekStart: weekStart}, nil
+}
+
+// NewCalendarFromEnv reads FISCAL_YEAR_START_MONTH (1-12, default 1),
+// FISCAL_PATTERN (default calendar) and FISCAL_WEEK_START (a weekday name,
+// default monday).
+func NewCalendarFromEnv() (*Calendar, error) {
+	startMonth := time.January
+	if value := os.Getenv("FISCAL_YEAR_START_MONTH"); value != "" {
+		month, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid FISCAL_YEAR_START_MONTH: %q", value)
+		}
+		startMonth = time.Month(month)
+	}
+	pattern := "calendar"
+	if value := os.Getenv("FISCAL_PATTERN"); value != "" {
+		pattern = value
+	}
+	weekStart := time.Monday
+	if value := os.Getenv("FISCAL_WEEK_START"); value != "" {
+		day, ok := weekdays[strings.ToLower(value)]
+		if !ok {
+			return nil, fmt.Errorf("invalid FISCAL_WEEK_START: %q", value)
+		}
+		weekStart = day
+	}
+	return NewCalendar(startMonth, pattern, weekStart)
+}
+
+// Default is a calendar-year calendar with Monday weeks.
+func Default() *Calendar {
+	return &Calendar{startMonth: time.January, pattern: "calendar", weekStart: time.Monday}
+}
+
+// IsLabel reports whether label looks like a fiscal period label.
+func IsLabel(label string) bool {
+	return strings.HasPrefix(label, "FY")
+}
+
+// Parse resolves a label such as FY2026, FY2026-Q3, FY2026-M07 or FY2026-W05.
+func (c *Calendar) Parse(label string) (Period, error) {
+	match := labelPattern.FindStringSubmatch(label)
+	if match == nil {
+		return Period{}, fmt.Errorf("invalid fiscal period %q", label)
+	}
+	year, _ := strconv.Atoi(match[1])
+	if match[2] == "" {
+		return c.period(year, Year, 0), nil
+	}
+	index, _ := strconv.Atoi(match[3])
+	granularity := map[string]Granularity{"Q": Quarter, "M": Month, "W": Week}[match[2]]
+	if index < 1 || index > c.count(year, granularity) {
+		return Period{}, fmt.Errorf("invalid fiscal period %q", label)
+	}
+	return c.period(year, granularity, index), nil
+}
+
+// PeriodOf returns the period of the given granularity containing t.
+func (c *Calendar) PeriodOf(t time.Time, granularity Granularity) Period {
+	t = t.UTC()
+	year := t.Year()
+	for !t.Before(c.yearStart(year + 1)) {
+		year++
+	}
+	for t.Before(c.yearStart(year)) {
+		year--
+	}
+	if granularity == Year {
+		return c.period(year, Year, 0)
+	}
+	// Periods are at most 53 entries long, so a scan is cheap.
+	for index := c.count(year, granularity); index > 1; index-- {
+		if start, _ := c.bounds(year, granularity, index); !t.Before(start) {
+			return c.period(year, granularity, index)
+		}
+	}
+	return c.period(year, granularity, 1)
+}
+
+// Shift returns the period n periods of the same granularity after p, or
+// before it when n is negative.
+func (c *Calendar) Shift(p Period, n int) Period {
+	if p.Granularity == Year {
+		return c.period(p.Year+n, Year, 0)
+	}
+	year, index := p.Year, p.Index+n
+	for index < 1 {
+		year--
+		index += c.count(year, p.Granularity)
+	}
+	for index > c.count(year, p.Granularity) {
+		index -= c.count(year, p.Granularity)
+		year++
+	}
+	return c.period(year, p.Granularity, index)
+}
+
+// YearAgo returns the period with the same number in the previous fiscal
+// year. Week 53 maps onto week 52 of a 52-week year.
+func (c *Calendar) YearAgo(p Period) Period {
+	if p.Granularity == Year {
+		return c.period(p.Year-1, Year, 0)
+	}
+	index := p.Index
+	if count := c.count(p.Year-1, p.Granularity); index > count {
+		index = count
+	}
+	return c.period(p.Year-1, p.Granularity, index)
+}
+
+func (c *Calendar) period(year int, granularity Granularity, index int) Period {
+	start, end := c.bounds(year, granularity, index)
+	label := fmt.Sprintf("FY%04d", year)
+	switch granularity {
+	case Quarter:
+		label += fmt.Sprintf("-Q%d", index)
+	case Month:
+		label += fmt.Sprintf("-M%02d", index)
+	case Week:
+		label += fmt.Sprintf("-W%02d", index)
+	}
+	return Period{Label: label, Granularity: granularity, Year: year, Index: index, Start: start, End: end}
+}
+
+// count returns the number of periods of a granularity in a fiscal year.
+func (c *Calendar) count(year int, granularity Granularity) int {
+	switch granularity {
+	case Quarter:
+		return 4
+	case Month:
+		return 12
+	case Week:
+		days := int(c.yearStart(year+1).Sub(c.firstWeekStart(year)).Hours() / 24)
+		return (days + 6) / 7
+	}
+	return 1
+}
+
+// bounds returns the start and end of a period.
+func (c *Calendar) bounds(year int, granularity Granularity, index int) (time.Time, time.Time) {
+	yearStart, yearEnd := c.yearStart(year), c.yearStart(year+1)
+	switch granularity {
+	case Quarter:
+		start, _ := c.bounds(year, Month, 3*index-2)
+		_, end := c.bounds(year, Month, 3*index)
+		return start, end
+	case Month:
+		weeks := Patterns[c.pattern]
+		if weeks == [3]int{} {
+			return yearStart.AddDate(0, index-1, 0), yearStart.AddDate(0, index, 0)
+		}
+		offset := 0
+		for m := 1; m < index; m++ {
+			offset += weeks[(m-1)%3]
+		}
+		start := yearStart.AddDate(0, 0, 7*offset)
+		if index == 12 {
+			// The last month absorbs the 53rd week.
+			return start, yearEnd
+		}
+		return start, start.AddDate(0, 0, 7*weeks[(index-1)%3])
+	case Week:
+		first := c.firstWeekStart(year)
+		start, end := first.AddDate(0, 0, 7*(index-1)), first.AddDate(0, 0, 7*index)
+		if start.Before(yearStart) {
+			start = yearStart
+		}
+		if end.After(yearEnd) {
+			end = yearEnd
+		}
+		return start, end
+	}
+	return yearStart, yearEnd
+}
+
+// yearStart returns the first day of a fiscal year.
+func (c *Calendar) yearStart(year int) time.Time {
+	calendarYear := year
+	if c.startMonth != time.January {
+		calendarYear--
+	}
+	anchor := time.Date(calendarYear, c.startMonth, 1, 0, 0, 0, 0, time.UTC)
+	if c.pattern == "calendar" {
+		return anchor
+	}
+	delta := (int(c.weekStart) - int(anchor.Weekday()) + 7) % 7
+	if delta > 3 {
+		delta -= 7
+	}
+	return anchor.AddDate(0, 0, delta)
+}
+
+// firstWeekStart returns the start of the week containing the first day of
+// a fiscal year. In calendar-month years it may precede the year, in which
+// case week 1 is cut short.
+func (c *Calendar) firstWeekStart(year int) time.Time {
+	start := c.yearStart(year)
+	back := (int(start.Weekday()) - int(c.weekStart) + 7) % 7
+	return start.AddDate(0, 0, -back)
+}
@@ -0,0 +1,173 @@
+package fiscal
+
+import (
+	"testing"
+	"time"
+)
+
+func day(year int, month time.Month, d int) time.Time {
+	return time.Date(year, month, d, 0, 0, 0, 0, time.UTC)
+}
+
+func mustCalendar(t *testing.T, startMonth time.Month, pattern string, weekStart time.Weekday) *Calendar {
+	t.Helper()
+	c, err := NewCalendar(startMonth, pattern, weekStart)
+	if err != nil {
+		t.Fatalf("NewCalendar(%v, %q, %v): %v", startMonth, pattern, weekStart, err)
+	}
+	return c
+}
+
+func checkPeriod(t *testing.T, got Period, label string, start, end time.Time) {
+	t.Helper()
+	if got.Label != label || !got.Start.Equal(start) || !got.End.Equal(end) {
+		t.Errorf("got %s [%s, %s), want %s [%s, %s)", got.Label, got.Start.Format(time.DateOnly), got.End.Format(time.DateOnly),
+			label, start.Format(time.DateOnly), end.Format(time.DateOnly))
+	}
+}
+
+func TestNewCalendar(t *testing.T) {
+	tests := []struct {
+		name       string
+		startMonth time.Month
+		pattern    string
+		weekStart  time.Weekday
+		wantErr    bool
+	}{
+		{"calendar months", time.January, "calendar", time.Monday, false},
+		{"retail pattern", time.February, "4-5-4", time.Sunday, false},
+		{"start month too low", 0, "calendar", time.Monday, true},
+		{"start month too high", 13, "calendar", time.Monday, true},
+		{"unknown pattern", time.January, "4-4-4", time.Monday, true},
+		{"unknown weekday", time.January, "4-4-5", 7, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewCalendar(tt.startMonth, tt.pattern, tt.weekStart)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NewCalendar() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	calendar := Default()
+	july := mustCalendar(t, time.July, "calendar", time.Monday)
+	retail := mustCalendar(t, time.January, "4-4-5", time.Monday)
+
+	tests := []struct {
+		name     string
+		calendar *Calendar
+		label    string
+		start    time.Time
+		end      time.Time
+		wantErr  bool
+	}{
+		{"calendar year", calendar, "FY2026", day(2026, time.January, 1), day(2027, time.January, 1), false},
+		{"calendar quarter", calendar, "FY2026-Q3", day(2026, time.July, 1), day(2026, time.October, 1), false},
+		{"calendar month", calendar, "FY2026-M02", day(2026, time.February, 1), day(2026, time.March, 1), false},
+		{"first week cut short", calendar, "FY2026-W01", day(2026, time.January, 1), day(2026, time.January, 5), false},
+		{"last week cut short", calendar, "FY2026-W53", day(2026, time.December, 28), day(2027, time.January, 1), false},
+		{"july year named after its end", july, "FY2026", day(2025, time.July, 1), day(2026, time.July, 1), false},
+		{"july third quarter", july, "FY2026-Q3", day(2026, time.January, 1), day(2026, time.April, 1), false},
+		{"july seventh month", july, "FY2026-M07", day(2026, time.January, 1), day(2026, time.February, 1), false},
+		{"retail 53-week year", retail, "FY2026", day(2025, time.December, 29), day(2027, time.January, 4), false},
+		{"retail first quarter", retail, "FY2026-Q1", day(2025, time.December, 29), day(2026, time.March, 30), false},
+		{"retail five-week month", retail, "FY2026-M03", day(2026, time.February, 23), day(2026, time.March, 30), false},
+		{"retail last month absorbs week 53", retail, "FY2026-M12", day(2026, time.November, 23), day(2027, time.January, 4), false},
+		{"retail week 53", retail, "FY2026-W53", day(2026, time.December, 28), day(2027, time.January, 4), false},
+		{"no week 53 in a 52-week year", retail, "FY2025-W53", time.Time{}, time.Time{}, true},
+		{"quarter out of range", calendar, "FY2026-Q5", time.Time{}, time.Time{}, true},
+		{"month out of range", calendar, "FY2026-M13", time.Time{}, time.Time{}, true},
+		{"month zero", calendar, "FY2026-M00", time.Time{}, time.Time{}, true},
+		{"two-digit year", calendar, "FY26", time.Time{}, time.Time{}, true},
+		{"calendar label", calendar, "2026-Q3", time.Time{}, time.Time{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.calendar.Parse(tt.label)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("Parse(%q) = %s, want an error", tt.label, got.Label)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Parse(%q): %v", tt.label, err)
+			}
+			checkPeriod(t, got, tt.label, tt.start, tt.end)
+		})
+	}
+}
+
+func TestPeriodOf(t *testing.T) {
+	calendar := Default()
+	july := mustCalendar(t, time.July, "calendar", time.Monday)
+	retail := mustCalendar(t, time.January, "4-4-5", time.Monday)
+
+	tests := []struct {
+		name        string
+		calendar    *Calendar
+		at          time.Time
+		granularity Granularity
+		label       string
+		start       time.Time
+		end         time.Time
+	}{
+		{"calendar month", calendar, day(2026, time.July, 15), Month, "FY2026-M07", day(2026, time.July, 1), day(2026, time.August, 1)},
+		{"calendar first week", calendar, day(2026, time.January, 3), Week, "FY2026-W01", day(2026, time.January, 1), day(2026, time.January, 5)},
+		{"calendar second week", calendar, day(2026, time.January, 5), Week, "FY2026-W02", day(2026, time.January, 5), day(2026, time.January, 12)},
+		{"july year", july, day(2026, time.July, 15), Year, "FY2027", day(2026, time.July, 1), day(2027, time.July, 1)},
+		{"july first quarter", july, day(2026, time.July, 1), Quarter, "FY2027-Q1", day(2026, time.July, 1), day(2026, time.October, 1)},
+		{"july last instant of the year", july, day(2026, time.July, 1).Add(-time.Nanosecond), Quarter, "FY2026-Q4",
+			day(2026, time.April, 1), day(2026, time.July, 1)},
+		{"retail year starting in december", retail, day(2025, time.December, 30), Month, "FY2026-M01",
+			day(2025, time.December, 29), day(2026, time.January, 26)},
+		{"retail month boundary", retail, day(2026, time.January, 26), Month, "FY2026-M02", day(2026, time.January, 26), day(2026, time.February, 23)},
+		{"retail january in the previous year", retail, day(2027, time.January, 2), Month, "FY2026-M12",
+			day(2026, time.November, 23), day(2027, time.January, 4)},
+		{"non-UTC time", calendar, time.Date(2026, time.August, 1, 1, 0, 0, 0, time.FixedZone("CEST", 2*3600)), Month, "FY2026-M07",
+			day(2026, time.July, 1), day(2026, time.August, 1)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checkPeriod(t, tt.calendar.PeriodOf(tt.at, tt.granularity), tt.label, tt.start, tt.end)
+		})
+	}
+}
+
+func TestShiftAndYearAgo(t *testing.T) {
+	calendar := Default()
+	retail := mustCalendar(t, time.January, "4-4-5", time.Monday)
+	parse := func(c *Calendar, label string) Period {
+		t.Helper()
+		p, err := c.Parse(label)
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", label, err)
+		}
+		return p
+	}
+
+	tests := []struct {
+		name string
+		got  Period
+		want string
+	}{
+		{"next month across years", calendar.Shift(parse(calendar, "FY2026-M12"), 1), "FY2027-M01"},
+		{"previous quarter across years", calendar.Shift(parse(calendar, "FY2026-Q1"), -1), "FY2025-Q4"},
+		{"several quarters ahead", calendar.Shift(parse(calendar, "FY2026-Q3"), 6), "FY2028-Q1"},
+		{"previous year", calendar.Shift(parse(calendar, "FY2026"), -1), "FY2025"},
+		{"week after week 53", retail.Shift(parse(retail, "FY2026-W53"), 1), "FY2027-W01"},
+		{"week before week 1", retail.Shift(parse(retail, "FY2027-W01"), -1), "FY2026-W53"},
+		{"year ago of a month", calendar.YearAgo(parse(calendar, "FY2026-M07")), "FY2025-M07"},
+		{"year ago of week 53", retail.YearAgo(parse(retail, "FY2026-W53")), "FY2025-W52"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.got.Label != tt.want {
+				t.Errorf("got %s, want %s", tt.got.Label, tt.want)
+			}
+		})
+	}
+}
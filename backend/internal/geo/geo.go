@@ -0,0 +1,78 @@
+package geo
+
+import (
+	_ "embed"
+	"encoding/json"
+	"strings"
+)
+
+//go:embed countries.json
+var bundledDataset []byte
+
+// Country is a bundled country with its centroid.
+type Country struct {
+	Code    string   `json:"code"`
+	Name    string   `json:"name"`
+	Region  string   `json:"region"`
+	Lat     float64  `json:"lat"`
+	Lon     float64  `json:"lon"`
+	Aliases []string `json:"aliases,omitempty"`
+}
+
+// Region is a sales region with its centroid.
+type Region struct {
+	Name string  `json:"name"`
+	Lat  float64 `json:"lat"`
+	Lon  float64 `json:"lon"`
+}
+
+var (
+	countries       []Country
+	countriesByCode = make(map[string]*Country)
+	regionsByName   = make(map[string]*Region)
+)
+
+func init() {
+	var dataset struct {
+		Regions   []Region  `json:"regions"`
+		Countries []Country `json:"countries"`
+	}
+	if err := json.Unmarshal(bundledDataset, &dataset); err != nil {
+		panic("geo: invalid bundled dataset: " + err.Error())
+	}
+	countries = dataset.Countries
+	for i := range countries {
+		c := &countries[i]
+		countriesByCode[c.Code] = c
+		for _, alias := range c.Aliases {
+			countriesByCode[alias] = c
+		}
+	}
+	for i := range dataset.Regions {
+		r := &dataset.Regions[i]
+		regionsByName[strings.ToLower(r.Name)] = r
+	}
+}
+
+// LookupCountry finds a country by code, as stored in user events and sales.
+func LookupCountry(code string) (*Country, bool) {
+	c, ok := countriesByCode[strings.ToUpper(code)]
+	return c, ok
+}
+
+// LookupRegion finds a region by name, ignoring case.
+func LookupRegion(name string) (*Region, bool) {
+	r, ok := regionsByName[strings.ToLower(name)]
+	return r, ok
+}
+
+// CountriesInRegion returns the codes of the bundled countries in a region.
+func CountriesInRegion(region string) []string {
+	var codes []string
+	for _, c := range countries {
+		if strings.EqualFold(c.Region, region) {
+			codes = append(codes, c.Code)
+		}
+	}
+	return codes
+}
@@ -0,0 +1,35 @@
+package geo
+
+// FeatureCollection is a GeoJSON FeatureCollection.
+type FeatureCollection struct {
+	Type     string     `json:"type"`
+	Features []*Feature `json:"features"`
+}
+
+// Feature is a GeoJSON Feature. Geometry is nil for places without known
+// coordinates, which GeoJSON allows.
+type Feature struct {
+	Type       string                 `json:"type"`
+	ID         string                 `json:"id,omitempty"`
+	Geometry   *Point                 `json:"geometry"`
+	Properties map[string]interface{} `json:"properties"`
+}
+
+// Point is a GeoJSON Point geometry.
+type Point struct {
+	Type        string     `json:"type"`
+	Coordinates [2]float64 `json:"coordinates"`
+}
+
+func NewFeatureCollection() *FeatureCollection {
+	return &FeatureCollection{Type: "FeatureCollection", Features: []*Feature{}}
+}
+
+// Add appends a feature. GeoJSON coordinates are longitude first.
+func (fc *FeatureCollection) Add(id string, lat, lon float64, hasPoint bool, properties map[string]interface{}) {
+	feature := &Feature{Type: "Feature", ID: id, Properties: properties}
+	if hasPoint {
+		feature.Geometry = &Point{Type: "Point", Coordinates: [2]float64{lon, lat}}
+	}
+	fc.Features = append(fc.Features, feature)
+}
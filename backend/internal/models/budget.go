@@ -0,0 +1,29 @@
+package models
+
+import (
+	"time"
+)
+
+// Budget is the budgeted amount of a metric type for a department and
+// category over a financial period. An empty Department or Category applies
+// the budget across all values. Version is "original" or "revised"; a
+// revision supersedes the original line with the same key.
+type Budget struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	Department  string    `gorm:"type:varchar(100);not null;default:''" json:"department"`
+	Category    string    `gorm:"type:varchar(100);not null;default:''" json:"category"`
+	MetricType  string    `gorm:"type:varchar(50);not null" json:"metric_type"`
+	Period      string    `gorm:"type:varchar(20);not null" json:"period"`
+	PeriodStart time.Time `gorm:"type:timestamptz;not null" json:"period_start"`
+	PeriodEnd   time.Time `gorm:"type:timestamptz;not null" json:"period_end"`
+	Version     string    `gorm:"type:varchar(20);not null;default:'original'" json:"version"`
+	Amount      float64   `gorm:"type:decimal(14,2);not null" json:"amount"`
+	Notes       string    `gorm:"type:text" json:"notes,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// TableName specifies the table name
+func (Budget) TableName() string {
+	return "budgets"
+}
@@ -0,0 +1,46 @@
+package models
+
+import (
+	"time"
+)
+
+// CostCenter is a node of the cost center hierarchy. Level is company,
+// division, department or team. Department is the financial metric
+// department the node owns, if any; parents are set by CostCenterLink.
+type CostCenter struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	Code       string    `gorm:"type:varchar(50);not null;uniqueIndex" json:"code"`
+	Name       string    `gorm:"type:varchar(100);not null" json:"name"`
+	Level      string    `gorm:"type:varchar(20);not null" json:"level"`
+	Department string    `gorm:"type:varchar(100);not null;default:''" json:"department"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// TableName specifies the table name
+func (CostCenter) TableName() string {
+	return "cost_centers"
+}
+
+// CostCenterLink places a cost center under a parent, or at the root when
+// ParentID is nil, from EffectiveFrom until EffectiveTo. Nil bounds are
+// open.
+type CostCenterLink struct {
+	ID            uint       `gorm:"primaryKey" json:"id"`
+	CostCenterID  uint       `gorm:"not null;index" json:"cost_center_id"`
+	ParentID      *uint      `gorm:"index" json:"parent_id"`
+	EffectiveFrom *time.Time `gorm:"type:timestamptz" json:"effective_from"`
+	EffectiveTo   *time.Time `gorm:"type:timestamptz" json:"effective_to"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+// TableName specifies the table name
+func (CostCenterLink) TableName() string {
+	return "cost_center_links"
+}
+
+// Covers reports whether the link is in effect at t.
+func (l *CostCenterLink) Covers(t time.Time) bool {
+	return (l.EffectiveFrom == nil || !t.Before(*l.EffectiveFrom)) &&
+		(l.EffectiveTo == nil || t.Before(*l.EffectiveTo))
+}
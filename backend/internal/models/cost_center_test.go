@@ -0,0 +1,34 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCostCenterLinkCovers(t *testing.T) {
+	from := time.Date(2026, time.April, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2026, time.July, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name string
+		link CostCenterLink
+		at   time.Time
+		want bool
+	}{
+		{"open on both sides", CostCenterLink{}, from, true},
+		{"at effective_from", CostCenterLink{EffectiveFrom: &from, EffectiveTo: &to}, from, true},
+		{"just before effective_from", CostCenterLink{EffectiveFrom: &from, EffectiveTo: &to}, from.Add(-time.Nanosecond), false},
+		{"just before effective_to", CostCenterLink{EffectiveFrom: &from, EffectiveTo: &to}, to.Add(-time.Nanosecond), true},
+		{"at effective_to", CostCenterLink{EffectiveFrom: &from, EffectiveTo: &to}, to, false},
+		{"open start before effective_to", CostCenterLink{EffectiveTo: &to}, time.Date(1990, time.January, 1, 0, 0, 0, 0, time.UTC), true},
+		{"open start at effective_to", CostCenterLink{EffectiveTo: &to}, to, false},
+		{"open end long after effective_from", CostCenterLink{EffectiveFrom: &from}, time.Date(2100, time.January, 1, 0, 0, 0, 0, time.UTC), true},
+		{"open end before effective_from", CostCenterLink{EffectiveFrom: &from}, from.Add(-time.Nanosecond), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.link.Covers(tt.at); got != tt.want {
+				t.Errorf("Covers(%s) = %v, want %v", tt.at, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,18 @@
+package models
+
+import (
+	"time"
+)
+
+// CustomerIdentity links a tracked user ID to a sales customer ID
+type CustomerIdentity struct {
+	UserID      string    `gorm:"type:varchar(50);primaryKey" json:"user_id"`
+	CustomerID  string    `gorm:"type:varchar(50);primaryKey;index" json:"customer_id"`
+	FirstSeenAt time.Time `gorm:"type:timestamptz;not null" json:"first_seen_at"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// TableName specifies the table name
+func (CustomerIdentity) TableName() string {
+	return "customer_identities"
+}
@@ -0,0 +1,31 @@
+package models
+
+import (
+	"time"
+)
+
+// Experiment is an A/B test. Events carry the assignment in their metadata as
+// {"experiments": {"<key>": "<variant>"}}; an actor's first such event is
+// their exposure, and GoalEvent after exposure counts as a conversion.
+// SequentialTau is the mixing standard deviation of the sequential test on the
+// conversion rate difference and is fixed when the experiment is created.
+type Experiment struct {
+	ID            uint       `gorm:"primaryKey" json:"id"`
+	Key           string     `gorm:"type:varchar(100);not null;uniqueIndex" json:"key"`
+	Name          string     `gorm:"type:varchar(255);not null" json:"name"`
+	Description   string     `gorm:"type:text" json:"description"`
+	Variants      []string   `gorm:"type:jsonb;serializer:json;not null" json:"variants"`
+	Control       string     `gorm:"type:varchar(100);not null" json:"control"`
+	GoalEvent     string     `gorm:"type:varchar(50);not null" json:"goal_event"`
+	Identity      string     `gorm:"type:varchar(20);not null;default:'user'" json:"identity"`
+	StartAt       time.Time  `gorm:"type:timestamptz;not null" json:"start_at"`
+	EndAt         *time.Time `gorm:"type:timestamptz" json:"end_at"`
+	SequentialTau float64    `gorm:"not null;default:0.01" json:"sequential_tau"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+// TableName specifies the table name
+func (Experiment) TableName() string {
+	return "experiments"
+}
@@ -12,10 +12,16 @@ type FinancialMetric struct {
 	Department    string    `gorm:"type:varchar(100);index" json:"department"`
 	Category      string    `gorm:"type:varchar(100);index" json:"category"`
 	Amount        float64   `gorm:"type:decimal(12,2);not null" json:"amount"`
+	// Budget is as stamped by the source on this row. Budget-vs-actual uses
+	// the budgets table instead, since row budgets overlap when summed.
 	Budget        float64   `gorm:"type:decimal(12,2)" json:"budget,omitempty"`
 	Variance      float64   `gorm:"type:decimal(12,2)" json:"variance,omitempty"`
 	VariancePct    float64   `gorm:"type:decimal(5,2)" json:"variance_pct,omitempty"`
+	// Period is as reported by the source, e.g. daily or monthly.
+	// FiscalPeriod is the fiscal month of Timestamp, such as FY2026-M07,
+	// stamped at ingest.
 	Period        string    `gorm:"type:varchar(20)" json:"period"` 
+	FiscalPeriod  string    `gorm:"type:varchar(20)" json:"fiscal_period"`
 	CreatedAt     time.Time `json:"created_at"`
 	UpdatedAt     time.Time `json:"updated_at"`
 }
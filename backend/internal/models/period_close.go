@@ -0,0 +1,47 @@
+package models
+
+import (
+	"time"
+)
+
+// PeriodClose records the close of a financial period, such as 2026-07 or
+// FY2026-M07. Metrics cannot be written into the period until the close is
+// reopened, which fills in the Reopened* fields.
+type PeriodClose struct {
+	ID           uint       `gorm:"primaryKey" json:"id"`
+	Period       string     `gorm:"type:varchar(20);not null" json:"period"`
+	PeriodStart  time.Time  `gorm:"type:timestamptz;not null" json:"period_start"`
+	PeriodEnd    time.Time  `gorm:"type:timestamptz;not null" json:"period_end"`
+	ClosedBy     string     `gorm:"type:varchar(255);not null" json:"closed_by"`
+	CloseReason  string     `gorm:"type:text;not null" json:"close_reason"`
+	ClosedAt     time.Time  `gorm:"type:timestamptz;not null" json:"closed_at"`
+	ReopenedBy   *string    `gorm:"type:varchar(255)" json:"reopened_by,omitempty"`
+	ReopenReason *string    `gorm:"type:text" json:"reopen_reason,omitempty"`
+	ReopenedAt   *time.Time `gorm:"type:timestamptz" json:"reopened_at,omitempty"`
+}
+
+// TableName specifies the table name
+func (PeriodClose) TableName() string {
+	return "period_closes"
+}
+
+// FinancialAdjustment is a financial metric that arrived after its period
+// was closed. Metric is the metric as received; PeriodCloseID is the close
+// it ran into, or nil if the period was reopened before it was recorded.
+type FinancialAdjustment struct {
+	ID            uint             `gorm:"primaryKey" json:"id"`
+	PeriodCloseID *uint            `json:"period_close_id"`
+	Period        string           `gorm:"type:varchar(20);not null" json:"period"`
+	Timestamp     time.Time        `gorm:"type:timestamptz;not null;index" json:"timestamp"`
+	MetricType    string           `gorm:"type:varchar(50);not null" json:"metric_type"`
+	Department    string           `gorm:"type:varchar(100)" json:"department"`
+	Category      string           `gorm:"type:varchar(100)" json:"category"`
+	Amount        float64          `gorm:"type:decimal(12,2);not null" json:"amount"`
+	Metric        *FinancialMetric `gorm:"type:jsonb;serializer:json;not null" json:"metric"`
+	CreatedAt     time.Time        `json:"created_at"`
+}
+
+// TableName specifies the table name
+func (FinancialAdjustment) TableName() string {
+	return "financial_adjustments"
+}
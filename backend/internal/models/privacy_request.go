@@ -0,0 +1,30 @@
+package models
+
+import (
+	"time"
+)
+
+// PrivacyRequest is the audit record of an export or erasure of the data held
+// about a user or customer. Subject is the ID sealed under the pseudonym key,
+// kept only until a pending erasure has run; SubjectHash is the SHA-256 of the
+// raw ID and identifies the subject for good. Result counts the rows exported
+// or erased per table.
+type PrivacyRequest struct {
+	ID          uint             `gorm:"primaryKey" json:"id"`
+	Kind        string           `gorm:"type:varchar(20);not null" json:"kind"`
+	SubjectType string           `gorm:"type:varchar(20);not null" json:"subject_type"`
+	SubjectHash string           `gorm:"type:char(64);not null;index" json:"subject_hash"`
+	Subject     *string          `gorm:"type:text" json:"-"`
+	RequestedBy string           `gorm:"type:varchar(255);not null" json:"requested_by"`
+	Reason      string           `gorm:"type:text" json:"reason"`
+	Status      string           `gorm:"type:varchar(20);not null;default:'pending'" json:"status"`
+	Result      map[string]int64 `gorm:"type:jsonb;serializer:json" json:"result,omitempty"`
+	Error       string           `gorm:"type:text" json:"error,omitempty"`
+	CreatedAt   time.Time        `json:"created_at"`
+	CompletedAt *time.Time       `gorm:"type:timestamptz" json:"completed_at"`
+}
+
+// TableName specifies the table name
+func (PrivacyRequest) TableName() string {
+	return "privacy_requests"
+}
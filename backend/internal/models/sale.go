@@ -13,6 +13,7 @@ type Sale struct {
 	Category    string    `gorm:"type:varchar(100);index" json:"category"`
 	CustomerID  string    `gorm:"type:varchar(50);index" json:"customer_id"`
 	Region      string    `gorm:"type:varchar(100);index" json:"region"`
+	Country     string    `gorm:"type:varchar(100)" json:"country"`
 	Quantity    int       `gorm:"not null" json:"quantity"`
 	UnitPrice   float64   `gorm:"type:decimal(10,2);not null" json:"unit_price"`
 	Discount    float64   `gorm:"type:decimal(10,2);default:0" json:"discount"`
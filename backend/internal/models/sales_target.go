@@ -0,0 +1,35 @@
+package models
+
+import (
+	"time"
+)
+
+// SalesTarget is a revenue target for a region and category over a period.
+// An empty Region or Category applies the target across all values.
+type SalesTarget struct {
+	ID            uint      `gorm:"primaryKey" json:"id"`
+	Region        string    `gorm:"type:varchar(100);not null;default:''" json:"region"`
+	Category      string    `gorm:"type:varchar(100);not null;default:''" json:"category"`
+	PeriodStart   time.Time `gorm:"type:timestamptz;not null" json:"period_start"`
+	PeriodEnd     time.Time `gorm:"type:timestamptz;not null" json:"period_end"`
+	TargetRevenue float64   `gorm:"type:decimal(14,2);not null" json:"target_revenue"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// TableName specifies the table name
+func (SalesTarget) TableName() string {
+	return "sales_targets"
+}
+
+// Matches reports whether a sale in the given region and category counts
+// towards the target.
+func (t *SalesTarget) Matches(region, category string) bool {
+	if t.Region != "" && t.Region != region {
+		return false
+	}
+	if t.Category != "" && t.Category != category {
+		return false
+	}
+	return true
+}
@@ -4,7 +4,9 @@ import (
 	"time"
 )
 
-// UserEvent represents user behavior tracking data
+// UserEvent represents user behavior tracking data. UserAgent is the raw
+// header as queued by producers; ingestion parses it into the device, browser
+// and OS fields and it is never stored.
 type UserEvent struct {
 	ID          uint      `gorm:"primaryKey" json:"id"`
 	Timestamp   time.Time `gorm:"type:timestamptz;not null;index" json:"timestamp"`
@@ -14,10 +16,15 @@ type UserEvent struct {
 	Page        string    `gorm:"type:varchar(255)" json:"page"`
 	Device      string    `gorm:"type:varchar(50)" json:"device"`
 	Browser     string    `gorm:"type:varchar(50)" json:"browser"`
+	BrowserVersion string `gorm:"type:varchar(50)" json:"browser_version,omitempty"`
+	OS          string    `gorm:"type:varchar(50)" json:"os,omitempty"`
+	OSVersion   string    `gorm:"type:varchar(50)" json:"os_version,omitempty"`
+	UserAgent   string    `gorm:"-" json:"user_agent,omitempty"`
 	Country     string    `gorm:"type:varchar(100);index" json:"country"`
 	City        string    `gorm:"type:varchar(100)" json:"city"`
 	Referrer    string    `gorm:"type:varchar(500)" json:"referrer"`
 	Metadata    string    `gorm:"type:jsonb" json:"metadata,omitempty"`
+	IsBot       bool      `gorm:"not null;default:false" json:"is_bot"`
 	CreatedAt   time.Time `json:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at"`
 }
@@ -0,0 +1,30 @@
+package models
+
+import (
+	"time"
+)
+
+// UserSession is a rollup of the user events sharing a session ID
+type UserSession struct {
+	SessionID       string    `gorm:"type:varchar(100);primaryKey" json:"session_id"`
+	UserID          string    `gorm:"type:varchar(50);index" json:"user_id"`
+	StartedAt       time.Time `gorm:"type:timestamptz;not null;index" json:"started_at"`
+	EndedAt         time.Time `gorm:"type:timestamptz;not null" json:"ended_at"`
+	DurationSeconds float64   `gorm:"not null" json:"duration_seconds"`
+	EventCount      int       `gorm:"not null" json:"event_count"`
+	PageViews       int       `gorm:"not null" json:"page_views"`
+	EntryPage       string    `gorm:"type:varchar(255)" json:"entry_page"`
+	ExitPage        string    `gorm:"type:varchar(255)" json:"exit_page"`
+	IsBounce        bool      `gorm:"not null" json:"is_bounce"`
+	Device          string    `gorm:"type:varchar(50)" json:"device"`
+	Browser         string    `gorm:"type:varchar(50)" json:"browser"`
+	Country         string    `gorm:"type:varchar(100)" json:"country"`
+	IsBot           bool      `gorm:"not null;default:false" json:"is_bot"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+// TableName specifies the table name
+func (UserSession) TableName() string {
+	return "user_sessions"
+}
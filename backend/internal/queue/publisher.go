@@ -35,6 +35,10 @@ func (p *Publisher) PublishFinancialMetric(queue string, data interface{}) error
 	return p.publish(queue, data)
 }
 
+func (p *Publisher) PublishFinancialAdjustment(queue string, data interface{}) error {
+	return p.publish(queue, data)
+}
+
 func (p *Publisher) publish(queueName string, data interface{}) error {
 	body, err := json.Marshal(data)
 	if err != nil {
@@ -18,6 +18,9 @@ var (
 	SalesQueue      = "sales"
 	UserEventsQueue = "user_events"
 	FinancialQueue  = "financial_metrics"
+	// AdjustmentsQueue holds financial metrics that arrived for a closed
+	// period, until they are recorded as adjustments.
+	AdjustmentsQueue = "financial_adjustments"
 )
 
 func NewRabbitMQ() (*RabbitMQ, error) {
@@ -54,6 +57,7 @@ func (r *RabbitMQ) setupQueues() error {
 		SalesQueue,
 		UserEventsQueue,
 		FinancialQueue,
+		AdjustmentsQueue,
 	}
 
 	for _, queueName := range queues {
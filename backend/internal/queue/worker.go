@@ -2,9 +2,11 @@ package queue
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"sync"
+	"time"
 
 	"github.com/KalebAsratemedhi/stock-dashboard/backend/internal/models"
 	"github.com/KalebAsratemedhi/stock-dashboard/backend/internal/redis"
@@ -12,15 +14,23 @@ import (
 )
 
 type Worker struct {
-	consumer        *Consumer
-	stockService    services.StockService
-	saleService     services.SaleService
-	userEventService services.UserEventService
-	financialService services.FinancialMetricService
-	redisClient     *redis.Client
-	batchSize       int
-	batchBuffer     map[string][]interface{}
-	batchMutex      sync.Mutex
+	consumer           *Consumer
+	stockService       services.StockService
+	saleService        services.SaleService
+	userEventService   services.UserEventService
+	financialService   services.FinancialMetricService
+	salesTargetService services.SalesTargetService
+	uniqueUserService  services.UniqueUserService
+	sessionService     services.SessionService
+	realtimeService    services.RealtimeService
+	attributionService services.AttributionService
+	privacyService     services.PrivacyService
+	periodCloseService services.PeriodCloseService
+	publisher          *Publisher
+	redisClient        *redis.Client
+	batchSize          int
+	batchBuffer        map[string][]interface{}
+	batchMutex         sync.Mutex
 }
 
 func NewWorker(
@@ -29,17 +39,33 @@ func NewWorker(
 	saleService services.SaleService,
 	userEventService services.UserEventService,
 	financialService services.FinancialMetricService,
+	salesTargetService services.SalesTargetService,
+	uniqueUserService services.UniqueUserService,
+	sessionService services.SessionService,
+	realtimeService services.RealtimeService,
+	attributionService services.AttributionService,
+	privacyService services.PrivacyService,
+	periodCloseService services.PeriodCloseService,
+	publisher *Publisher,
 	redisClient *redis.Client,
 ) *Worker {
 	return &Worker{
-		consumer:        consumer,
-		stockService:    stockService,
-		saleService:     saleService,
-		userEventService: userEventService,
-		financialService: financialService,
-		redisClient:     redisClient,
-		batchSize:       100,
-		batchBuffer:     make(map[string][]interface{}),
+		consumer:           consumer,
+		stockService:       stockService,
+		saleService:        saleService,
+		userEventService:   userEventService,
+		financialService:   financialService,
+		salesTargetService: salesTargetService,
+		uniqueUserService:  uniqueUserService,
+		sessionService:     sessionService,
+		realtimeService:    realtimeService,
+		attributionService: attributionService,
+		privacyService:     privacyService,
+		periodCloseService: periodCloseService,
+		publisher:          publisher,
+		redisClient:        redisClient,
+		batchSize:          100,
+		batchBuffer:        make(map[string][]interface{}),
 	}
 }
 
@@ -79,18 +105,38 @@ func (w *Worker) StartSaleWorker() error {
 			return fmt.Errorf("failed to unmarshal sale: %w", err)
 		}
 
+		w.privacyService.PseudonymizeSale(&sale)
+
 		if err := w.saleService.CreateSale(&sale); err != nil {
 			return err
 		}
 
 		if w.redisClient != nil {
 			w.redisClient.Publish(redis.SalesChannel, &sale)
+			w.publishAttainment(&sale)
 		}
 
 		return nil
 	})
 }
 
+// publishAttainment pushes refreshed attainment for every active target the
+// sale counts towards. Failures are logged rather than retried since the sale
+// itself has already been persisted.
+func (w *Worker) publishAttainment(sale *models.Sale) {
+	if w.salesTargetService == nil {
+		return
+	}
+	attainments, err := w.salesTargetService.GetAttainmentForSale(sale)
+	if err != nil {
+		log.Printf("Error computing sales attainment: %v", err)
+		return
+	}
+	for _, attainment := range attainments {
+		w.redisClient.Publish(redis.SalesAttainmentChannel, attainment)
+	}
+}
+
 func (w *Worker) StartUserEventWorker() error {
 	return w.consumer.ConsumeJSON(UserEventsQueue, func(data interface{}) error {
 		jsonData, err := json.Marshal(data)
@@ -103,11 +149,32 @@ func (w *Worker) StartUserEventWorker() error {
 			return fmt.Errorf("failed to unmarshal user event: %w", err)
 		}
 
+		services.ApplyUserAgent(&event)
+		w.privacyService.PseudonymizeEvent(&event)
+
 		if err := w.userEventService.CreateEvent(&event); err != nil {
+			// Redelivering an event that fails validation cannot succeed,
+			// so drop it rather than requeue it forever.
+			if errors.Is(err, services.ErrInvalidInput) {
+				log.Printf("Dropping invalid user event: %v", err)
+				return nil
+			}
 			return err
 		}
 
-		if w.redisClient != nil {
+		if err := w.uniqueUserService.RecordEvent(&event); err != nil {
+			log.Printf("Error recording unique user sketch: %v", err)
+		}
+
+		if err := w.realtimeService.RecordEvent(&event); err != nil {
+			log.Printf("Error recording realtime activity: %v", err)
+		}
+
+		if err := w.attributionService.RecordIdentity(&event); err != nil {
+			log.Printf("Error linking customer identity: %v", err)
+		}
+
+		if w.redisClient != nil && !event.IsBot {
 			w.redisClient.Publish(redis.UserEventsChannel, &event)
 		}
 
@@ -115,6 +182,79 @@ func (w *Worker) StartUserEventWorker() error {
 	})
 }
 
+// StartSessionRollup backfills recent sessions and then periodically rolls up
+// sessions that have been idle for services.SessionTimeout.
+func (w *Worker) StartSessionRollup(interval, backfill time.Duration) {
+	go func() {
+		now := time.Now()
+		if n, err := w.sessionService.BackfillSessions(now.Add(-backfill), now); err != nil {
+			log.Printf("Error backfilling sessions: %v", err)
+		} else {
+			log.Printf("Backfilled %d sessions", n)
+		}
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			if _, err := w.sessionService.RollupSessions(time.Now()); err != nil {
+				log.Printf("Error rolling up sessions: %v", err)
+			}
+		}
+	}()
+	log.Println("Started session rollup")
+}
+
+// StartFiscalPeriodBackfill stamps the fiscal period of financial metrics
+// stored without one.
+func (w *Worker) StartFiscalPeriodBackfill() {
+	go func() {
+		if n, err := w.financialService.StampFiscalPeriods(); err != nil {
+			log.Printf("Error stamping fiscal periods: %v", err)
+		} else if n > 0 {
+			log.Printf("Stamped the fiscal period of %d financial metrics", n)
+		}
+	}()
+}
+
+// StartRealtimePublisher publishes a snapshot of active users and sessions
+// on redis.RealtimeUsersChannel every interval. It is a no-op without Redis.
+func (w *Worker) StartRealtimePublisher(interval time.Duration) {
+	if w.redisClient == nil {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for now := range ticker.C {
+			snapshot, err := w.realtimeService.Snapshot(now)
+			if err != nil {
+				log.Printf("Error computing realtime snapshot: %v", err)
+				continue
+			}
+			w.redisClient.Publish(redis.RealtimeUsersChannel, snapshot)
+		}
+	}()
+	log.Println("Started realtime publisher")
+}
+
+// StartErasureJob runs queued privacy erasures every interval.
+func (w *Worker) StartErasureJob(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			n, err := w.privacyService.ProcessErasures()
+			if err != nil {
+				log.Printf("Error processing privacy erasures: %v", err)
+			}
+			if n > 0 {
+				log.Printf("Completed %d privacy erasures", n)
+			}
+		}
+	}()
+	log.Println("Started privacy erasure job")
+}
+
 func (w *Worker) StartFinancialWorker() error {
 	return w.consumer.ConsumeJSON(FinancialQueue, func(data interface{}) error {
 		jsonData, err := json.Marshal(data)
@@ -128,6 +268,12 @@ func (w *Worker) StartFinancialWorker() error {
 		}
 
 		if err := w.financialService.CreateMetric(&metric); err != nil {
+			// Late metrics for a closed period are set aside for finance
+			// to review rather than retried or dropped.
+			if errors.Is(err, services.ErrPeriodClosed) {
+				log.Printf("Routing financial metric to %s: %v", AdjustmentsQueue, err)
+				return w.publisher.PublishFinancialAdjustment(AdjustmentsQueue, &metric)
+			}
 			return err
 		}
 
@@ -139,6 +285,24 @@ func (w *Worker) StartFinancialWorker() error {
 	})
 }
 
+// StartAdjustmentWorker records the metrics routed to the adjustments queue
+// for the adjustments report.
+func (w *Worker) StartAdjustmentWorker() error {
+	return w.consumer.Consume(AdjustmentsQueue, func(body []byte) error {
+		var metric models.FinancialMetric
+		if err := json.Unmarshal(body, &metric); err != nil {
+			return fmt.Errorf("failed to unmarshal financial adjustment: %w", err)
+		}
+
+		_, err := w.periodCloseService.RecordAdjustment(&metric)
+		if errors.Is(err, services.ErrInvalidInput) {
+			log.Printf("Dropping invalid financial adjustment: %v", err)
+			return nil
+		}
+		return err
+	})
+}
+
 func (w *Worker) StartBatchStockWorker() error {
 	return w.consumer.Consume(StockQueue, func(body []byte) error {
 		var quote models.StockQuote
@@ -181,7 +345,14 @@ func (w *Worker) StartAll() error {
 	if err := w.StartFinancialWorker(); err != nil {
 		return fmt.Errorf("failed to start financial worker: %w", err)
 	}
+	if err := w.StartAdjustmentWorker(); err != nil {
+		return fmt.Errorf("failed to start adjustment worker: %w", err)
+	}
+	w.StartSessionRollup(time.Minute, 30*24*time.Hour)
+	w.StartFiscalPeriodBackfill()
+	w.StartRealtimePublisher(5 * time.Second)
+	w.StartErasureJob(time.Minute)
 
 	log.Println("All workers started")
 	return nil
-}
\ No newline at end of file
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"time"
 
 	"github.com/redis/go-redis/v9"
 )
@@ -20,6 +21,9 @@ var (
 	SalesChannel      = "sales"
 	UserEventsChannel = "user_events"
 	FinancialChannel  = "financial_metrics"
+
+	SalesAttainmentChannel = "sales_attainment"
+	RealtimeUsersChannel   = "realtime_users"
 )
 
 func NewClient() (*Client, error) {
@@ -55,6 +59,94 @@ func (c *Client) Publish(channel string, data interface{}) error {
 	return nil
 }
 
+// PFAdd adds values to the HyperLogLog at key, creating it if needed.
+func (c *Client) PFAdd(key string, values ...string) error {
+	els := make([]interface{}, len(values))
+	for i, v := range values {
+		els[i] = v
+	}
+	return c.rdb.PFAdd(c.ctx, key, els...).Err()
+}
+
+// PFCount returns the approximate cardinality of the union of the
+// HyperLogLogs at keys.
+func (c *Client) PFCount(keys ...string) (int64, error) {
+	return c.rdb.PFCount(c.ctx, keys...).Result()
+}
+
+func (c *Client) Exists(key string) (bool, error) {
+	n, err := c.rdb.Exists(c.ctx, key).Result()
+	return n > 0, err
+}
+
+// Set stores value at key, expiring it after ttl.
+func (c *Client) Set(key, value string, ttl time.Duration) error {
+	return c.rdb.Set(c.ctx, key, value, ttl).Err()
+}
+
+func (c *Client) Expire(key string, ttl time.Duration) error {
+	return c.rdb.Expire(c.ctx, key, ttl).Err()
+}
+
+// ZAddGT adds member to the sorted set at key, or raises its score. An
+// existing member's score is never lowered.
+func (c *Client) ZAddGT(key string, score float64, member string) error {
+	return c.rdb.ZAddGT(c.ctx, key, redis.Z{Score: score, Member: member}).Err()
+}
+
+// ZRangeByScore returns the members of the sorted set at key with scores
+// within [min, max], using Redis score syntax such as "-inf" or "(123".
+func (c *Client) ZRangeByScore(key, min, max string) ([]string, error) {
+	return c.rdb.ZRangeByScore(c.ctx, key, &redis.ZRangeBy{Min: min, Max: max}).Result()
+}
+
+// ZRangeByScoreWithScores is ZRangeByScore returning each member's score.
+func (c *Client) ZRangeByScoreWithScores(key, min, max string) (map[string]float64, error) {
+	entries, err := c.rdb.ZRangeByScoreWithScores(c.ctx, key, &redis.ZRangeBy{Min: min, Max: max}).Result()
+	if err != nil {
+		return nil, err
+	}
+	scores := make(map[string]float64, len(entries))
+	for _, entry := range entries {
+		if member, ok := entry.Member.(string); ok {
+			scores[member] = entry.Score
+		}
+	}
+	return scores, nil
+}
+
+func (c *Client) ZRem(key string, members ...string) error {
+	els := make([]interface{}, len(members))
+	for i, m := range members {
+		els[i] = m
+	}
+	return c.rdb.ZRem(c.ctx, key, els...).Err()
+}
+
+func (c *Client) HSet(key, field, value string) error {
+	return c.rdb.HSet(c.ctx, key, field, value).Err()
+}
+
+// HMGet returns the values of fields in the hash at key. Missing fields are
+// returned as empty strings.
+func (c *Client) HMGet(key string, fields ...string) ([]string, error) {
+	raw, err := c.rdb.HMGet(c.ctx, key, fields...).Result()
+	if err != nil {
+		return nil, err
+	}
+	values := make([]string, len(raw))
+	for i, v := range raw {
+		if s, ok := v.(string); ok {
+			values[i] = s
+		}
+	}
+	return values, nil
+}
+
+func (c *Client) HDel(key string, fields ...string) error {
+	return c.rdb.HDel(c.ctx, key, fields...).Err()
+}
+
 func (c *Client) Subscribe(channels ...string) *redis.PubSub {
 	return c.rdb.Subscribe(c.ctx, channels...)
 }
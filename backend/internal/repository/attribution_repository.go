@@ -0,0 +1,106 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/KalebAsratemedhi/stock-dashboard/backend/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type AttributionRepository interface {
+	IncludeBots() AttributionRepository
+	LinkIdentity(identity *models.CustomerIdentity) error
+	GetConversions(start, end time.Time) ([]ConversionRow, error)
+	GetTouchpoints(start, end, since time.Time) ([]TouchpointRow, error)
+}
+
+// ConversionRow is a sale to be attributed.
+type ConversionRow struct {
+	SaleID     uint
+	CustomerID string
+	Timestamp  time.Time
+	Revenue    float64
+}
+
+// TouchpointRow is the first event of a session by a user linked to
+// CustomerID: the visit's landing page, referrer and metadata.
+type TouchpointRow struct {
+	CustomerID string
+	SessionID  string
+	Timestamp  time.Time
+	Page       string
+	Referrer   string
+	Metadata   string
+}
+
+type attributionRepository struct {
+	db          *gorm.DB
+	includeBots bool
+}
+
+func NewAttributionRepository(db *gorm.DB) AttributionRepository {
+	return &attributionRepository{db: db}
+}
+
+// IncludeBots returns a repository whose touchpoints also include sessions
+// flagged as bot traffic.
+func (r *attributionRepository) IncludeBots() AttributionRepository {
+	return &attributionRepository{db: r.db, includeBots: true}
+}
+
+// LinkIdentity records a user/customer link, keeping the earliest
+// first_seen_at when the link already exists.
+func (r *attributionRepository) LinkIdentity(identity *models.CustomerIdentity) error {
+	return r.db.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "user_id"}, {Name: "customer_id"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"first_seen_at": gorm.Expr("LEAST(customer_identities.first_seen_at, EXCLUDED.first_seen_at)"),
+		}),
+	}).Create(identity).Error
+}
+
+func (r *attributionRepository) GetConversions(start, end time.Time) ([]ConversionRow, error) {
+	var results []ConversionRow
+	err := r.db.Model(&models.Sale{}).
+		Select("id AS sale_id, COALESCE(customer_id, '') AS customer_id, timestamp, revenue").
+		Where("timestamp >= ? AND timestamp <= ?", start, end).
+		Order("timestamp ASC, id ASC").
+		Scan(&results).Error
+	return results, err
+}
+
+// GetTouchpoints returns the sessions started in [since, end] by users linked
+// to a customer with a sale in [start, end], ordered by customer and time.
+func (r *attributionRepository) GetTouchpoints(start, end, since time.Time) ([]TouchpointRow, error) {
+	humanFilter := "NOT e.is_bot"
+	if r.includeBots {
+		humanFilter = "TRUE"
+	}
+	query := `
+		SELECT customer_id, session_id, timestamp, page, referrer, metadata
+		FROM (
+			SELECT DISTINCT ON (ci.customer_id, e.session_id)
+				ci.customer_id,
+				e.session_id,
+				e.timestamp,
+				COALESCE(e.page, '') AS page,
+				COALESCE(e.referrer, '') AS referrer,
+				COALESCE(e.metadata::text, '{}') AS metadata
+			FROM user_events e
+			JOIN customer_identities ci ON ci.user_id = e.user_id
+			WHERE e.timestamp >= ? AND e.timestamp <= ?
+				AND e.session_id <> ''
+				AND ` + humanFilter + `
+				AND ci.customer_id IN (
+					SELECT DISTINCT customer_id FROM sales
+					WHERE timestamp >= ? AND timestamp <= ?
+				)
+			ORDER BY ci.customer_id, e.session_id, e.timestamp
+		) touches
+		ORDER BY customer_id, timestamp
+	`
+	var results []TouchpointRow
+	err := r.db.Raw(query, since, end, start, end).Scan(&results).Error
+	return results, err
+}
@@ -0,0 +1,112 @@
+package repository
+
+import (
+	"github.com/KalebAsratemedhi/stock-dashboard/backend/internal/models"
+	"gorm.io/gorm"
+)
+
+type BudgetRepository interface {
+	Create(budget *models.Budget) error
+	Update(budget *models.Budget) error
+	Delete(id uint) error
+	GetByID(id uint) (*models.Budget, error)
+	GetByKey(key BudgetFilter) (*models.Budget, error)
+	ListOverlapping(budget *models.Budget) ([]*models.Budget, error)
+	List(filter BudgetFilter) ([]*models.Budget, error)
+}
+
+// BudgetFilter selects budget lines. Empty fields match anything in List,
+// whereas GetByKey matches every field exactly.
+type BudgetFilter struct {
+	Department string
+	Category   string
+	MetricType string
+	Period     string
+	Version    string
+}
+
+type budgetRepository struct {
+	db *gorm.DB
+}
+
+func NewBudgetRepository(db *gorm.DB) BudgetRepository {
+	return &budgetRepository{db: db}
+}
+
+func (r *budgetRepository) Create(budget *models.Budget) error {
+	return r.db.Create(budget).Error
+}
+
+func (r *budgetRepository) Update(budget *models.Budget) error {
+	return r.db.Save(budget).Error
+}
+
+func (r *budgetRepository) Delete(id uint) error {
+	result := r.db.Delete(&models.Budget{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (r *budgetRepository) GetByID(id uint) (*models.Budget, error) {
+	var budget models.Budget
+	err := r.db.Where("id = ?", id).First(&budget).Error
+	if err != nil {
+		return nil, err
+	}
+	return &budget, nil
+}
+
+func (r *budgetRepository) GetByKey(key BudgetFilter) (*models.Budget, error) {
+	var budget models.Budget
+	err := r.db.Where("department = ? AND category = ? AND metric_type = ? AND period = ? AND version = ?",
+		key.Department, key.Category, key.MetricType, key.Period, key.Version).
+		First(&budget).Error
+	if err != nil {
+		return nil, err
+	}
+	return &budget, nil
+}
+
+// ListOverlapping returns the other lines of the budget's metric type whose
+// range overlaps the budget's and whose department and category scopes share
+// some actuals with it, an empty value sharing with every value. Lines with
+// the budget's own key and period, its other version, are left out.
+func (r *budgetRepository) ListOverlapping(budget *models.Budget) ([]*models.Budget, error) {
+	var budgets []*models.Budget
+	err := r.db.
+		Where("id <> ? AND metric_type = ? AND period_start < ? AND period_end > ?",
+			budget.ID, budget.MetricType, budget.PeriodEnd, budget.PeriodStart).
+		Where("(department = ? OR department = '' OR ? = '')", budget.Department, budget.Department).
+		Where("(category = ? OR category = '' OR ? = '')", budget.Category, budget.Category).
+		Where("NOT (department = ? AND category = ? AND period = ?)", budget.Department, budget.Category, budget.Period).
+		Order("period_start, id").
+		Find(&budgets).Error
+	return budgets, err
+}
+
+func (r *budgetRepository) List(filter BudgetFilter) ([]*models.Budget, error) {
+	var budgets []*models.Budget
+	query := r.db.Order("period_start DESC, department, category, metric_type, version, id")
+	if filter.Department != "" {
+		query = query.Where("department = ?", filter.Department)
+	}
+	if filter.Category != "" {
+		query = query.Where("category = ?", filter.Category)
+	}
+	if filter.MetricType != "" {
+		query = query.Where("metric_type = ?", filter.MetricType)
+	}
+	if filter.Period != "" {
+		query = query.Where("period = ?", filter.Period)
+	}
+	if filter.Version != "" {
+		query = query.Where("version = ?", filter.Version)
+	}
+	err := query.Find(&budgets).Error
+	return budgets, err
+}
@@ -0,0 +1,192 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/KalebAsratemedhi/stock-dashboard/backend/internal/models"
+	"gorm.io/gorm"
+)
+
+type CostCenterRepository interface {
+	Create(center *models.CostCenter, link *models.CostCenterLink) error
+	Update(center *models.CostCenter) error
+	Delete(id uint) error
+	GetByID(id uint) (*models.CostCenter, error)
+	List() ([]*models.CostCenter, error)
+	GetLinks(id uint) ([]*models.CostCenterLink, error)
+	ListLinks() ([]*models.CostCenterLink, error)
+	HasChildren(id uint) (bool, error)
+	Move(link *models.CostCenterLink) error
+	GetRollup(start, end, asOf time.Time, restate bool, metricType string) ([]CostCenterRollupRow, error)
+}
+
+// CostCenterRollupRow totals one metric type for a cost center and all of
+// its descendants. Own is the part booked to the cost center's own
+// department. CostCenterID 0 holds metrics of departments without a cost
+// center.
+type CostCenterRollupRow struct {
+	CostCenterID uint    `json:"cost_center_id"`
+	MetricType   string  `json:"metric_type"`
+	Amount       float64 `json:"amount"`
+	Own          float64 `json:"own"`
+	Count        int64   `json:"count"`
+}
+
+type costCenterRepository struct {
+	db *gorm.DB
+}
+
+func NewCostCenterRepository(db *gorm.DB) CostCenterRepository {
+	return &costCenterRepository{db: db}
+}
+
+// Create adds a cost center with its first parent link.
+func (r *costCenterRepository) Create(center *models.CostCenter, link *models.CostCenterLink) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(center).Error; err != nil {
+			return err
+		}
+		link.CostCenterID = center.ID
+		return tx.Create(link).Error
+	})
+}
+
+func (r *costCenterRepository) Update(center *models.CostCenter) error {
+	return r.db.Save(center).Error
+}
+
+// Delete removes a cost center and its links.
+func (r *costCenterRepository) Delete(id uint) error {
+	result := r.db.Delete(&models.CostCenter{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (r *costCenterRepository) GetByID(id uint) (*models.CostCenter, error) {
+	var center models.CostCenter
+	err := r.db.Where("id = ?", id).First(&center).Error
+	if err != nil {
+		return nil, err
+	}
+	return &center, nil
+}
+
+func (r *costCenterRepository) List() ([]*models.CostCenter, error) {
+	var centers []*models.CostCenter
+	err := r.db.Order("code").Find(&centers).Error
+	return centers, err
+}
+
+// GetLinks returns the parent links of a cost center, oldest first.
+func (r *costCenterRepository) GetLinks(id uint) ([]*models.CostCenterLink, error) {
+	var links []*models.CostCenterLink
+	err := r.db.Where("cost_center_id = ?", id).
+		Order("effective_from ASC NULLS FIRST, id").
+		Find(&links).Error
+	return links, err
+}
+
+func (r *costCenterRepository) ListLinks() ([]*models.CostCenterLink, error) {
+	var links []*models.CostCenterLink
+	err := r.db.Order("cost_center_id, effective_from ASC NULLS FIRST, id").Find(&links).Error
+	return links, err
+}
+
+// HasChildren reports whether any link, current or past, has the cost
+// center as its parent.
+func (r *costCenterRepository) HasChildren(id uint) (bool, error) {
+	var count int64
+	err := r.db.Model(&models.CostCenterLink{}).Where("parent_id = ?", id).Count(&count).Error
+	return count > 0, err
+}
+
+// Move closes the open link of the cost center at link.EffectiveFrom and
+// adds link.
+func (r *costCenterRepository) Move(link *models.CostCenterLink) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&models.CostCenterLink{}).
+			Where("cost_center_id = ? AND effective_to IS NULL", link.CostCenterID).
+			Update("effective_to", link.EffectiveFrom).Error
+		if err != nil {
+			return err
+		}
+		return tx.Create(link).Error
+	})
+}
+
+// costCenterClosureCTE selects as "closure" every ancestor of every cost
+// center, the cost center itself included at depth 0, with the time window
+// in which the whole path of links is in effect. It takes the restate flag
+// three times and the as-of time twice: restating keeps only the links in
+// effect at the as-of time and treats them as always in effect. Links only
+// point from a level to a higher one, so paths are at most three links long;
+// the depth bound guards against bad rows.
+const costCenterClosureCTE = `
+		WITH RECURSIVE links AS (
+			SELECT
+				cost_center_id,
+				parent_id,
+				CASE WHEN ? THEN '-infinity'::timestamptz ELSE COALESCE(effective_from, '-infinity') END AS valid_from,
+				CASE WHEN ? THEN 'infinity'::timestamptz ELSE COALESCE(effective_to, 'infinity') END AS valid_to
+			FROM cost_center_links
+			WHERE parent_id IS NOT NULL
+				AND (NOT ? OR (COALESCE(effective_from, '-infinity') <= ? AND COALESCE(effective_to, 'infinity') > ?))
+		),
+		closure AS (
+			SELECT id AS descendant, id AS ancestor,
+				'-infinity'::timestamptz AS valid_from, 'infinity'::timestamptz AS valid_to, 0 AS depth
+			FROM cost_centers
+			UNION ALL
+			SELECT c.descendant, l.parent_id,
+				GREATEST(c.valid_from, l.valid_from), LEAST(c.valid_to, l.valid_to), c.depth + 1
+			FROM closure c
+			JOIN links l ON l.cost_center_id = c.ancestor
+			WHERE l.valid_from < c.valid_to AND l.valid_to > c.valid_from AND c.depth < 4
+		)`
+
+// GetRollup totals metrics in [start, end] up the cost center tree. Each
+// metric counts towards the cost center owning its department and every
+// ancestor linked at the metric's timestamp, or, when restating, at asOf.
+// Rows are ordered by cost center and metric type.
+func (r *costCenterRepository) GetRollup(start, end, asOf time.Time, restate bool, metricType string) ([]CostCenterRollupRow, error) {
+	var results []CostCenterRollupRow
+	err := r.db.Raw(costCenterClosureCTE+`,
+		rolled AS (
+			SELECT c.ancestor AS cost_center_id, m.metric_type, m.amount, c.depth
+			FROM financial_metrics m
+			JOIN cost_centers cc ON cc.department = m.department AND cc.department <> ''
+			JOIN closure c ON c.descendant = cc.id
+				AND m.timestamp >= c.valid_from AND m.timestamp < c.valid_to
+			WHERE m.timestamp >= ? AND m.timestamp <= ?
+				AND (? = '' OR m.metric_type = ?)
+		)
+		SELECT
+			cost_center_id,
+			metric_type,
+			SUM(amount) AS amount,
+			SUM(CASE WHEN depth = 0 THEN amount ELSE 0 END) AS own,
+			COUNT(*) AS count
+		FROM rolled
+		GROUP BY cost_center_id, metric_type
+		UNION ALL
+		SELECT 0, metric_type, SUM(amount), SUM(amount), COUNT(*)
+		FROM financial_metrics m
+		WHERE m.timestamp >= ? AND m.timestamp <= ?
+			AND (? = '' OR m.metric_type = ?)
+			AND NOT EXISTS (
+				SELECT 1 FROM cost_centers cc
+				WHERE cc.department = m.department AND cc.department <> ''
+			)
+		GROUP BY metric_type
+		ORDER BY 1, 2
+	`, restate, restate, restate, asOf, asOf,
+		start, end, metricType, metricType,
+		start, end, metricType, metricType,
+	).Scan(&results).Error
+	return results, err
+}
@@ -0,0 +1,186 @@
+package repository
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/KalebAsratemedhi/stock-dashboard/backend/internal/models"
+	"gorm.io/gorm"
+)
+
+type ExperimentRepository interface {
+	IncludeBots() ExperimentRepository
+	Create(experiment *models.Experiment) error
+	Update(experiment *models.Experiment) error
+	Delete(id uint) error
+	GetByID(id uint) (*models.Experiment, error)
+	List() ([]*models.Experiment, error)
+	GetVariantCounts(experiment *models.Experiment, end time.Time) ([]VariantCountRow, error)
+	GetVariantLooks(experiment *models.Experiment, end time.Time) ([]VariantLookRow, error)
+}
+
+// VariantCountRow counts the actors exposed to a variant and those among
+// them converting. Actors seen in more than one variant are counted under
+// an empty Variant.
+type VariantCountRow struct {
+	Variant     string
+	Exposures   int64
+	Conversions int64
+}
+
+// VariantLookRow counts the actors newly exposed to a variant and newly
+// converting since the previous look. Looks are taken at every UTC midnight
+// and at the end of the analysis.
+type VariantLookRow struct {
+	Look        time.Time
+	Variant     string
+	Exposures   int64
+	Conversions int64
+}
+
+type experimentRepository struct {
+	db          *gorm.DB
+	includeBots bool
+}
+
+func NewExperimentRepository(db *gorm.DB) ExperimentRepository {
+	return &experimentRepository{db: db}
+}
+
+// IncludeBots returns a repository whose results also count bot traffic.
+func (r *experimentRepository) IncludeBots() ExperimentRepository {
+	return &experimentRepository{db: r.db, includeBots: true}
+}
+
+func (r *experimentRepository) Create(experiment *models.Experiment) error {
+	return r.db.Create(experiment).Error
+}
+
+func (r *experimentRepository) Update(experiment *models.Experiment) error {
+	return r.db.Save(experiment).Error
+}
+
+func (r *experimentRepository) Delete(id uint) error {
+	result := r.db.Delete(&models.Experiment{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (r *experimentRepository) GetByID(id uint) (*models.Experiment, error) {
+	var experiment models.Experiment
+	err := r.db.Where("id = ?", id).First(&experiment).Error
+	if err != nil {
+		return nil, err
+	}
+	return &experiment, nil
+}
+
+func (r *experimentRepository) List() ([]*models.Experiment, error) {
+	var experiments []*models.Experiment
+	err := r.db.Order("start_at DESC, id ASC").Find(&experiments).Error
+	return experiments, err
+}
+
+// variantOutcomesCTE selects as "outcomes" every actor exposed between the
+// experiment start and end with their variant, empty for actors seen in more
+// than one, the time of their first exposure and the time of their first goal
+// event after it, if any. It takes the experiment key, start, end and key
+// again, then the goal event and end.
+func (r *experimentRepository) variantOutcomesCTE(experiment *models.Experiment) (string, error) {
+	actor, ok := EventIdentityColumns[experiment.Identity]
+	if !ok {
+		return "", fmt.Errorf("unsupported identity: %s", experiment.Identity)
+	}
+	humanFilter, goalHumanFilter := "NOT is_bot", "NOT g.is_bot"
+	if r.includeBots {
+		humanFilter, goalHumanFilter = "TRUE", "TRUE"
+	}
+
+	return fmt.Sprintf(`
+		WITH assignments AS (
+			SELECT %[1]s AS actor, timestamp, metadata->'experiments'->>? AS variant
+			FROM user_events
+			WHERE timestamp >= ? AND timestamp <= ?
+				AND %[1]s <> ''
+				AND metadata->'experiments'->>? IS NOT NULL
+				AND %[2]s
+		),
+		exposures AS (
+			SELECT actor, MIN(timestamp) AS exposed_at, MIN(variant) AS variant, COUNT(DISTINCT variant) AS variants
+			FROM assignments
+			GROUP BY actor
+		),
+		outcomes AS (
+			SELECT
+				CASE WHEN x.variants > 1 THEN '' ELSE x.variant END AS variant,
+				x.exposed_at,
+				(
+					SELECT MIN(g.timestamp) FROM user_events g
+					WHERE g.%[1]s = x.actor
+						AND g.event_type = ?
+						AND g.timestamp >= x.exposed_at AND g.timestamp <= ?
+						AND %[3]s
+				) AS converted_at
+			FROM exposures x
+		)`, actor, humanFilter, goalHumanFilter), nil
+}
+
+// GetVariantCounts counts exposures and conversions per variant between the
+// experiment start and end. An actor converts when the goal event follows
+// their first exposure.
+func (r *experimentRepository) GetVariantCounts(experiment *models.Experiment, end time.Time) ([]VariantCountRow, error) {
+	outcomes, err := r.variantOutcomesCTE(experiment)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []VariantCountRow
+	err = r.db.Raw(outcomes+`
+		SELECT variant, COUNT(*) AS exposures, COUNT(converted_at) AS conversions
+		FROM outcomes
+		GROUP BY variant
+		ORDER BY variant
+	`,
+		experiment.Key, experiment.StartAt, end, experiment.Key,
+		experiment.GoalEvent, end,
+	).Scan(&results).Error
+	return results, err
+}
+
+// GetVariantLooks spreads the counts of GetVariantCounts over the looks at
+// which they were first observed, ordered by look and variant. Each exposure
+// and conversion is counted at the first look at or after it. Actors later
+// seen in a second variant are counted under an empty Variant at every look.
+func (r *experimentRepository) GetVariantLooks(experiment *models.Experiment, end time.Time) ([]VariantLookRow, error) {
+	outcomes, err := r.variantOutcomesCTE(experiment)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []VariantLookRow
+	err = r.db.Raw(outcomes+`,
+		observations AS (
+			SELECT variant, exposed_at AS observed_at, 1 AS exposed, 0 AS converted FROM outcomes
+			UNION ALL
+			SELECT variant, converted_at, 0, 1 FROM outcomes WHERE converted_at IS NOT NULL
+		)
+		SELECT
+			LEAST(time_bucket('1 day', observed_at - interval '1 microsecond') + interval '1 day', ?::timestamptz) AS look,
+			variant,
+			SUM(exposed) AS exposures,
+			SUM(converted) AS conversions
+		FROM observations
+		GROUP BY look, variant
+		ORDER BY look, variant
+	`,
+		experiment.Key, experiment.StartAt, end, experiment.Key,
+		experiment.GoalEvent, end,
+		end,
+	).Scan(&results).Error
+	return results, err
+}
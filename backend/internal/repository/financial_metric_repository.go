@@ -1,6 +1,7 @@
 package repository
 
 import (
+	"fmt"
 	"time"
 
 	"github.com/KalebAsratemedhi/stock-dashboard/backend/internal/models"
@@ -10,12 +11,96 @@ import (
 type FinancialMetricRepository interface {
 	Create(metric *models.FinancialMetric) error
 	BatchCreate(metrics []*models.FinancialMetric) error
+	CreateUnlessClosed(metrics []*models.FinancialMetric, check func(closes []*models.PeriodClose) error) error
 	GetByID(id uint) (*models.FinancialMetric, error)
 	GetByTimeRange(start, end time.Time) ([]*models.FinancialMetric, error)
 	GetByMetricType(metricType string, limit int) ([]*models.FinancialMetric, error)
 	GetByDepartment(department string, limit int) ([]*models.FinancialMetric, error)
 	GetTotalByType(metricType string, start, end time.Time) (float64, error)
-	GetMetricsByDepartment(start, end time.Time) ([]map[string]interface{}, error)
+	GetMetricsByDepartment(start, end time.Time) ([]DepartmentMetricRow, error)
+	GetBudgetLines(start, end time.Time, version string) ([]BudgetLineRow, error)
+	GetBudgetActuals(start, end time.Time, version string) ([]BudgetActualRow, error)
+	GetTimeSeries(start, end time.Time, interval, groupBy string, filter FinancialFilter) ([]FinancialBucketRow, error)
+	GetCategoryTotals(start, end time.Time, department string) ([]CategoryTotalRow, error)
+	GetContributingMetrics(start, end time.Time, metricType, category, department string, limit int) ([]*models.FinancialMetric, error)
+	GetUnstampedRange() (*time.Time, *time.Time, error)
+	StampFiscalPeriod(start, end time.Time, label string) (int64, error)
+}
+
+// FinancialGroupColumns maps the group_by values accepted by financial
+// metric analytics onto their columns. Only keys in this map are ever
+// interpolated into SQL.
+var FinancialGroupColumns = map[string]string{
+	"metric_type": "metric_type",
+	"department":  "department",
+	"category":    "category",
+}
+
+// FinancialFilter restricts financial metric analytics to one metric type
+// and/or department. Empty fields match anything.
+type FinancialFilter struct {
+	MetricType string
+	Department string
+}
+
+// DepartmentMetricRow totals one metric type within one department.
+type DepartmentMetricRow struct {
+	Department  string  `json:"department"`
+	MetricType  string  `json:"metric_type"`
+	TotalAmount float64 `json:"total_amount"`
+	Count       int64   `json:"count"`
+}
+
+// BudgetLineRow is a budget line in force over part of a range.
+type BudgetLineRow struct {
+	Department  string    `json:"department"`
+	Category    string    `json:"category"`
+	MetricType  string    `json:"metric_type"`
+	PeriodStart time.Time `json:"period_start"`
+	PeriodEnd   time.Time `json:"period_end"`
+	Amount      float64   `json:"amount"`
+}
+
+// BudgetActualRow totals the actuals of a metric type for a department and
+// category as scoped by budget lines. Empty Department or Category cover all
+// values.
+type BudgetActualRow struct {
+	Department string  `json:"department"`
+	Category   string  `json:"category"`
+	MetricType string  `json:"metric_type"`
+	Actual     float64 `json:"actual"`
+}
+
+// BudgetVsActualRow compares the actual amount of a metric type with its
+// budget, for a department and category as scoped by budget lines. Empty
+// Department or Category cover all values; metrics of a department and metric
+// type without any budget line are reported with Category empty and a zero
+// Budget.
+type BudgetVsActualRow struct {
+	Department  string  `json:"department"`
+	Category    string  `json:"category"`
+	MetricType  string  `json:"metric_type"`
+	Actual      float64 `json:"actual"`
+	Budget      float64 `json:"budget"`
+	Variance    float64 `json:"variance"`
+	VariancePct float64 `json:"variance_pct"`
+}
+
+// CategoryTotalRow totals one metric type and category.
+type CategoryTotalRow struct {
+	MetricType string  `json:"metric_type"`
+	Category   string  `json:"category"`
+	Amount     float64 `json:"amount"`
+	Count      int64   `json:"count"`
+}
+
+// FinancialBucketRow totals the metrics of one group in one time bucket.
+// Group is empty when the series is not grouped.
+type FinancialBucketRow struct {
+	Group  string    `json:"group"`
+	Bucket time.Time `json:"bucket"`
+	Amount float64   `json:"amount"`
+	Count  int64     `json:"count"`
 }
 
 type financialMetricRepository struct {
@@ -37,6 +122,41 @@ func (r *financialMetricRepository) BatchCreate(metrics []*models.FinancialMetri
 	return r.db.CreateInBatches(metrics, 1000).Error
 }
 
+// CreateUnlessClosed inserts metrics if check accepts the period closes in
+// force around their timestamps. The check and the insert run in one
+// transaction holding a share lock on period_closes, so that no period can be
+// closed or reopened in between.
+func (r *financialMetricRepository) CreateUnlessClosed(metrics []*models.FinancialMetric, check func(closes []*models.PeriodClose) error) error {
+	if len(metrics) == 0 {
+		return nil
+	}
+	start, end := metrics[0].Timestamp, metrics[0].Timestamp
+	for _, metric := range metrics {
+		if metric.Timestamp.Before(start) {
+			start = metric.Timestamp
+		}
+		if metric.Timestamp.After(end) {
+			end = metric.Timestamp
+		}
+	}
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("LOCK TABLE period_closes IN SHARE MODE").Error; err != nil {
+			return err
+		}
+		var closes []*models.PeriodClose
+		err := tx.Where("reopened_at IS NULL AND period_start <= ? AND period_end > ?", end, start).
+			Order("closed_at DESC, id DESC").
+			Find(&closes).Error
+		if err != nil {
+			return err
+		}
+		if err := check(closes); err != nil {
+			return err
+		}
+		return tx.CreateInBatches(metrics, 1000).Error
+	})
+}
+
 func (r *financialMetricRepository) GetByID(id uint) (*models.FinancialMetric, error) {
 	var metric models.FinancialMetric
 	err := r.db.Where("id = ?", id).First(&metric).Error
@@ -83,13 +203,184 @@ func (r *financialMetricRepository) GetTotalByType(metricType string, start, end
 	return total, err
 }
 
-func (r *financialMetricRepository) GetMetricsByDepartment(start, end time.Time) ([]map[string]interface{}, error) {
-	var results []map[string]interface{}
+func (r *financialMetricRepository) GetMetricsByDepartment(start, end time.Time) ([]DepartmentMetricRow, error) {
+	var results []DepartmentMetricRow
 	err := r.db.Model(&models.FinancialMetric{}).
-		Select("department, metric_type, SUM(amount) as total_amount").
+		Select("COALESCE(department, '') AS department, metric_type, SUM(amount) AS total_amount, COUNT(*) AS count").
 		Where("timestamp >= ? AND timestamp <= ?", start, end).
 		Group("department, metric_type").
 		Order("department, metric_type").
-		Find(&results).Error
+		Scan(&results).Error
+	return results, err
+}
+
+// budgetLinesCTE selects as "lines" the budget lines overlapping a range,
+// taking the range end, start and budget version as arguments. Unless the
+// version is "original", a revised line replaces its original.
+const budgetLinesCTE = `
+		WITH lines AS (
+			SELECT DISTINCT ON (department, category, metric_type, period)
+				department, category, metric_type, period_start, period_end, amount
+			FROM budgets
+			WHERE period_start <= ? AND period_end > ?
+				AND (? <> 'original' OR version = 'original')
+			ORDER BY department, category, metric_type, period, version = 'revised' DESC
+		)`
+
+// GetBudgetLines returns the budget lines overlapping [start, end]. version
+// "original" ignores revisions; otherwise a revised line replaces its
+// original.
+func (r *financialMetricRepository) GetBudgetLines(start, end time.Time, version string) ([]BudgetLineRow, error) {
+	var results []BudgetLineRow
+	err := r.db.Raw(budgetLinesCTE+`
+		SELECT department, category, metric_type, period_start, period_end, amount
+		FROM lines
+		ORDER BY department, category, metric_type, period_start
+	`, end, start, version).Scan(&results).Error
 	return results, err
-}
\ No newline at end of file
+}
+
+// GetBudgetActuals totals the actuals in [start, end] per scope of the budget
+// lines GetBudgetLines returns. Each metric counts towards the most specific
+// scope matching it, by department first and then category, so a line across
+// all departments only counts the departments without a line of their own.
+// Metrics of a department and metric type without any line get a scope of
+// their own with Category empty. Rows are ordered by department, metric type
+// and category.
+func (r *financialMetricRepository) GetBudgetActuals(start, end time.Time, version string) ([]BudgetActualRow, error) {
+	var results []BudgetActualRow
+	err := r.db.Raw(budgetLinesCTE+`,
+		budgeted AS (
+			SELECT DISTINCT department, category, metric_type
+			FROM lines
+		),
+		metrics AS (
+			SELECT COALESCE(department, '') AS department, COALESCE(category, '') AS category, metric_type, amount
+			FROM financial_metrics
+			WHERE timestamp >= ? AND timestamp <= ?
+		),
+		scopes AS (
+			SELECT department, category, metric_type
+			FROM budgeted
+			UNION ALL
+			SELECT DISTINCT m.department, '', m.metric_type
+			FROM metrics m
+			WHERE m.department <> ''
+				AND NOT EXISTS (
+					SELECT 1 FROM budgeted b
+					WHERE b.metric_type = m.metric_type AND b.department IN ('', m.department)
+				)
+		),
+		assigned AS (
+			SELECT s.department, s.category, s.metric_type, m.amount
+			FROM metrics m
+			CROSS JOIN LATERAL (
+				SELECT department, category, metric_type
+				FROM scopes
+				WHERE metric_type = m.metric_type
+					AND department IN ('', m.department)
+					AND category IN ('', m.category)
+				ORDER BY department = '', category = ''
+				LIMIT 1
+			) s
+		)
+		SELECT
+			s.department,
+			s.category,
+			s.metric_type,
+			COALESCE(SUM(a.amount), 0) AS actual
+		FROM scopes s
+		LEFT JOIN assigned a
+			ON a.department = s.department AND a.category = s.category AND a.metric_type = s.metric_type
+		GROUP BY s.department, s.category, s.metric_type
+		ORDER BY s.department, s.metric_type, s.category
+	`, end, start, version, start, end).Scan(&results).Error
+	return results, err
+}
+
+// GetTimeSeries totals metrics per interval bucket, split by a column of
+// FinancialGroupColumns when groupBy is set, ordered by group then bucket.
+func (r *financialMetricRepository) GetTimeSeries(start, end time.Time, interval, groupBy string, filter FinancialFilter) ([]FinancialBucketRow, error) {
+	group := "''"
+	if groupBy != "" {
+		column, ok := FinancialGroupColumns[groupBy]
+		if !ok {
+			return nil, fmt.Errorf("unsupported group_by: %s", groupBy)
+		}
+		group = "COALESCE(" + column + ", '')"
+	}
+
+	query := fmt.Sprintf(`
+		SELECT
+			%s AS "group",
+			time_bucket(?::interval, timestamp) AS bucket,
+			SUM(amount) AS amount,
+			COUNT(*) AS count
+		FROM financial_metrics
+		WHERE timestamp >= ? AND timestamp <= ?
+			AND (? = '' OR metric_type = ?)
+			AND (? = '' OR department = ?)
+		GROUP BY 1, 2
+		ORDER BY 1, 2
+	`, group)
+	var results []FinancialBucketRow
+	err := r.db.Raw(query, interval, start, end,
+		filter.MetricType, filter.MetricType, filter.Department, filter.Department,
+	).Scan(&results).Error
+	return results, err
+}
+
+// GetCategoryTotals totals metrics by metric type and category, optionally
+// for one department.
+func (r *financialMetricRepository) GetCategoryTotals(start, end time.Time, department string) ([]CategoryTotalRow, error) {
+	query := r.db.Model(&models.FinancialMetric{}).
+		Where("timestamp >= ? AND timestamp <= ?", start, end)
+	if department != "" {
+		query = query.Where("department = ?", department)
+	}
+
+	var results []CategoryTotalRow
+	err := query.
+		Select("metric_type, COALESCE(category, '') AS category, SUM(amount) AS amount, COUNT(*) AS count").
+		Group("metric_type, category").
+		Order("metric_type, category").
+		Scan(&results).Error
+	return results, err
+}
+
+// GetContributingMetrics returns the metrics of one metric type and category,
+// optionally for one department, largest first.
+func (r *financialMetricRepository) GetContributingMetrics(start, end time.Time, metricType, category, department string, limit int) ([]*models.FinancialMetric, error) {
+	query := r.db.Where("timestamp >= ? AND timestamp <= ?", start, end).
+		Where("metric_type = ? AND COALESCE(category, '') = ?", metricType, category)
+	if department != "" {
+		query = query.Where("department = ?", department)
+	}
+
+	var metrics []*models.FinancialMetric
+	err := query.Order("amount DESC, timestamp, id").Limit(limit).Find(&metrics).Error
+	return metrics, err
+}
+
+// GetUnstampedRange returns the first and last timestamps of metrics without
+// a fiscal period, or nils when every metric has one.
+func (r *financialMetricRepository) GetUnstampedRange() (*time.Time, *time.Time, error) {
+	var bounds struct {
+		First *time.Time
+		Last  *time.Time
+	}
+	err := r.db.Model(&models.FinancialMetric{}).
+		Select("MIN(timestamp) AS first, MAX(timestamp) AS last").
+		Where("fiscal_period IS NULL").
+		Scan(&bounds).Error
+	return bounds.First, bounds.Last, err
+}
+
+// StampFiscalPeriod sets the fiscal period of the metrics in [start, end)
+// that have none, returning how many it set.
+func (r *financialMetricRepository) StampFiscalPeriod(start, end time.Time, label string) (int64, error) {
+	result := r.db.Model(&models.FinancialMetric{}).
+		Where("fiscal_period IS NULL AND timestamp >= ? AND timestamp < ?", start, end).
+		Update("fiscal_period", label)
+	return result.RowsAffected, result.Error
+}
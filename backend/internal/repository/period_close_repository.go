@@ -0,0 +1,129 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/KalebAsratemedhi/stock-dashboard/backend/internal/models"
+	"gorm.io/gorm"
+)
+
+type PeriodCloseRepository interface {
+	CreateExclusive(periodClose *models.PeriodClose, check func(overlapping []*models.PeriodClose) error) error
+	Update(periodClose *models.PeriodClose) error
+	GetOpen(period string) (*models.PeriodClose, error)
+	FindClosed(start, end time.Time) ([]*models.PeriodClose, error)
+	List(period string) ([]*models.PeriodClose, error)
+	CreateAdjustment(adjustment *models.FinancialAdjustment) error
+	ListAdjustments(start, end time.Time, department string, limit int) ([]*models.FinancialAdjustment, error)
+	GetAdjustmentTotals(start, end time.Time, department string) ([]AdjustmentTotalRow, error)
+}
+
+// AdjustmentTotalRow totals the adjustments of one metric type recorded
+// against one period.
+type AdjustmentTotalRow struct {
+	Period     string  `json:"period"`
+	MetricType string  `json:"metric_type"`
+	Amount     float64 `json:"amount"`
+	Count      int64   `json:"count"`
+}
+
+type periodCloseRepository struct {
+	db *gorm.DB
+}
+
+func NewPeriodCloseRepository(db *gorm.DB) PeriodCloseRepository {
+	return &periodCloseRepository{db: db}
+}
+
+// CreateExclusive inserts a close if check accepts the closes in force that
+// overlap its range. Closes are created one at a time, and never while a
+// financial metric insert is checking them.
+func (r *periodCloseRepository) CreateExclusive(periodClose *models.PeriodClose, check func(overlapping []*models.PeriodClose) error) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("LOCK TABLE period_closes IN SHARE ROW EXCLUSIVE MODE").Error; err != nil {
+			return err
+		}
+		var overlapping []*models.PeriodClose
+		err := tx.Where("reopened_at IS NULL AND period_start < ? AND period_end > ?", periodClose.PeriodEnd, periodClose.PeriodStart).
+			Order("period_start, id").
+			Find(&overlapping).Error
+		if err != nil {
+			return err
+		}
+		if err := check(overlapping); err != nil {
+			return err
+		}
+		return tx.Create(periodClose).Error
+	})
+}
+
+func (r *periodCloseRepository) Update(periodClose *models.PeriodClose) error {
+	return r.db.Save(periodClose).Error
+}
+
+// GetOpen returns the close of a period that has not been reopened.
+func (r *periodCloseRepository) GetOpen(period string) (*models.PeriodClose, error) {
+	var periodClose models.PeriodClose
+	err := r.db.Where("period = ? AND reopened_at IS NULL", period).First(&periodClose).Error
+	if err != nil {
+		return nil, err
+	}
+	return &periodClose, nil
+}
+
+// FindClosed returns the closes in force that overlap [start, end], most
+// recent first.
+func (r *periodCloseRepository) FindClosed(start, end time.Time) ([]*models.PeriodClose, error) {
+	var closes []*models.PeriodClose
+	err := r.db.Where("reopened_at IS NULL AND period_start <= ? AND period_end > ?", end, start).
+		Order("closed_at DESC, id DESC").
+		Find(&closes).Error
+	return closes, err
+}
+
+// List returns every close, reopened ones included, optionally of one
+// period, latest period first.
+func (r *periodCloseRepository) List(period string) ([]*models.PeriodClose, error) {
+	var closes []*models.PeriodClose
+	query := r.db.Order("period_start DESC, closed_at DESC, id DESC")
+	if period != "" {
+		query = query.Where("period = ?", period)
+	}
+	err := query.Find(&closes).Error
+	return closes, err
+}
+
+func (r *periodCloseRepository) CreateAdjustment(adjustment *models.FinancialAdjustment) error {
+	return r.db.Create(adjustment).Error
+}
+
+// ListAdjustments returns the adjustments for metrics in [start, end],
+// optionally of one department, latest metric first.
+func (r *periodCloseRepository) ListAdjustments(start, end time.Time, department string, limit int) ([]*models.FinancialAdjustment, error) {
+	query := r.db.Where("timestamp >= ? AND timestamp <= ?", start, end)
+	if department != "" {
+		query = query.Where("department = ?", department)
+	}
+
+	var adjustments []*models.FinancialAdjustment
+	err := query.Order("timestamp DESC, id DESC").Limit(limit).Find(&adjustments).Error
+	return adjustments, err
+}
+
+// GetAdjustmentTotals totals the adjustments for metrics in [start, end] by
+// period and metric type.
+func (r *periodCloseRepository) GetAdjustmentTotals(start, end time.Time, department string) ([]AdjustmentTotalRow, error) {
+	query := r.db.Model(&models.FinancialAdjustment{}).
+		Where("timestamp >= ? AND timestamp <= ?", start, end)
+	if department != "" {
+		query = query.Where("department = ?", department)
+	}
+
+	var results []AdjustmentTotalRow
+	err := query.
+		Select("period, metric_type, SUM(amount) AS amount, COUNT(*) AS count").
+		Group("period, metric_type").
+		Order("period, metric_type").
+		Scan(&results).Error
+	return results, err
+}
@@ -0,0 +1,208 @@
+package repository
+
+import (
+	"github.com/KalebAsratemedhi/stock-dashboard/backend/internal/models"
+	"gorm.io/gorm"
+)
+
+type PrivacyRepository interface {
+	CreateRequest(request *models.PrivacyRequest) error
+	UpdateRequest(request *models.PrivacyRequest) error
+	GetRequestByID(id uint) (*models.PrivacyRequest, error)
+	ListRequests(kind, status string, limit int) ([]*models.PrivacyRequest, error)
+	ListPendingErasures(limit int) ([]*models.PrivacyRequest, error)
+	ResolveSubject(userIDs, customerIDs []string) (*PrivacySubject, error)
+	ExportSubject(subject *PrivacySubject) (*SubjectData, error)
+	EraseSubject(subject *PrivacySubject) (map[string]int64, error)
+}
+
+// PrivacySubject is every stored ID belonging to one data subject: the user
+// IDs and customer IDs linked through customer_identities, and the sessions
+// those users had.
+type PrivacySubject struct {
+	UserIDs     []string
+	CustomerIDs []string
+	SessionIDs  []string
+}
+
+// SubjectData is all rows held about a PrivacySubject.
+type SubjectData struct {
+	UserEvents []*models.UserEvent        `json:"user_events"`
+	Sessions   []*models.UserSession      `json:"sessions"`
+	Identities []*models.CustomerIdentity `json:"customer_identities"`
+	Sales      []*models.Sale             `json:"sales"`
+}
+
+type privacyRepository struct {
+	db *gorm.DB
+}
+
+func NewPrivacyRepository(db *gorm.DB) PrivacyRepository {
+	return &privacyRepository{db: db}
+}
+
+func (r *privacyRepository) CreateRequest(request *models.PrivacyRequest) error {
+	return r.db.Create(request).Error
+}
+
+func (r *privacyRepository) UpdateRequest(request *models.PrivacyRequest) error {
+	return r.db.Save(request).Error
+}
+
+func (r *privacyRepository) GetRequestByID(id uint) (*models.PrivacyRequest, error) {
+	var request models.PrivacyRequest
+	err := r.db.Where("id = ?", id).First(&request).Error
+	if err != nil {
+		return nil, err
+	}
+	return &request, nil
+}
+
+func (r *privacyRepository) ListRequests(kind, status string, limit int) ([]*models.PrivacyRequest, error) {
+	var requests []*models.PrivacyRequest
+	query := r.db.Model(&models.PrivacyRequest{})
+	if kind != "" {
+		query = query.Where("kind = ?", kind)
+	}
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	err := query.Order("created_at DESC, id DESC").Limit(limit).Find(&requests).Error
+	return requests, err
+}
+
+func (r *privacyRepository) ListPendingErasures(limit int) ([]*models.PrivacyRequest, error) {
+	var requests []*models.PrivacyRequest
+	err := r.db.Where("kind = 'erasure' AND status = 'pending'").
+		Order("id ASC").
+		Limit(limit).
+		Find(&requests).Error
+	return requests, err
+}
+
+// ResolveSubject expands the given IDs by one hop of customer_identities and
+// collects the sessions of the resulting users.
+func (r *privacyRepository) ResolveSubject(userIDs, customerIDs []string) (*PrivacySubject, error) {
+	var identities []*models.CustomerIdentity
+	err := r.db.Where("user_id IN ? OR customer_id IN ?", nonEmpty(userIDs), nonEmpty(customerIDs)).
+		Find(&identities).Error
+	if err != nil {
+		return nil, err
+	}
+	subject := &PrivacySubject{
+		UserIDs:     append([]string{}, userIDs...),
+		CustomerIDs: append([]string{}, customerIDs...),
+	}
+	for _, identity := range identities {
+		subject.UserIDs = appendUnique(subject.UserIDs, identity.UserID)
+		subject.CustomerIDs = appendUnique(subject.CustomerIDs, identity.CustomerID)
+	}
+
+	err = r.db.Model(&models.UserEvent{}).
+		Distinct("session_id").
+		Where("session_id <> ''").
+		Where("user_id IN ? OR metadata->>'customer_id' IN ?", nonEmpty(subject.UserIDs), nonEmpty(subject.CustomerIDs)).
+		Pluck("session_id", &subject.SessionIDs).Error
+	if err != nil {
+		return nil, err
+	}
+	return subject, nil
+}
+
+func (r *privacyRepository) ExportSubject(subject *PrivacySubject) (*SubjectData, error) {
+	data := &SubjectData{}
+	err := r.subjectEvents(r.db, subject).Order("timestamp ASC, id ASC").Find(&data.UserEvents).Error
+	if err != nil {
+		return nil, err
+	}
+	err = r.db.Where("user_id IN ? OR session_id IN ?", nonEmpty(subject.UserIDs), nonEmpty(subject.SessionIDs)).
+		Order("started_at ASC").
+		Find(&data.Sessions).Error
+	if err != nil {
+		return nil, err
+	}
+	err = r.db.Where("user_id IN ? OR customer_id IN ?", nonEmpty(subject.UserIDs), nonEmpty(subject.CustomerIDs)).
+		Order("first_seen_at ASC").
+		Find(&data.Identities).Error
+	if err != nil {
+		return nil, err
+	}
+	err = r.db.Where("customer_id IN ?", nonEmpty(subject.CustomerIDs)).
+		Order("timestamp ASC, id ASC").
+		Find(&data.Sales).Error
+	if err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// EraseSubject deletes the subject's events, sessions and identity links and
+// detaches their sales from the customer ID, keeping the sales themselves for
+// financial reporting. It returns the rows affected per table.
+func (r *privacyRepository) EraseSubject(subject *PrivacySubject) (map[string]int64, error) {
+	counts := make(map[string]int64)
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		result := r.subjectEvents(tx, subject).Delete(&models.UserEvent{})
+		if result.Error != nil {
+			return result.Error
+		}
+		counts["user_events"] = result.RowsAffected
+
+		result = tx.Where("user_id IN ? OR session_id IN ?", nonEmpty(subject.UserIDs), nonEmpty(subject.SessionIDs)).
+			Delete(&models.UserSession{})
+		if result.Error != nil {
+			return result.Error
+		}
+		counts["user_sessions"] = result.RowsAffected
+
+		result = tx.Where("user_id IN ? OR customer_id IN ?", nonEmpty(subject.UserIDs), nonEmpty(subject.CustomerIDs)).
+			Delete(&models.CustomerIdentity{})
+		if result.Error != nil {
+			return result.Error
+		}
+		counts["customer_identities"] = result.RowsAffected
+
+		result = tx.Model(&models.Sale{}).
+			Where("customer_id IN ?", nonEmpty(subject.CustomerIDs)).
+			Update("customer_id", gorm.Expr("NULL"))
+		if result.Error != nil {
+			return result.Error
+		}
+		counts["sales"] = result.RowsAffected
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return counts, nil
+}
+
+// subjectEvents selects the subject's events: those of their users and
+// sessions, and purchases tagged with their customer IDs.
+func (r *privacyRepository) subjectEvents(db *gorm.DB, subject *PrivacySubject) *gorm.DB {
+	return db.Model(&models.UserEvent{}).Where(
+		"user_id IN ? OR session_id IN ? OR metadata->>'customer_id' IN ?",
+		nonEmpty(subject.UserIDs), nonEmpty(subject.SessionIDs), nonEmpty(subject.CustomerIDs),
+	)
+}
+
+// nonEmpty drops empty IDs, which would otherwise match anonymous rows. GORM
+// renders an empty list as IN (NULL), which matches nothing.
+func nonEmpty(ids []string) []string {
+	result := make([]string, 0, len(ids))
+	for _, id := range ids {
+		if id != "" {
+			result = append(result, id)
+		}
+	}
+	return result
+}
+
+func appendUnique(ids []string, id string) []string {
+	for _, existing := range ids {
+		if existing == id {
+			return ids
+		}
+	}
+	return append(ids, id)
+}
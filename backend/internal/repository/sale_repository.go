@@ -1,6 +1,7 @@
 package repository
 
 import (
+	"fmt"
 	"time"
 
 	"github.com/KalebAsratemedhi/stock-dashboard/backend/internal/models"
@@ -16,6 +17,58 @@ type SaleRepository interface {
 	GetByRegion(region string, limit int) ([]*models.Sale, error)
 	GetRevenueByTimeRange(start, end time.Time) (float64, error)
 	GetRevenueByCategory(start, end time.Time) ([]map[string]interface{}, error)
+	GetDiscountBands(start, end time.Time, groupBy string) ([]DiscountBandRow, error)
+	GetPriceElasticity(start, end time.Time, groupBy string) ([]PriceElasticityRow, error)
+	GetRevenueBySegment(start, end time.Time, groupBy string) ([]SegmentRevenueRow, error)
+	GetRevenueByGeo(start, end time.Time, region string) ([]SalesGeoRow, error)
+}
+
+// SaleGroupColumns maps the group_by values accepted by sales analytics onto
+// their columns. Only keys in this map are ever interpolated into SQL.
+var SaleGroupColumns = map[string]string{
+	"product":    "product_id",
+	"product_id": "product_id",
+	"category":   "category",
+	"region":     "region",
+	"country":    "country",
+}
+
+// DiscountBandRow aggregates sales for one group within one discount band.
+type DiscountBandRow struct {
+	GroupKey     string  `json:"group_key"`
+	GroupLabel   string  `json:"group_label"`
+	Band         string  `json:"band"`
+	Orders       int64   `json:"orders"`
+	Quantity     int64   `json:"quantity"`
+	Revenue      float64 `json:"revenue"`
+	GrossRevenue float64 `json:"gross_revenue"`
+	AvgPrice     float64 `json:"avg_effective_price"`
+}
+
+// SegmentRevenueRow is revenue and volume for one value of a dimension.
+type SegmentRevenueRow struct {
+	Segment  string  `json:"segment"`
+	Revenue  float64 `json:"revenue"`
+	Quantity int64   `json:"quantity"`
+	Orders   int64   `json:"orders"`
+}
+
+// PriceElasticityRow is the log-log regression of quantity on effective price.
+type PriceElasticityRow struct {
+	GroupKey     string   `json:"group_key"`
+	Elasticity   *float64 `json:"elasticity"`
+	Intercept    *float64 `json:"intercept"`
+	RSquared     *float64 `json:"r_squared"`
+	Observations int64    `json:"observations"`
+}
+
+// SalesGeoRow aggregates sales for one region, or one country within a region.
+type SalesGeoRow struct {
+	Location  string  `json:"location"`
+	Revenue   float64 `json:"revenue"`
+	Orders    int64   `json:"orders"`
+	Quantity  int64   `json:"quantity"`
+	Customers int64   `json:"customers"`
 }
 
 type saleRepository struct {
@@ -92,4 +145,106 @@ func (r *saleRepository) GetRevenueByCategory(start, end time.Time) ([]map[strin
 		Order("total_revenue DESC").
 		Find(&results).Error
 	return results, err
-}
\ No newline at end of file
+}
+
+func (r *saleRepository) GetDiscountBands(start, end time.Time, groupBy string) ([]DiscountBandRow, error) {
+	column, ok := SaleGroupColumns[groupBy]
+	if !ok {
+		return nil, fmt.Errorf("unsupported group_by: %s", groupBy)
+	}
+	label := "MAX(" + column + ")"
+	if column == "product_id" {
+		label = "MAX(product_name)"
+	}
+
+	var results []DiscountBandRow
+	query := fmt.Sprintf(`
+		SELECT
+			COALESCE(%[1]s, '') AS group_key,
+			COALESCE(%[2]s, '') AS group_label,
+			CASE
+				WHEN COALESCE(discount, 0) <= 0 THEN 'none'
+				WHEN discount < 10 THEN '0-10%%'
+				WHEN discount < 20 THEN '10-20%%'
+				ELSE '20%%+'
+			END AS band,
+			COUNT(*) AS orders,
+			SUM(quantity) AS quantity,
+			SUM(revenue) AS revenue,
+			SUM(quantity * unit_price) AS gross_revenue,
+			COALESCE(SUM(revenue) / NULLIF(SUM(quantity), 0), 0) AS avg_price
+		FROM sales
+		WHERE timestamp >= ? AND timestamp <= ?
+		GROUP BY 1, 3
+		ORDER BY 1, MIN(COALESCE(discount, 0))
+	`, column, label)
+	err := r.db.Raw(query, start, end).Scan(&results).Error
+	return results, err
+}
+
+func (r *saleRepository) GetPriceElasticity(start, end time.Time, groupBy string) ([]PriceElasticityRow, error) {
+	column, ok := SaleGroupColumns[groupBy]
+	if !ok {
+		return nil, fmt.Errorf("unsupported group_by: %s", groupBy)
+	}
+
+	var results []PriceElasticityRow
+	query := fmt.Sprintf(`
+		WITH points AS (
+			SELECT
+				COALESCE(%s, '') AS group_key,
+				ln(quantity::float8) AS log_quantity,
+				ln((unit_price * (1 - COALESCE(discount, 0) / 100))::float8) AS log_price
+			FROM sales
+			WHERE timestamp >= ? AND timestamp <= ?
+				AND quantity > 0
+				AND unit_price * (1 - COALESCE(discount, 0) / 100) > 0
+		)
+		SELECT
+			group_key,
+			regr_slope(log_quantity, log_price) AS elasticity,
+			regr_intercept(log_quantity, log_price) AS intercept,
+			regr_r2(log_quantity, log_price) AS r_squared,
+			regr_count(log_quantity, log_price) AS observations
+		FROM points
+		GROUP BY group_key
+		ORDER BY group_key
+	`, column)
+	err := r.db.Raw(query, start, end).Scan(&results).Error
+	return results, err
+}
+
+func (r *saleRepository) GetRevenueBySegment(start, end time.Time, groupBy string) ([]SegmentRevenueRow, error) {
+	column, ok := SaleGroupColumns[groupBy]
+	if !ok {
+		return nil, fmt.Errorf("unsupported group_by: %s", groupBy)
+	}
+
+	var results []SegmentRevenueRow
+	err := r.db.Model(&models.Sale{}).
+		Select(fmt.Sprintf("COALESCE(%s, '') AS segment, SUM(revenue) AS revenue, SUM(quantity) AS quantity, COUNT(*) AS orders", column)).
+		Where("timestamp >= ? AND timestamp <= ?", start, end).
+		Group("segment").
+		Order("revenue DESC").
+		Scan(&results).Error
+	return results, err
+}
+
+// GetRevenueByGeo groups sales by region, or by country when region is set.
+func (r *saleRepository) GetRevenueByGeo(start, end time.Time, region string) ([]SalesGeoRow, error) {
+	column := "region"
+	query := r.db.Model(&models.Sale{}).
+		Where("timestamp >= ? AND timestamp <= ?", start, end)
+	if region != "" {
+		column = "country"
+		query = query.Where("region = ?", region)
+	}
+
+	var results []SalesGeoRow
+	err := query.
+		Select(fmt.Sprintf("COALESCE(%s, '') AS location, SUM(revenue) AS revenue, COUNT(*) AS orders, SUM(quantity) AS quantity, COUNT(DISTINCT customer_id) AS customers", column)).
+		Group("location").
+		Order("revenue DESC, location").
+		Scan(&results).Error
+	return results, err
+}
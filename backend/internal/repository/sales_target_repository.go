@@ -0,0 +1,110 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/KalebAsratemedhi/stock-dashboard/backend/internal/models"
+	"gorm.io/gorm"
+)
+
+type SalesTargetRepository interface {
+	Create(target *models.SalesTarget) error
+	Update(target *models.SalesTarget) error
+	Delete(id uint) error
+	GetByID(id uint) (*models.SalesTarget, error)
+	List(region, category string) ([]*models.SalesTarget, error)
+	GetActive(at time.Time) ([]*models.SalesTarget, error)
+	GetRevenue(ids []uint, at time.Time) (map[uint]float64, error)
+}
+
+type salesTargetRepository struct {
+	db *gorm.DB
+}
+
+func NewSalesTargetRepository(db *gorm.DB) SalesTargetRepository {
+	return &salesTargetRepository{db: db}
+}
+
+func (r *salesTargetRepository) Create(target *models.SalesTarget) error {
+	return r.db.Create(target).Error
+}
+
+func (r *salesTargetRepository) Update(target *models.SalesTarget) error {
+	return r.db.Save(target).Error
+}
+
+func (r *salesTargetRepository) Delete(id uint) error {
+	result := r.db.Delete(&models.SalesTarget{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (r *salesTargetRepository) GetByID(id uint) (*models.SalesTarget, error) {
+	var target models.SalesTarget
+	err := r.db.Where("id = ?", id).First(&target).Error
+	if err != nil {
+		return nil, err
+	}
+	return &target, nil
+}
+
+func (r *salesTargetRepository) List(region, category string) ([]*models.SalesTarget, error) {
+	var targets []*models.SalesTarget
+	query := r.db.Order("period_start DESC, id ASC")
+	if region != "" {
+		query = query.Where("region = ?", region)
+	}
+	if category != "" {
+		query = query.Where("category = ?", category)
+	}
+	err := query.Find(&targets).Error
+	return targets, err
+}
+
+// GetActive returns the targets whose period contains at. Periods are
+// half-open: a target ending at midnight no longer applies at midnight.
+func (r *salesTargetRepository) GetActive(at time.Time) ([]*models.SalesTarget, error) {
+	var targets []*models.SalesTarget
+	err := r.db.Where("period_start <= ? AND period_end > ?", at, at).
+		Order("period_start ASC, id ASC").
+		Find(&targets).Error
+	return targets, err
+}
+
+// GetRevenue totals, in one query, the revenue of the sales counting towards
+// each target from the start of its period up to and including at. Sales at
+// or after the end of a period are left out, matching GetActive.
+func (r *salesTargetRepository) GetRevenue(ids []uint, at time.Time) (map[uint]float64, error) {
+	revenue := make(map[uint]float64, len(ids))
+	if len(ids) == 0 {
+		return revenue, nil
+	}
+
+	var rows []struct {
+		TargetID uint
+		Revenue  float64
+	}
+	err := r.db.Raw(`
+		SELECT t.id AS target_id, COALESCE(SUM(s.revenue), 0) AS revenue
+		FROM sales_targets t
+		LEFT JOIN sales s ON s.timestamp >= t.period_start
+			AND s.timestamp < t.period_end
+			AND s.timestamp <= ?
+			AND (t.region = '' OR s.region = t.region)
+			AND (t.category = '' OR s.category = t.category)
+		WHERE t.id IN ?
+		GROUP BY t.id
+	`, at, ids).Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		revenue[row.TargetID] = row.Revenue
+	}
+	return revenue, nil
+}
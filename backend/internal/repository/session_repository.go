@@ -0,0 +1,165 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/KalebAsratemedhi/stock-dashboard/backend/internal/models"
+	"gorm.io/gorm"
+)
+
+type SessionRepository interface {
+	IncludeBots() SessionRepository
+	Rollup(since, idleBefore time.Time) (int64, error)
+	GetByTimeRange(start, end time.Time, limit int) ([]*models.UserSession, error)
+	GetSummary(start, end time.Time, page string) (*SessionSummaryRow, error)
+	GetSummaryByBucket(start, end time.Time, interval, page string) ([]SessionSummaryRow, error)
+	GetSummaryByEntryPage(start, end time.Time, limit int) ([]SessionSummaryRow, error)
+}
+
+// SessionSummaryRow aggregates sessions, optionally per bucket or entry page.
+type SessionSummaryRow struct {
+	Bucket             *time.Time `json:"bucket,omitempty"`
+	Page               string     `json:"page,omitempty"`
+	Sessions           int64      `json:"sessions"`
+	Bounces            int64      `json:"bounces"`
+	BounceRate         float64    `json:"bounce_rate"`
+	AvgDurationSeconds float64    `json:"avg_duration_seconds"`
+	AvgPageViews       float64    `json:"avg_page_views"`
+	AvgEvents          float64    `json:"avg_events"`
+}
+
+const sessionAggregates = `
+	COUNT(*) AS sessions,
+	COUNT(*) FILTER (WHERE is_bounce) AS bounces,
+	COALESCE(100.0 * COUNT(*) FILTER (WHERE is_bounce) / NULLIF(COUNT(*), 0), 0) AS bounce_rate,
+	COALESCE(AVG(duration_seconds), 0) AS avg_duration_seconds,
+	COALESCE(AVG(page_views), 0) AS avg_page_views,
+	COALESCE(AVG(event_count), 0) AS avg_events
+`
+
+type sessionRepository struct {
+	db          *gorm.DB
+	includeBots bool
+}
+
+func NewSessionRepository(db *gorm.DB) SessionRepository {
+	return &sessionRepository{db: db}
+}
+
+// IncludeBots returns a repository whose queries also read sessions
+// containing bot traffic, which are excluded by default.
+func (r *sessionRepository) IncludeBots() SessionRepository {
+	return &sessionRepository{db: r.db, includeBots: true}
+}
+
+func (r *sessionRepository) sessions() *gorm.DB {
+	query := r.db.Model(&models.UserSession{})
+	if !r.includeBots {
+		query = query.Where("NOT is_bot")
+	}
+	return query
+}
+
+// Rollup upserts a session row for every session with events since `since`
+// whose last event is before idleBefore. A session counts as a bounce when it
+// consists of a single event, and as bot traffic when any event was flagged.
+// Later rollups may only see the tail of a session that started before
+// `since`, so an existing row is only ever extended: counts and the end never
+// go down and the start never moves later.
+func (r *sessionRepository) Rollup(since, idleBefore time.Time) (int64, error) {
+	query := `
+		INSERT INTO user_sessions (
+			session_id, user_id, started_at, ended_at, duration_seconds,
+			event_count, page_views, entry_page, exit_page, is_bounce,
+			device, browser, country, is_bot, created_at, updated_at
+		)
+		SELECT
+			session_id,
+			MAX(user_id),
+			MIN(timestamp),
+			MAX(timestamp),
+			EXTRACT(EPOCH FROM MAX(timestamp) - MIN(timestamp)),
+			COUNT(*),
+			COUNT(*) FILTER (WHERE event_type = 'page_view'),
+			first(page, timestamp),
+			last(page, timestamp),
+			COUNT(*) = 1,
+			first(device, timestamp),
+			first(browser, timestamp),
+			first(country, timestamp),
+			bool_or(is_bot),
+			NOW(),
+			NOW()
+		FROM user_events
+		WHERE timestamp >= ? AND session_id <> ''
+		GROUP BY session_id
+		HAVING MAX(timestamp) < ?
+		ON CONFLICT (session_id) DO UPDATE SET
+			user_id = COALESCE(NULLIF(EXCLUDED.user_id, ''), user_sessions.user_id),
+			started_at = LEAST(user_sessions.started_at, EXCLUDED.started_at),
+			ended_at = GREATEST(user_sessions.ended_at, EXCLUDED.ended_at),
+			duration_seconds = EXTRACT(EPOCH FROM
+				GREATEST(user_sessions.ended_at, EXCLUDED.ended_at) - LEAST(user_sessions.started_at, EXCLUDED.started_at)),
+			event_count = GREATEST(user_sessions.event_count, EXCLUDED.event_count),
+			page_views = GREATEST(user_sessions.page_views, EXCLUDED.page_views),
+			exit_page = CASE WHEN EXCLUDED.ended_at >= user_sessions.ended_at
+				THEN EXCLUDED.exit_page ELSE user_sessions.exit_page END,
+			is_bounce = GREATEST(user_sessions.event_count, EXCLUDED.event_count) = 1,
+			is_bot = user_sessions.is_bot OR EXCLUDED.is_bot,
+			updated_at = NOW()
+		WHERE EXCLUDED.ended_at > user_sessions.ended_at
+			OR EXCLUDED.event_count > user_sessions.event_count
+	`
+	result := r.db.Exec(query, since, idleBefore)
+	return result.RowsAffected, result.Error
+}
+
+func (r *sessionRepository) GetByTimeRange(start, end time.Time, limit int) ([]*models.UserSession, error) {
+	var sessions []*models.UserSession
+	query := r.sessions().Where("started_at >= ? AND started_at <= ?", start, end).Order("started_at DESC")
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+	err := query.Find(&sessions).Error
+	return sessions, err
+}
+
+func (r *sessionRepository) GetSummary(start, end time.Time, page string) (*SessionSummaryRow, error) {
+	var result SessionSummaryRow
+	query := r.sessions().
+		Select(sessionAggregates).
+		Where("started_at >= ? AND started_at <= ?", start, end)
+	if page != "" {
+		query = query.Where("entry_page = ?", page)
+	}
+	if err := query.Scan(&result).Error; err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (r *sessionRepository) GetSummaryByBucket(start, end time.Time, interval, page string) ([]SessionSummaryRow, error) {
+	var results []SessionSummaryRow
+	query := r.sessions().
+		Select("time_bucket(?, started_at) AS bucket,"+sessionAggregates, interval).
+		Where("started_at >= ? AND started_at <= ?", start, end)
+	if page != "" {
+		query = query.Where("entry_page = ?", page)
+	}
+	err := query.Group("bucket").Order("bucket ASC").Scan(&results).Error
+	return results, err
+}
+
+func (r *sessionRepository) GetSummaryByEntryPage(start, end time.Time, limit int) ([]SessionSummaryRow, error) {
+	var results []SessionSummaryRow
+	query := r.sessions().
+		Select("COALESCE(entry_page, '') AS page,"+sessionAggregates).
+		Where("started_at >= ? AND started_at <= ?", start, end).
+		Group("entry_page").
+		Order("sessions DESC, page ASC")
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+	err := query.Scan(&results).Error
+	return results, err
+}
@@ -0,0 +1,168 @@
+package repository
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/KalebAsratemedhi/stock-dashboard/backend/internal/models"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// testDB connects to the TimescaleDB database in TEST_DATABASE_URL, which
+// needs no migrations, and skips the test when it is unset. The pool holds a
+// single connection, so the temporary tables a test creates shadow the real
+// ones for all its queries and vanish with it.
+func testDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("failed to connect to the test database: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+	return db
+}
+
+func TestSessionRollup(t *testing.T) {
+	db := testDB(t)
+	for _, stmt := range []string{
+		`CREATE TEMP TABLE user_events (
+			timestamp TIMESTAMPTZ NOT NULL,
+			event_type VARCHAR(50) NOT NULL,
+			user_id VARCHAR(50),
+			session_id VARCHAR(100),
+			page VARCHAR(255),
+			device VARCHAR(50),
+			browser VARCHAR(50),
+			country VARCHAR(100),
+			is_bot BOOLEAN NOT NULL DEFAULT FALSE
+		)`,
+		`CREATE TEMP TABLE user_sessions (
+			session_id VARCHAR(100) PRIMARY KEY,
+			user_id VARCHAR(50),
+			started_at TIMESTAMPTZ NOT NULL,
+			ended_at TIMESTAMPTZ NOT NULL,
+			duration_seconds DOUBLE PRECISION NOT NULL DEFAULT 0,
+			event_count INTEGER NOT NULL,
+			page_views INTEGER NOT NULL DEFAULT 0,
+			entry_page VARCHAR(255),
+			exit_page VARCHAR(255),
+			is_bounce BOOLEAN NOT NULL DEFAULT FALSE,
+			device VARCHAR(50),
+			browser VARCHAR(50),
+			country VARCHAR(100),
+			is_bot BOOLEAN NOT NULL DEFAULT FALSE,
+			created_at TIMESTAMPTZ DEFAULT NOW(),
+			updated_at TIMESTAMPTZ DEFAULT NOW()
+		)`,
+	} {
+		if err := db.Exec(stmt).Error; err != nil {
+			t.Fatalf("failed to set up tables: %v", err)
+		}
+	}
+
+	base := time.Date(2026, time.March, 1, 12, 0, 0, 0, time.UTC)
+	minutes := func(m int) time.Time { return base.Add(time.Duration(m) * time.Minute) }
+	insert := func(session, eventType, page string, at time.Time, isBot bool) {
+		t.Helper()
+		err := db.Exec(`INSERT INTO user_events (timestamp, event_type, user_id, session_id, page, device, browser, country, is_bot)
+			VALUES (?, ?, ?, ?, ?, 'desktop', 'Firefox', 'SE', ?)`,
+			at, eventType, "u-"+session, session, page, isBot).Error
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	insert("bounce", "page_view", "/", minutes(0), false)
+	insert("visit", "page_view", "/a", minutes(0), false)
+	insert("visit", "click", "/a", minutes(1), false)
+	insert("visit", "page_view", "/b", minutes(5), false)
+	insert("bot", "page_view", "/", minutes(2), false)
+	insert("bot", "page_view", "/", minutes(3), true)
+	insert("active", "page_view", "/", minutes(10), false)
+	insert("active", "page_view", "/", minutes(70), false)
+	insert("stale", "page_view", "/", minutes(-7*60), false)
+	insert("", "page_view", "/", minutes(0), false)
+
+	repo := NewSessionRepository(db)
+	n, err := repo.Rollup(minutes(-6*60), minutes(60))
+	if err != nil {
+		t.Fatalf("Rollup(): %v", err)
+	}
+	if n != 3 {
+		t.Errorf("Rollup() = %d, want 3", n)
+	}
+
+	load := func() map[string]models.UserSession {
+		t.Helper()
+		var sessions []models.UserSession
+		if err := db.Order("session_id").Find(&sessions).Error; err != nil {
+			t.Fatal(err)
+		}
+		byID := make(map[string]models.UserSession, len(sessions))
+		for _, session := range sessions {
+			byID[session.SessionID] = session
+		}
+		return byID
+	}
+	type want struct {
+		start, end        time.Time
+		duration          float64
+		events, pageViews int
+		entry, exit       string
+		bounce, bot       bool
+	}
+	check := func(sessions map[string]models.UserSession, expected map[string]want) {
+		t.Helper()
+		if len(sessions) != len(expected) {
+			t.Errorf("got %d sessions, want %d", len(sessions), len(expected))
+		}
+		for id, w := range expected {
+			s, ok := sessions[id]
+			if !ok {
+				t.Errorf("session %q was not rolled up", id)
+				continue
+			}
+			if !s.StartedAt.Equal(w.start) || !s.EndedAt.Equal(w.end) || s.DurationSeconds != w.duration {
+				t.Errorf("%s: [%s, %s] %vs, want [%s, %s] %vs", id, s.StartedAt, s.EndedAt, s.DurationSeconds, w.start, w.end, w.duration)
+			}
+			if s.EventCount != w.events || s.PageViews != w.pageViews || s.EntryPage != w.entry || s.ExitPage != w.exit {
+				t.Errorf("%s: %d events, %d page views, %s to %s, want %d, %d, %s to %s", id,
+					s.EventCount, s.PageViews, s.EntryPage, s.ExitPage, w.events, w.pageViews, w.entry, w.exit)
+			}
+			if s.IsBounce != w.bounce || s.IsBot != w.bot {
+				t.Errorf("%s: bounce %v bot %v, want bounce %v bot %v", id, s.IsBounce, s.IsBot, w.bounce, w.bot)
+			}
+		}
+	}
+	check(load(), map[string]want{
+		"bounce": {minutes(0), minutes(0), 0, 1, 1, "/", "/", true, false},
+		"visit":  {minutes(0), minutes(5), 300, 3, 2, "/a", "/b", false, false},
+		"bot":    {minutes(2), minutes(3), 60, 2, 2, "/", "/", false, true},
+	})
+
+	// A late event extends the bounce into a visit, while a rollup that only
+	// sees the tail of a session leaves it as it was.
+	insert("bounce", "page_view", "/pricing", minutes(10), false)
+	if _, err := repo.Rollup(minutes(-6*60), minutes(60)); err != nil {
+		t.Fatalf("Rollup(): %v", err)
+	}
+	if _, err := repo.Rollup(minutes(4), minutes(60)); err != nil {
+		t.Fatalf("Rollup(): %v", err)
+	}
+	check(load(), map[string]want{
+		"bounce": {minutes(0), minutes(10), 600, 2, 2, "/", "/pricing", false, false},
+		"visit":  {minutes(0), minutes(5), 300, 3, 2, "/a", "/b", false, false},
+		"bot":    {minutes(2), minutes(3), 60, 2, 2, "/", "/", false, true},
+	})
+}
@@ -1,6 +1,10 @@
 package repository
 
 import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
 	"time"
 
 	"github.com/KalebAsratemedhi/stock-dashboard/backend/internal/models"
@@ -8,24 +12,311 @@ import (
 )
 
 type UserEventRepository interface {
+	IncludeBots() UserEventRepository
 	Create(event *models.UserEvent) error
 	BatchCreate(events []*models.UserEvent) error
 	GetByID(id uint) (*models.UserEvent, error)
 	GetByTimeRange(start, end time.Time) ([]*models.UserEvent, error)
 	GetByEventType(eventType string, limit int) ([]*models.UserEvent, error)
 	GetByUserID(userID string, limit int) ([]*models.UserEvent, error)
+	FindEvents(query EventQuery) ([]*models.UserEvent, error)
+	GroupByMetadata(query EventQuery, group, value []string) ([]MetadataGroupRow, error)
 	GetEventCountsByType(start, end time.Time) ([]map[string]interface{}, error)
 	GetPageViewsByTimeRange(start, end time.Time, interval string) ([]map[string]interface{}, error)
+	CountDistinct(start, end time.Time) (*DistinctCountRow, error)
+	CountDistinctByBucket(start, end time.Time, interval string) ([]DistinctCountRow, error)
+	GetDistinctIDs(start, end time.Time, column string) ([]string, error)
+	GetFunnelEvents(start, end time.Time, identity, breakdown string, steps []EventFilter) ([]FunnelEventRow, error)
+	GetPathLinks(start, end time.Time, anchor string, reverse bool, steps int) ([]PathLinkRow, error)
+	GetTopPaths(start, end time.Time, anchor string, reverse bool, steps, limit int) ([]PathRow, error)
+	GetRetention(start, end time.Time, period, cohortEvent, returnEvent string) ([]RetentionRow, error)
+	GetGeoBreakdown(start, end time.Time, country string) ([]GeoRow, error)
+	GetDimensionBreakdown(start, end time.Time, dimension, eventType string, limit int) ([]DimensionRow, error)
+	GetPageReport(start, end time.Time, sort string, ascending bool, limit, offset int) ([]PageReportRow, int64, error)
+	GetPageTrends(start, end time.Time, interval string, pages []string) ([]PageTrendRow, error)
+}
+
+// PageSortColumns maps the sort values accepted by the page report onto its
+// output columns. Only keys in this map are ever interpolated into SQL.
+var PageSortColumns = map[string]string{
+	"views":            "views",
+	"visitors":         "visitors",
+	"sessions":         "sessions",
+	"entrances":        "entrances",
+	"exits":            "exits",
+	"exit_rate":        "exit_rate",
+	"avg_time_on_page": "avg_time_on_page",
+	"purchase_rate":    "purchase_rate",
+	"page":             "page",
+}
+
+// PageReportRow is the content performance of one page. Time on page is the
+// delta to the session's next page view, so a session's last view has none.
+type PageReportRow struct {
+	Page             string  `json:"page"`
+	Views            int64   `json:"views"`
+	Visitors         int64   `json:"visitors"`
+	Sessions         int64   `json:"sessions"`
+	Entrances        int64   `json:"entrances"`
+	Exits            int64   `json:"exits"`
+	ExitRate         float64 `json:"exit_rate"`
+	AvgTimeOnPage    float64 `json:"avg_time_on_page"`
+	PurchaseSessions int64   `json:"purchase_sessions"`
+	PurchaseRate     float64 `json:"purchase_rate"`
+	Total            int64   `json:"-"`
+}
+
+// PageTrendRow counts views of one page in one time bucket.
+type PageTrendRow struct {
+	Page   string    `json:"page"`
+	Bucket time.Time `json:"bucket"`
+	Views  int64     `json:"views"`
+}
+
+// GeoRow aggregates events for one country, or one city within a country.
+type GeoRow struct {
+	Location  string `json:"location"`
+	Events    int64  `json:"events"`
+	Users     int64  `json:"users"`
+	Sessions  int64  `json:"sessions"`
+	Purchases int64  `json:"purchases"`
+}
+
+// RetentionRow counts cohort users. Rows with a nil ActivePeriod hold the
+// cohort size; others count users returning in ActivePeriod.
+type RetentionRow struct {
+	CohortPeriod time.Time
+	ActivePeriod *time.Time
+	Users        int64
+}
+
+// PathLinkRow counts sessions moving from Page to NextPage, where Offset is
+// the position of Page relative to the anchor page (or the session entry).
+// Paths are measured in steps, i.e. page-to-page transitions.
+type PathLinkRow struct {
+	Offset   int    `json:"offset"`
+	Page     string `json:"page"`
+	NextPage string `json:"next_page"`
+	Sessions int64  `json:"sessions"`
+}
+
+// PathRow counts sessions following the same sequence of pages.
+type PathRow struct {
+	Path     string `json:"path"`
+	Sessions int64  `json:"sessions"`
+}
+
+// EventIdentityColumns maps the identity values accepted by event analytics
+// onto the column identifying an actor.
+var EventIdentityColumns = map[string]string{
+	"user":    "user_id",
+	"session": "session_id",
+}
+
+// EventDimensionColumns maps breakdown dimensions onto user_events columns,
+// or expressions over them. Versions are qualified by their browser or OS
+// name. Only keys in this map are ever interpolated into SQL.
+var EventDimensionColumns = map[string]string{
+	"device":          "device",
+	"browser":         "browser",
+	"browser_version": "CONCAT_WS(' ', NULLIF(browser, ''), NULLIF(browser_version, ''))",
+	"os":              "os",
+	"os_version":      "CONCAT_WS(' ', NULLIF(os, ''), NULLIF(os_version, ''))",
+	"country":         "country",
+}
+
+// DimensionRow aggregates events for one value of a breakdown dimension.
+type DimensionRow struct {
+	Value    string `json:"value"`
+	Events   int64  `json:"events"`
+	Users    int64  `json:"users"`
+	Sessions int64  `json:"sessions"`
+}
+
+// EventFilter matches events by type, page and a metadata subset. Empty
+// fields match anything.
+type EventFilter struct {
+	EventType string                 `json:"event_type"`
+	Page      string                 `json:"page,omitempty"`
+	Metadata  map[string]interface{} `json:"metadata,omitempty"`
+}
+
+// sql renders the filter as a boolean expression and its arguments.
+func (f EventFilter) sql() (string, []interface{}, error) {
+	var clauses []string
+	var args []interface{}
+	if f.EventType != "" {
+		clauses = append(clauses, "event_type = ?")
+		args = append(args, f.EventType)
+	}
+	if f.Page != "" {
+		clauses = append(clauses, "page = ?")
+		args = append(args, f.Page)
+	}
+	if len(f.Metadata) > 0 {
+		encoded, err := json.Marshal(f.Metadata)
+		if err != nil {
+			return "", nil, err
+		}
+		clauses = append(clauses, "metadata @> ?::jsonb")
+		args = append(args, string(encoded))
+	}
+	if len(clauses) == 0 {
+		return "TRUE", nil, nil
+	}
+	return "(" + strings.Join(clauses, " AND ") + ")", args, nil
+}
+
+// MetadataOps are the comparison operators of MetadataPredicate. "exists"
+// ignores Value.
+var MetadataOps = []string{"=", "!=", ">", ">=", "<", "<=", "exists"}
+
+// maxMetadataDepth bounds how deeply predicates may reach into metadata.
+const maxMetadataDepth = 5
+
+var metadataKeyPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+
+// MetadataPredicate compares the metadata value at Path, a list of nested
+// object keys, with Value. Equality is typed JSON containment, so 100 and
+// "100" differ, and != also matches events without the key. Range operators
+// compare numbers numerically and strings lexically, and never match values
+// of another JSON type.
+type MetadataPredicate struct {
+	Path  []string    `json:"path"`
+	Op    string      `json:"op"`
+	Value interface{} `json:"value"`
+}
+
+// ValidMetadataPath reports whether path may be used to query metadata: at
+// most maxMetadataDepth keys of letters, digits, underscores and hyphens.
+func ValidMetadataPath(path []string) bool {
+	if len(path) == 0 || len(path) > maxMetadataDepth {
+		return false
+	}
+	for _, key := range path {
+		if !metadataKeyPattern.MatchString(key) {
+			return false
+		}
+	}
+	return true
+}
+
+// metadataPath renders a metadata path as a text[] literal. Valid keys need
+// no quoting.
+func metadataPath(path []string) (string, error) {
+	if !ValidMetadataPath(path) {
+		return "", fmt.Errorf("unsupported metadata path: %q", path)
+	}
+	return "{" + strings.Join(path, ",") + "}", nil
+}
+
+// metadataNumber is the numeric value at a metadata path, or NULL when the
+// value is missing or not a number. It takes the path argument twice.
+const metadataNumber = "(CASE WHEN jsonb_typeof(metadata #> ?::text[]) = 'number' THEN (metadata #>> ?::text[])::numeric END)"
+
+// sql renders the predicate as a boolean expression and its arguments.
+// Equality uses containment so that it is served by the metadata GIN index.
+func (p MetadataPredicate) sql() (string, []interface{}, error) {
+	path, err := metadataPath(p.Path)
+	if err != nil {
+		return "", nil, err
+	}
+	switch p.Op {
+	case "=", "!=":
+		var nested interface{} = p.Value
+		for i := len(p.Path) - 1; i >= 0; i-- {
+			nested = map[string]interface{}{p.Path[i]: nested}
+		}
+		encoded, err := json.Marshal(nested)
+		if err != nil {
+			return "", nil, err
+		}
+		if p.Op == "!=" {
+			return "NOT (metadata @> ?::jsonb)", []interface{}{string(encoded)}, nil
+		}
+		return "metadata @> ?::jsonb", []interface{}{string(encoded)}, nil
+	case ">", ">=", "<", "<=":
+		switch value := p.Value.(type) {
+		case float64:
+			return metadataNumber + " " + p.Op + " ?", []interface{}{path, path, value}, nil
+		case string:
+			return "(CASE WHEN jsonb_typeof(metadata #> ?::text[]) = 'string' THEN metadata #>> ?::text[] END) " + p.Op + " ?",
+				[]interface{}{path, path, value}, nil
+		}
+		return "", nil, fmt.Errorf("unsupported value for %s: %v", p.Op, p.Value)
+	case "exists":
+		return "metadata #> ?::text[] IS NOT NULL", []interface{}{path}, nil
+	}
+	return "", nil, fmt.Errorf("unsupported metadata operator: %s", p.Op)
+}
+
+// EventQuery selects events in [Start, End], optionally of one type, whose
+// metadata matches every predicate.
+type EventQuery struct {
+	Start     time.Time
+	End       time.Time
+	EventType string
+	Metadata  []MetadataPredicate
+	Limit     int
+}
+
+// MetadataGroupRow aggregates events sharing one value of a metadata key.
+// Value is nil for events without the key; objects and arrays are grouped by
+// their JSON text. Sum and Avg cover the numeric values of the aggregated
+// key and are nil when there are none or no key was given.
+type MetadataGroupRow struct {
+	Value    *string  `json:"value"`
+	Events   int64    `json:"events"`
+	Users    int64    `json:"users"`
+	Sessions int64    `json:"sessions"`
+	Sum      *float64 `json:"sum,omitempty"`
+	Avg      *float64 `json:"avg,omitempty"`
+}
+
+// FunnelEventRow is an event matching at least one funnel step. Steps is a
+// bitmask with bit i set when the event matches step i.
+type FunnelEventRow struct {
+	Actor     string
+	Timestamp time.Time
+	Segment   string
+	Steps     int64
+}
+
+// DistinctCountRow holds exact distinct user and session counts, optionally
+// for a single time bucket.
+type DistinctCountRow struct {
+	Bucket   time.Time `json:"bucket,omitempty"`
+	Users    int64     `json:"users"`
+	Sessions int64     `json:"sessions"`
 }
 
 type userEventRepository struct {
-	db *gorm.DB
+	db          *gorm.DB
+	includeBots bool
 }
 
 func NewUserEventRepository(db *gorm.DB) UserEventRepository {
 	return &userEventRepository{db: db}
 }
 
+// IncludeBots returns a repository whose queries also read events flagged
+// as bot traffic, which are excluded by default.
+func (r *userEventRepository) IncludeBots() UserEventRepository {
+	return &userEventRepository{db: r.db, includeBots: true}
+}
+
+// humanFilter is the condition every read query applies to user_events.
+func (r *userEventRepository) humanFilter() string {
+	if r.includeBots {
+		return "TRUE"
+	}
+	return "NOT is_bot"
+}
+
+func (r *userEventRepository) events() *gorm.DB {
+	return r.db.Model(&models.UserEvent{}).Where(r.humanFilter())
+}
+
 func (r *userEventRepository) Create(event *models.UserEvent) error {
 	return r.db.Create(event).Error
 }
@@ -48,7 +339,7 @@ func (r *userEventRepository) GetByID(id uint) (*models.UserEvent, error) {
 
 func (r *userEventRepository) GetByTimeRange(start, end time.Time) ([]*models.UserEvent, error) {
 	var events []*models.UserEvent
-	err := r.db.Where("timestamp >= ? AND timestamp <= ?", start, end).
+	err := r.events().Where("timestamp >= ? AND timestamp <= ?", start, end).
 		Order("timestamp ASC").
 		Find(&events).Error
 	return events, err
@@ -56,7 +347,7 @@ func (r *userEventRepository) GetByTimeRange(start, end time.Time) ([]*models.Us
 
 func (r *userEventRepository) GetByEventType(eventType string, limit int) ([]*models.UserEvent, error) {
 	var events []*models.UserEvent
-	query := r.db.Where("event_type = ?", eventType).Order("timestamp DESC")
+	query := r.events().Where("event_type = ?", eventType).Order("timestamp DESC")
 	if limit > 0 {
 		query = query.Limit(limit)
 	}
@@ -66,7 +357,7 @@ func (r *userEventRepository) GetByEventType(eventType string, limit int) ([]*mo
 
 func (r *userEventRepository) GetByUserID(userID string, limit int) ([]*models.UserEvent, error) {
 	var events []*models.UserEvent
-	query := r.db.Where("user_id = ?", userID).Order("timestamp DESC")
+	query := r.events().Where("user_id = ?", userID).Order("timestamp DESC")
 	if limit > 0 {
 		query = query.Limit(limit)
 	}
@@ -74,9 +365,74 @@ func (r *userEventRepository) GetByUserID(userID string, limit int) ([]*models.U
 	return events, err
 }
 
+// filter applies an EventQuery's conditions, but not its limit, to events().
+func (r *userEventRepository) filter(query EventQuery) (*gorm.DB, error) {
+	db := r.events().Where("timestamp >= ? AND timestamp <= ?", query.Start, query.End)
+	if query.EventType != "" {
+		db = db.Where("event_type = ?", query.EventType)
+	}
+	for _, predicate := range query.Metadata {
+		cond, args, err := predicate.sql()
+		if err != nil {
+			return nil, err
+		}
+		db = db.Where(cond, args...)
+	}
+	return db, nil
+}
+
+// FindEvents returns the newest events matching the query.
+func (r *userEventRepository) FindEvents(query EventQuery) ([]*models.UserEvent, error) {
+	db, err := r.filter(query)
+	if err != nil {
+		return nil, err
+	}
+	var events []*models.UserEvent
+	err = db.Order("timestamp DESC").Limit(query.Limit).Find(&events).Error
+	return events, err
+}
+
+// GroupByMetadata aggregates the events matching the query by the metadata
+// value at group, summing the numeric value at value when it is set. Groups
+// are ordered by size and limited to query.Limit.
+func (r *userEventRepository) GroupByMetadata(query EventQuery, group, value []string) ([]MetadataGroupRow, error) {
+	groupPath, err := metadataPath(group)
+	if err != nil {
+		return nil, err
+	}
+	amount, args := "NULL::numeric", []interface{}{groupPath}
+	if len(value) > 0 {
+		valuePath, err := metadataPath(value)
+		if err != nil {
+			return nil, err
+		}
+		amount = metadataNumber
+		args = append(args, valuePath, valuePath)
+	}
+	db, err := r.filter(query)
+	if err != nil {
+		return nil, err
+	}
+	events := db.Select("metadata #>> ?::text[] AS value, "+amount+" AS amount, user_id, session_id", args...)
+
+	var results []MetadataGroupRow
+	err = r.db.Table("(?) AS e", events).
+		Select(`value,
+			COUNT(*) AS events,
+			COUNT(DISTINCT NULLIF(user_id, '')) AS users,
+			COUNT(DISTINCT NULLIF(session_id, '')) AS sessions,
+			SUM(amount) AS sum,
+			AVG(amount) AS avg`).
+		Group("value").
+		Order("events DESC, value NULLS LAST").
+		Limit(query.Limit).
+		Scan(&results).Error
+	return results, err
+}
+
 func (r *userEventRepository) GetEventCountsByType(start, end time.Time) ([]map[string]interface{}, error) {
 	var results []map[string]interface{}
-	err := r.db.Model(&models.UserEvent{}).
+	err := r.events().
 		Select("event_type, COUNT(*) as count").
 		Where("timestamp >= ? AND timestamp <= ?", start, end).
 		Group("event_type").
@@ -93,9 +449,390 @@ func (r *userEventRepository) GetPageViewsByTimeRange(start, end time.Time, inte
 			COUNT(*) AS page_views
 		FROM user_events
 		WHERE event_type = 'page_view' AND timestamp >= ? AND timestamp <= ?
+			AND ` + r.humanFilter() + `
+		GROUP BY bucket
+		ORDER BY bucket ASC
+	`
+	err := r.db.Raw(query, interval, start, end).Scan(&results).Error
+	return results, err
+}
+
+func (r *userEventRepository) CountDistinct(start, end time.Time) (*DistinctCountRow, error) {
+	var result DistinctCountRow
+	err := r.events().
+		Select("COUNT(DISTINCT NULLIF(user_id, '')) AS users, COUNT(DISTINCT NULLIF(session_id, '')) AS sessions").
+		Where("timestamp >= ? AND timestamp <= ?", start, end).
+		Scan(&result).Error
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+func (r *userEventRepository) CountDistinctByBucket(start, end time.Time, interval string) ([]DistinctCountRow, error) {
+	var results []DistinctCountRow
+	query := `
+		SELECT
+			time_bucket(?, timestamp) AS bucket,
+			COUNT(DISTINCT NULLIF(user_id, '')) AS users,
+			COUNT(DISTINCT NULLIF(session_id, '')) AS sessions
+		FROM user_events
+		WHERE timestamp >= ? AND timestamp <= ?
+			AND ` + r.humanFilter() + `
 		GROUP BY bucket
 		ORDER BY bucket ASC
 	`
 	err := r.db.Raw(query, interval, start, end).Scan(&results).Error
 	return results, err
-}
\ No newline at end of file
+}
+
+func (r *userEventRepository) GetDistinctIDs(start, end time.Time, column string) ([]string, error) {
+	if column != "user_id" && column != "session_id" {
+		return nil, fmt.Errorf("unsupported column: %s", column)
+	}
+	var ids []string
+	err := r.events().
+		Distinct(column).
+		Where("timestamp >= ? AND timestamp < ? AND "+column+" <> ''", start, end).
+		Pluck(column, &ids).Error
+	return ids, err
+}
+
+func (r *userEventRepository) GetFunnelEvents(start, end time.Time, identity, breakdown string, steps []EventFilter) ([]FunnelEventRow, error) {
+	actor, ok := EventIdentityColumns[identity]
+	if !ok {
+		return nil, fmt.Errorf("unsupported identity: %s", identity)
+	}
+	segment := "''"
+	if breakdown != "" {
+		column, ok := EventDimensionColumns[breakdown]
+		if !ok {
+			return nil, fmt.Errorf("unsupported breakdown: %s", breakdown)
+		}
+		segment = "COALESCE(" + column + ", '')"
+	}
+	if len(steps) == 0 || len(steps) > 62 {
+		return nil, fmt.Errorf("unsupported number of steps: %d", len(steps))
+	}
+
+	var masks, matches []string
+	var maskArgs, matchArgs []interface{}
+	for i, step := range steps {
+		cond, args, err := step.sql()
+		if err != nil {
+			return nil, err
+		}
+		masks = append(masks, fmt.Sprintf("(CASE WHEN %s THEN %d::bigint ELSE 0::bigint END)", cond, int64(1)<<i))
+		maskArgs = append(maskArgs, args...)
+		matches = append(matches, cond)
+		matchArgs = append(matchArgs, args...)
+	}
+
+	query := fmt.Sprintf(`
+		SELECT
+			%[1]s AS actor,
+			timestamp,
+			%[2]s AS segment,
+			%[3]s AS steps
+		FROM user_events
+		WHERE timestamp >= ? AND timestamp <= ?
+			AND %[1]s <> ''
+			AND %[5]s
+			AND (%[4]s)
+		ORDER BY actor, timestamp ASC
+	`, actor, segment, strings.Join(masks, " | "), strings.Join(matches, " OR "), r.humanFilter())
+
+	args := append(maskArgs, start, end)
+	args = append(args, matchArgs...)
+
+	var results []FunnelEventRow
+	err := r.db.Raw(query, args...).Scan(&results).Error
+	return results, err
+}
+
+// pageSequenceCTE numbers each session's page views, collapsing repeated views
+// of the same page, and finds each session's anchor step: the first view of
+// the anchor page, or step 1 when no anchor is given.
+func (r *userEventRepository) pageSequenceCTE() string {
+	return `
+	WITH views AS (
+		SELECT
+			session_id,
+			page,
+			timestamp,
+			LAG(page) OVER (PARTITION BY session_id ORDER BY timestamp) AS prev_page
+		FROM user_events
+		WHERE event_type = 'page_view'
+			AND timestamp >= ? AND timestamp <= ?
+			AND session_id <> '' AND page <> ''
+			AND ` + r.humanFilter() + `
+	),
+	sequence AS (
+		SELECT
+			session_id,
+			page,
+			ROW_NUMBER() OVER (PARTITION BY session_id ORDER BY timestamp) AS step
+		FROM views
+		WHERE prev_page IS DISTINCT FROM page
+	),
+	anchors AS (
+		SELECT session_id, MIN(step) AS anchor
+		FROM sequence
+		WHERE ? = '' OR page = ?
+		GROUP BY session_id
+	)
+`
+}
+
+func (r *userEventRepository) GetPathLinks(start, end time.Time, anchor string, reverse bool, steps int) ([]PathLinkRow, error) {
+	window := "s.step - a.anchor BETWEEN 0 AND ?"
+	if reverse {
+		window = "s.step - a.anchor BETWEEN -(?::int) AND -1"
+	}
+	query := r.pageSequenceCTE() + `
+		SELECT
+			(s.step - a.anchor)::int AS offset,
+			s.page,
+			n.page AS next_page,
+			COUNT(*) AS sessions
+		FROM sequence s
+		JOIN anchors a ON a.session_id = s.session_id
+		JOIN sequence n ON n.session_id = s.session_id AND n.step = s.step + 1
+		WHERE ` + window + `
+		GROUP BY 1, 2, 3
+		ORDER BY 1, sessions DESC, 2, 3
+	`
+	limit := steps - 1
+	if reverse {
+		limit = steps
+	}
+	var results []PathLinkRow
+	err := r.db.Raw(query, start, end, anchor, anchor, limit).Scan(&results).Error
+	return results, err
+}
+
+func (r *userEventRepository) GetTopPaths(start, end time.Time, anchor string, reverse bool, steps, limit int) ([]PathRow, error) {
+	window := "s.step - a.anchor BETWEEN 0 AND ?"
+	if reverse {
+		window = "s.step - a.anchor BETWEEN -(?::int) AND 0"
+	}
+	query := r.pageSequenceCTE() + `
+		SELECT path, COUNT(*) AS sessions
+		FROM (
+			SELECT s.session_id, string_agg(s.page, ' > ' ORDER BY s.step) AS path
+			FROM sequence s
+			JOIN anchors a ON a.session_id = s.session_id
+			WHERE ` + window + `
+			GROUP BY s.session_id
+		) paths
+		GROUP BY path
+		ORDER BY sessions DESC, path
+		LIMIT ?
+	`
+	var results []PathRow
+	err := r.db.Raw(query, start, end, anchor, anchor, steps, limit).Scan(&results).Error
+	return results, err
+}
+
+// GetRetention assigns each user to the period ("week" or "month") of their
+// first cohortEvent (or first event of any type), keeping cohorts that start
+// within [start, end], and counts the users of each cohort with a returnEvent
+// in each later period.
+func (r *userEventRepository) GetRetention(start, end time.Time, period, cohortEvent, returnEvent string) ([]RetentionRow, error) {
+	if period != "week" && period != "month" {
+		return nil, fmt.Errorf("unsupported period: %s", period)
+	}
+	query := `
+		WITH cohort AS (
+			SELECT user_id, date_trunc(?, MIN(timestamp)) AS cohort_period
+			FROM user_events
+			WHERE user_id <> '' AND (? = '' OR event_type = ?)
+				AND ` + r.humanFilter() + `
+			GROUP BY user_id
+			HAVING MIN(timestamp) >= ? AND MIN(timestamp) <= ?
+		),
+		activity AS (
+			SELECT DISTINCT user_id, date_trunc(?, timestamp) AS active_period
+			FROM user_events
+			WHERE user_id <> '' AND timestamp >= ? AND (? = '' OR event_type = ?)
+				AND ` + r.humanFilter() + `
+		)
+		SELECT cohort_period, NULL::timestamptz AS active_period, COUNT(*) AS users
+		FROM cohort
+		GROUP BY cohort_period
+		UNION ALL
+		SELECT c.cohort_period, a.active_period, COUNT(*) AS users
+		FROM cohort c
+		JOIN activity a ON a.user_id = c.user_id AND a.active_period > c.cohort_period
+		GROUP BY c.cohort_period, a.active_period
+		ORDER BY 1, 2 NULLS FIRST
+	`
+	var results []RetentionRow
+	err := r.db.Raw(query,
+		period, cohortEvent, cohortEvent, start, end,
+		period, start, returnEvent, returnEvent,
+	).Scan(&results).Error
+	return results, err
+}
+
+// GetGeoBreakdown groups events by country, or by city when country is set.
+func (r *userEventRepository) GetGeoBreakdown(start, end time.Time, country string) ([]GeoRow, error) {
+	column := "country"
+	query := r.events().
+		Where("timestamp >= ? AND timestamp <= ?", start, end)
+	if country != "" {
+		column = "city"
+		query = query.Where("country = ?", country)
+	}
+
+	var results []GeoRow
+	err := query.
+		Select(fmt.Sprintf(`COALESCE(%s, '') AS location,
+			COUNT(*) AS events,
+			COUNT(DISTINCT NULLIF(user_id, '')) AS users,
+			COUNT(DISTINCT NULLIF(session_id, '')) AS sessions,
+			COUNT(*) FILTER (WHERE event_type = 'purchase') AS purchases`, column)).
+		Group("location").
+		Order("events DESC, location").
+		Scan(&results).Error
+	return results, err
+}
+
+// GetDimensionBreakdown aggregates events by a dimension of
+// EventDimensionColumns, optionally for one event type, largest first.
+func (r *userEventRepository) GetDimensionBreakdown(start, end time.Time, dimension, eventType string, limit int) ([]DimensionRow, error) {
+	column, ok := EventDimensionColumns[dimension]
+	if !ok {
+		return nil, fmt.Errorf("unsupported dimension: %s", dimension)
+	}
+	query := r.events().
+		Where("timestamp >= ? AND timestamp <= ?", start, end)
+	if eventType != "" {
+		query = query.Where("event_type = ?", eventType)
+	}
+
+	var results []DimensionRow
+	err := query.
+		Select(fmt.Sprintf(`COALESCE(%s, '') AS value,
+			COUNT(*) AS events,
+			COUNT(DISTINCT NULLIF(user_id, '')) AS users,
+			COUNT(DISTINCT NULLIF(session_id, '')) AS sessions`, column)).
+		Group("value").
+		Order("events DESC, value").
+		Limit(limit).
+		Scan(&results).Error
+	return results, err
+}
+
+// GetPageReport aggregates session page views per page. Purchase sessions are
+// sessions with a purchase after viewing the page. The second result is the
+// number of pages before pagination.
+func (r *userEventRepository) GetPageReport(start, end time.Time, sort string, ascending bool, limit, offset int) ([]PageReportRow, int64, error) {
+	column, ok := PageSortColumns[sort]
+	if !ok {
+		return nil, 0, fmt.Errorf("unsupported sort: %s", sort)
+	}
+	direction := "DESC"
+	if ascending {
+		direction = "ASC"
+	}
+
+	query := fmt.Sprintf(`
+		WITH session_events AS (
+			SELECT
+				session_id,
+				user_id,
+				page,
+				event_type,
+				timestamp,
+				MAX(timestamp) FILTER (WHERE event_type = 'purchase') OVER (PARTITION BY session_id) AS last_purchase
+			FROM user_events
+			WHERE timestamp >= ? AND timestamp <= ?
+				AND session_id <> ''
+				AND %s
+		),
+		views AS (
+			SELECT
+				session_id,
+				user_id,
+				page,
+				timestamp,
+				last_purchase,
+				LEAD(timestamp) OVER w AS next_view,
+				ROW_NUMBER() OVER w = 1 AS is_entrance
+			FROM session_events
+			WHERE event_type = 'page_view' AND page <> ''
+			WINDOW w AS (PARTITION BY session_id ORDER BY timestamp)
+		),
+		pages AS (
+			SELECT
+				page,
+				COUNT(*) AS views,
+				COUNT(DISTINCT COALESCE(NULLIF(user_id, ''), session_id)) AS visitors,
+				COUNT(DISTINCT session_id) AS sessions,
+				COUNT(*) FILTER (WHERE is_entrance) AS entrances,
+				COUNT(*) FILTER (WHERE next_view IS NULL) AS exits,
+				COALESCE(AVG(EXTRACT(EPOCH FROM next_view - timestamp)), 0) AS avg_time_on_page,
+				COUNT(DISTINCT session_id) FILTER (WHERE last_purchase > timestamp) AS purchase_sessions
+			FROM views
+			GROUP BY page
+		)
+		SELECT
+			*,
+			100.0 * exits / views AS exit_rate,
+			100.0 * purchase_sessions / sessions AS purchase_rate,
+			COUNT(*) OVER () AS total
+		FROM pages
+		ORDER BY %s %s, page
+		LIMIT ? OFFSET ?
+	`, r.humanFilter(), column, direction)
+
+	var results []PageReportRow
+	if err := r.db.Raw(query, start, end, limit, offset).Scan(&results).Error; err != nil {
+		return nil, 0, err
+	}
+	var total int64
+	if len(results) > 0 {
+		total = results[0].Total
+	} else if offset > 0 {
+		// Paging past the end returns no rows to read the total from.
+		err := r.events().
+			Where("timestamp >= ? AND timestamp <= ?", start, end).
+			Where("event_type = 'page_view' AND page <> '' AND session_id <> ''").
+			Distinct("page").
+			Count(&total).Error
+		if err != nil {
+			return nil, 0, err
+		}
+	}
+	return results, total, nil
+}
+
+// GetPageTrends counts views per bucket for the given pages, with empty
+// buckets filled with zero so every page has the same series length.
+func (r *userEventRepository) GetPageTrends(start, end time.Time, interval string, pages []string) ([]PageTrendRow, error) {
+	if len(pages) == 0 {
+		return nil, nil
+	}
+	// Gap-filled buckets have no rows to count, so their views are NULL
+	// until the outer select replaces them with zero.
+	query := `
+		SELECT page, bucket, COALESCE(views, 0) AS views
+		FROM (
+			SELECT
+				page,
+				time_bucket_gapfill(?::interval, timestamp, ?::timestamptz, ?::timestamptz) AS bucket,
+				COUNT(*) AS views
+			FROM user_events
+			WHERE event_type = 'page_view'
+				AND timestamp >= ? AND timestamp <= ?
+				AND session_id <> '' AND page IN ?
+				AND ` + r.humanFilter() + `
+			GROUP BY page, bucket
+		) trends
+		ORDER BY page, bucket
+	`
+	var results []PageTrendRow
+	err := r.db.Raw(query, interval, start, end, start, end, pages).Scan(&results).Error
+	return results, err
+}
@@ -0,0 +1,79 @@
+package repository
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMetadataPredicateSQL(t *testing.T) {
+	tests := []struct {
+		name      string
+		predicate MetadataPredicate
+		wantSQL   string
+		wantArgs  []interface{}
+		wantErr   bool
+	}{
+		{
+			name:      "equality uses containment",
+			predicate: MetadataPredicate{Path: []string{"cart", "plan"}, Op: "=", Value: "pro"},
+			wantSQL:   "metadata @> ?::jsonb",
+			wantArgs:  []interface{}{`{"cart":{"plan":"pro"}}`},
+		},
+		{
+			name:      "inequality negates containment",
+			predicate: MetadataPredicate{Path: []string{"trial"}, Op: "!=", Value: true},
+			wantSQL:   "NOT (metadata @> ?::jsonb)",
+			wantArgs:  []interface{}{`{"trial":true}`},
+		},
+		{
+			name:      "numeric comparison",
+			predicate: MetadataPredicate{Path: []string{"cart", "total"}, Op: ">=", Value: 100.0},
+			wantSQL:   metadataNumber + " >= ?",
+			wantArgs:  []interface{}{"{cart,total}", "{cart,total}", 100.0},
+		},
+		{
+			name:      "string comparison",
+			predicate: MetadataPredicate{Path: []string{"version"}, Op: "<", Value: "v2"},
+			wantSQL:   "(CASE WHEN jsonb_typeof(metadata #> ?::text[]) = 'string' THEN metadata #>> ?::text[] END) < ?",
+			wantArgs:  []interface{}{"{version}", "{version}", "v2"},
+		},
+		{
+			name:      "presence",
+			predicate: MetadataPredicate{Path: []string{"coupon"}, Op: "exists"},
+			wantSQL:   "metadata #> ?::text[] IS NOT NULL",
+			wantArgs:  []interface{}{"{coupon}"},
+		},
+		{
+			name:      "comparison with a boolean",
+			predicate: MetadataPredicate{Path: []string{"trial"}, Op: ">", Value: true},
+			wantErr:   true,
+		},
+		{
+			name:      "unknown operator",
+			predicate: MetadataPredicate{Path: []string{"plan"}, Op: "~", Value: "pro"},
+			wantErr:   true,
+		},
+		{
+			name:      "key that would need quoting",
+			predicate: MetadataPredicate{Path: []string{"a,b"}, Op: "exists"},
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sql, args, err := tt.predicate.sql()
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("sql() = %q, want an error", sql)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("sql(): %v", err)
+			}
+			if sql != tt.wantSQL || !reflect.DeepEqual(args, tt.wantArgs) {
+				t.Errorf("sql() = %q %v, want %q %v", sql, args, tt.wantSQL, tt.wantArgs)
+			}
+		})
+	}
+}
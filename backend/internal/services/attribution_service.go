@@ -0,0 +1,272 @@
+package services
+
+import (
+	"encoding/json"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/KalebAsratemedhi/stock-dashboard/backend/internal/enrich"
+	"github.com/KalebAsratemedhi/stock-dashboard/backend/internal/models"
+	"github.com/KalebAsratemedhi/stock-dashboard/backend/internal/repository"
+)
+
+const (
+	defaultAttributionLookback = 30 * 24 * time.Hour
+	maxAttributionLookback     = 90 * 24 * time.Hour
+
+	// attributionHalfLife is the age at which a touch earns half the weight
+	// of a touch at the time of the sale under the time_decay model.
+	attributionHalfLife = 7 * 24 * time.Hour
+
+	unattributedChannel = "(unattributed)"
+)
+
+// AttributionModels are the supported models, in report order.
+var AttributionModels = []string{"first_touch", "last_touch", "linear", "time_decay"}
+
+// AttributionQuery configures GetAttribution. Model is one of
+// AttributionModels, or empty for all of them. GroupBy is channel (default),
+// source, medium or campaign.
+type AttributionQuery struct {
+	Model    string
+	GroupBy  string
+	Lookback time.Duration
+}
+
+// AttributionRow is the revenue and conversions credited to one group under
+// each model. Conversions are fractional under linear and time_decay.
+type AttributionRow struct {
+	Group       string             `json:"group"`
+	Revenue     map[string]float64 `json:"revenue"`
+	Conversions map[string]float64 `json:"conversions"`
+}
+
+// AttributionReport is the response of GetAttribution. Sales without a linked
+// customer or without touches in the lookback window are credited to
+// "(unattributed)".
+type AttributionReport struct {
+	Range               TimeRange         `json:"range"`
+	Models              []string          `json:"models"`
+	GroupBy             string            `json:"group_by"`
+	LookbackDays        float64           `json:"lookback_days"`
+	TotalRevenue        float64           `json:"total_revenue"`
+	AttributedRevenue   float64           `json:"attributed_revenue"`
+	UnattributedRevenue float64           `json:"unattributed_revenue"`
+	Sales               int               `json:"sales"`
+	AttributedSales     int               `json:"attributed_sales"`
+	Groups              []*AttributionRow `json:"groups"`
+}
+
+type AttributionService interface {
+	IncludeBots() AttributionService
+	RecordIdentity(event *models.UserEvent) error
+	LinkIdentity(userID, customerID string, at time.Time) error
+	GetAttribution(start, end time.Time, query AttributionQuery) (*AttributionReport, error)
+}
+
+type attributionService struct {
+	repo repository.AttributionRepository
+}
+
+func NewAttributionService(repo repository.AttributionRepository) AttributionService {
+	return &attributionService{repo: repo}
+}
+
+// IncludeBots returns a service whose touches also include bot traffic.
+func (s *attributionService) IncludeBots() AttributionService {
+	return &attributionService{repo: s.repo.IncludeBots()}
+}
+
+// RecordIdentity links the event's user to the customer_id in its metadata,
+// if both are present.
+func (s *attributionService) RecordIdentity(event *models.UserEvent) error {
+	if event.UserID == "" || event.Metadata == "" || event.IsBot {
+		return nil
+	}
+	var metadata struct {
+		CustomerID string `json:"customer_id"`
+	}
+	if err := json.Unmarshal([]byte(event.Metadata), &metadata); err != nil || metadata.CustomerID == "" {
+		return nil
+	}
+	return s.LinkIdentity(event.UserID, metadata.CustomerID, event.Timestamp)
+}
+
+func (s *attributionService) LinkIdentity(userID, customerID string, at time.Time) error {
+	if userID == "" || customerID == "" || len(userID) > 50 || len(customerID) > 50 {
+		return ErrInvalidInput
+	}
+	if at.IsZero() {
+		at = time.Now()
+	}
+	return s.repo.LinkIdentity(&models.CustomerIdentity{
+		UserID:      userID,
+		CustomerID:  customerID,
+		FirstSeenAt: at,
+	})
+}
+
+type attributionTouch struct {
+	at    time.Time
+	group string
+}
+
+func (s *attributionService) GetAttribution(start, end time.Time, query AttributionQuery) (*AttributionReport, error) {
+	if end.Before(start) {
+		return nil, ErrInvalidInput
+	}
+	modelNames := AttributionModels
+	if query.Model != "" {
+		if !containsString(AttributionModels, query.Model) {
+			return nil, ErrInvalidInput
+		}
+		modelNames = []string{query.Model}
+	}
+	if query.GroupBy == "" {
+		query.GroupBy = "channel"
+	}
+	groupOf, ok := touchGroupers[query.GroupBy]
+	if !ok {
+		return nil, ErrInvalidInput
+	}
+	if query.Lookback == 0 {
+		query.Lookback = defaultAttributionLookback
+	}
+	if query.Lookback < 0 || query.Lookback > maxAttributionLookback {
+		return nil, ErrInvalidInput
+	}
+
+	conversions, err := s.repo.GetConversions(start, end)
+	if err != nil {
+		return nil, err
+	}
+	touchpoints, err := s.repo.GetTouchpoints(start, end, start.Add(-query.Lookback))
+	if err != nil {
+		return nil, err
+	}
+
+	touches := make(map[string][]attributionTouch)
+	for _, tp := range touchpoints {
+		var metadata map[string]interface{}
+		_ = json.Unmarshal([]byte(tp.Metadata), &metadata)
+		touch := enrich.ParseTouch(tp.Page, tp.Referrer, metadata)
+		touches[tp.CustomerID] = append(touches[tp.CustomerID], attributionTouch{at: tp.Timestamp, group: groupOf(touch)})
+	}
+
+	report := &AttributionReport{
+		Range:        TimeRange{Start: start, End: end},
+		Models:       modelNames,
+		GroupBy:      query.GroupBy,
+		LookbackDays: query.Lookback.Hours() / 24,
+		Sales:        len(conversions),
+		Groups:       []*AttributionRow{},
+	}
+	groups := make(map[string]*AttributionRow)
+	credit := func(group, model string, weight, revenue float64) {
+		row, ok := groups[group]
+		if !ok {
+			row = &AttributionRow{Group: group, Revenue: map[string]float64{}, Conversions: map[string]float64{}}
+			for _, m := range modelNames {
+				row.Revenue[m] = 0
+				row.Conversions[m] = 0
+			}
+			groups[group] = row
+			report.Groups = append(report.Groups, row)
+		}
+		row.Revenue[model] += weight * revenue
+		row.Conversions[model] += weight
+	}
+
+	for _, sale := range conversions {
+		report.TotalRevenue += sale.Revenue
+		window := touchesWithin(touches[sale.CustomerID], sale.Timestamp.Add(-query.Lookback), sale.Timestamp)
+		if len(window) == 0 {
+			report.UnattributedRevenue += sale.Revenue
+			for _, model := range modelNames {
+				credit(unattributedChannel, model, 1, sale.Revenue)
+			}
+			continue
+		}
+		report.AttributedRevenue += sale.Revenue
+		report.AttributedSales++
+		for _, model := range modelNames {
+			for i, weight := range attributionWeights(model, window, sale.Timestamp) {
+				if weight > 0 {
+					credit(window[i].group, model, weight, sale.Revenue)
+				}
+			}
+		}
+	}
+
+	sortBy := modelNames[0]
+	sort.Slice(report.Groups, func(i, j int) bool {
+		a, b := report.Groups[i], report.Groups[j]
+		if (a.Group == unattributedChannel) != (b.Group == unattributedChannel) {
+			return b.Group == unattributedChannel
+		}
+		if a.Revenue[sortBy] != b.Revenue[sortBy] {
+			return a.Revenue[sortBy] > b.Revenue[sortBy]
+		}
+		return a.Group < b.Group
+	})
+	return report, nil
+}
+
+var touchGroupers = map[string]func(enrich.Touch) string{
+	"channel":  func(t enrich.Touch) string { return t.Channel },
+	"source":   func(t enrich.Touch) string { return t.Source },
+	"medium":   func(t enrich.Touch) string { return t.Medium },
+	"campaign": func(t enrich.Touch) string { return orNone(t.Campaign) },
+}
+
+// touchesWithin returns the touches in [from, to]. touches are in time order.
+func touchesWithin(touches []attributionTouch, from, to time.Time) []attributionTouch {
+	lo := sort.Search(len(touches), func(i int) bool { return !touches[i].at.Before(from) })
+	hi := sort.Search(len(touches), func(i int) bool { return touches[i].at.After(to) })
+	if lo >= hi {
+		return nil
+	}
+	return touches[lo:hi]
+}
+
+// attributionWeights splits one conversion across touches; weights sum to 1.
+func attributionWeights(model string, touches []attributionTouch, at time.Time) []float64 {
+	weights := make([]float64, len(touches))
+	switch model {
+	case "first_touch":
+		weights[0] = 1
+	case "last_touch":
+		weights[len(weights)-1] = 1
+	case "linear":
+		for i := range weights {
+			weights[i] = 1 / float64(len(weights))
+		}
+	case "time_decay":
+		total := 0.0
+		for i, t := range touches {
+			weights[i] = math.Exp2(-at.Sub(t.at).Hours() / attributionHalfLife.Hours())
+			total += weights[i]
+		}
+		for i := range weights {
+			weights[i] /= total
+		}
+	}
+	return weights
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func orNone(value string) string {
+	if value == "" {
+		return "(none)"
+	}
+	return value
+}
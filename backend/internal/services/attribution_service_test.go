@@ -0,0 +1,80 @@
+package services
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestAttributionWeights(t *testing.T) {
+	sale := date(2026, time.March, 15)
+	touches := []attributionTouch{
+		{at: sale.Add(-14 * 24 * time.Hour), group: "email"},
+		{at: sale.Add(-7 * 24 * time.Hour), group: "paid_search"},
+		{at: sale, group: "direct"},
+	}
+	tests := []struct {
+		model   string
+		touches []attributionTouch
+		want    []float64
+	}{
+		{"first_touch", touches, []float64{1, 0, 0}},
+		{"last_touch", touches, []float64{0, 0, 1}},
+		{"linear", touches, []float64{1.0 / 3, 1.0 / 3, 1.0 / 3}},
+		{"time_decay", touches, []float64{1.0 / 7, 2.0 / 7, 4.0 / 7}},
+		{"first_touch", touches[1:2], []float64{1}},
+		{"linear", touches[:2], []float64{0.5, 0.5}},
+		{"time_decay", touches[2:], []float64{1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.model, func(t *testing.T) {
+			got := attributionWeights(tt.model, tt.touches, sale)
+			if len(got) != len(tt.want) {
+				t.Fatalf("attributionWeights() = %v, want %v", got, tt.want)
+			}
+			sum := 0.0
+			for i := range got {
+				sum += got[i]
+				if math.Abs(got[i]-tt.want[i]) > 1e-9 {
+					t.Errorf("attributionWeights() = %v, want %v", got, tt.want)
+					break
+				}
+			}
+			if math.Abs(sum-1) > 1e-9 {
+				t.Errorf("weights sum to %v, want 1", sum)
+			}
+		})
+	}
+}
+
+func TestTouchesWithin(t *testing.T) {
+	base := date(2026, time.March, 1)
+	touches := []attributionTouch{
+		{at: base, group: "a"},
+		{at: base.Add(time.Hour), group: "b"},
+		{at: base.Add(2 * time.Hour), group: "c"},
+	}
+	tests := []struct {
+		name     string
+		from, to time.Time
+		want     string
+	}{
+		{"all", base, base.Add(2 * time.Hour), "abc"},
+		{"bounds are inclusive", base.Add(time.Hour), base.Add(time.Hour), "b"},
+		{"window starts between touches", base.Add(time.Minute), base.Add(3 * time.Hour), "bc"},
+		{"window ends between touches", base.Add(-time.Hour), base.Add(time.Minute), "a"},
+		{"window before every touch", base.Add(-2 * time.Hour), base.Add(-time.Hour), ""},
+		{"window after every touch", base.Add(3 * time.Hour), base.Add(4 * time.Hour), ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ""
+			for _, touch := range touchesWithin(touches, tt.from, tt.to) {
+				got += touch.group
+			}
+			if got != tt.want {
+				t.Errorf("touchesWithin() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
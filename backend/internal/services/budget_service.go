@@ -0,0 +1,291 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/KalebAsratemedhi/stock-dashboard/backend/internal/fiscal"
+	"github.com/KalebAsratemedhi/stock-dashboard/backend/internal/models"
+	"github.com/KalebAsratemedhi/stock-dashboard/backend/internal/repository"
+	"gorm.io/gorm"
+)
+
+// BudgetVersions are the versions a budget line may have. Budget-vs-actual
+// uses the revised line where there is one unless asked for the original.
+var BudgetVersions = []string{"original", "revised"}
+
+type BudgetService interface {
+	CreateBudget(budget *models.Budget) error
+	UpdateBudget(budget *models.Budget) error
+	DeleteBudget(id uint) error
+	GetBudgetByID(id uint) (*models.Budget, error)
+	ListBudgets(filter repository.BudgetFilter) ([]*models.Budget, error)
+}
+
+type budgetService struct {
+	repo     repository.BudgetRepository
+	calendar *fiscal.Calendar
+}
+
+// NewBudgetService creates a budget service resolving fiscal period labels
+// with calendar.
+func NewBudgetService(repo repository.BudgetRepository, calendar *fiscal.Calendar) BudgetService {
+	return &budgetService{repo: repo, calendar: calendar}
+}
+
+// CreateBudget adds a budget line. A revised line needs an original line with
+// the same key, and each key has at most one line per version. Lines of a
+// metric type never overlap in both period and scope, so that every actual
+// counts towards at most one line.
+func (s *budgetService) CreateBudget(budget *models.Budget) error {
+	if err := s.validateBudget(budget); err != nil {
+		return err
+	}
+	if err := s.checkVersion(budget); err != nil {
+		return err
+	}
+	return s.repo.Create(budget)
+}
+
+// UpdateBudget changes the amount and notes of a budget line. Its key and
+// version are fixed, since moving or re-versioning a line could leave a
+// revision without its original; an empty Version keeps the stored one.
+func (s *budgetService) UpdateBudget(budget *models.Budget) error {
+	existing, err := s.GetBudgetByID(budget.ID)
+	if err != nil {
+		return err
+	}
+	if budget.Version == "" {
+		budget.Version = existing.Version
+	}
+	if err := s.validateBudget(budget); err != nil {
+		return err
+	}
+	if budget.Department != existing.Department || budget.Category != existing.Category ||
+		budget.MetricType != existing.MetricType || budget.Period != existing.Period {
+		return fmt.Errorf("%w: the department, category, type and period of a budget cannot change", ErrInvalidInput)
+	}
+	if budget.Version != existing.Version {
+		return fmt.Errorf("%w: the version of a budget cannot change", ErrInvalidInput)
+	}
+	budget.CreatedAt = existing.CreatedAt
+	return s.repo.Update(budget)
+}
+
+// DeleteBudget removes a budget line. An original line cannot be removed
+// while it has a revision.
+func (s *budgetService) DeleteBudget(id uint) error {
+	budget, err := s.GetBudgetByID(id)
+	if err != nil {
+		return err
+	}
+	if budget.Version == "original" {
+		revised, err := s.lookup(budget, "revised")
+		if err != nil {
+			return err
+		}
+		if revised != nil {
+			return fmt.Errorf("%w: budget %d has a revision", ErrInvalidInput, id)
+		}
+	}
+	if err := s.repo.Delete(id); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+func (s *budgetService) GetBudgetByID(id uint) (*models.Budget, error) {
+	budget, err := s.repo.GetByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return budget, nil
+}
+
+func (s *budgetService) ListBudgets(filter repository.BudgetFilter) ([]*models.Budget, error) {
+	if filter.Version != "" && !containsString(BudgetVersions, filter.Version) {
+		return nil, ErrInvalidInput
+	}
+	return s.repo.List(filter)
+}
+
+// checkVersion rejects a second line with the same key and version, a
+// revision without an original, and a line overlapping another line's period
+// and scope, such as 2026 and 2026-Q3 lines for the same key, or a line for
+// all departments and one for a single department.
+func (s *budgetService) checkVersion(budget *models.Budget) error {
+	overlapping, err := s.repo.ListOverlapping(budget)
+	if err != nil {
+		return err
+	}
+	if len(overlapping) > 0 {
+		other := overlapping[0]
+		return fmt.Errorf("%w: budget overlaps budget %d (%s %q/%q)", ErrInvalidInput,
+			other.ID, other.Period, other.Department, other.Category)
+	}
+	duplicate, err := s.lookup(budget, budget.Version)
+	if err != nil {
+		return err
+	}
+	if duplicate != nil && duplicate.ID != budget.ID {
+		return fmt.Errorf("%w: budget %d already has this key and version", ErrInvalidInput, duplicate.ID)
+	}
+	if budget.Version == "revised" {
+		original, err := s.lookup(budget, "original")
+		if err != nil {
+			return err
+		}
+		if original == nil {
+			return fmt.Errorf("%w: a revised budget needs an original budget", ErrInvalidInput)
+		}
+	}
+	return nil
+}
+
+// lookup returns the line with the budget's key and the given version, or
+// nil if there is none.
+func (s *budgetService) lookup(budget *models.Budget, version string) (*models.Budget, error) {
+	found, err := s.repo.GetByKey(repository.BudgetFilter{
+		Department: budget.Department,
+		Category:   budget.Category,
+		MetricType: budget.MetricType,
+		Period:     budget.Period,
+		Version:    version,
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	return found, err
+}
+
+// validateBudget checks a budget line and derives its range from Period.
+func (s *budgetService) validateBudget(budget *models.Budget) error {
+	if budget.MetricType == "" || len(budget.MetricType) > 50 {
+		return ErrInvalidInput
+	}
+	if len(budget.Department) > 100 || len(budget.Category) > 100 {
+		return ErrInvalidInput
+	}
+	if budget.Amount < 0 {
+		return ErrInvalidInput
+	}
+	if budget.Version == "" {
+		budget.Version = "original"
+	}
+	if !containsString(BudgetVersions, budget.Version) {
+		return ErrInvalidInput
+	}
+	period, err := ParseFinancialPeriod(s.calendar, budget.Period)
+	if err != nil {
+		return err
+	}
+	budget.PeriodStart, budget.PeriodEnd = period.Start, period.End
+	return nil
+}
+
+// budgetScope is the department, category and metric type a budget line
+// applies to.
+type budgetScope struct {
+	department string
+	category   string
+	metricType string
+}
+
+// proratedBudget returns the share of a line's amount falling inside [start,
+// end], in proportion to the time of its period inside the range.
+func proratedBudget(line repository.BudgetLineRow, start, end time.Time) float64 {
+	length := line.PeriodEnd.Sub(line.PeriodStart)
+	from, to := line.PeriodStart, line.PeriodEnd
+	if start.After(from) {
+		from = start
+	}
+	if end.Before(to) {
+		to = end
+	}
+	if length <= 0 || !to.After(from) {
+		return 0
+	}
+	return line.Amount * float64(to.Sub(from)) / float64(length)
+}
+
+// budgetVsActual pairs the actuals of each scope with the lines of that
+// scope, prorated to [start, end]. Rows keep the order of actuals.
+func budgetVsActual(lines []repository.BudgetLineRow, actuals []repository.BudgetActualRow, start, end time.Time) []repository.BudgetVsActualRow {
+	budgets := make(map[budgetScope]float64)
+	for _, line := range lines {
+		budgets[budgetScope{line.Department, line.Category, line.MetricType}] += proratedBudget(line, start, end)
+	}
+
+	rows := make([]repository.BudgetVsActualRow, 0, len(actuals))
+	for _, actual := range actuals {
+		budget := budgets[budgetScope{actual.Department, actual.Category, actual.MetricType}]
+		row := repository.BudgetVsActualRow{
+			Department: actual.Department,
+			Category:   actual.Category,
+			MetricType: actual.MetricType,
+			Actual:     actual.Actual,
+			Budget:     budget,
+			Variance:   actual.Actual - budget,
+		}
+		if budget != 0 {
+			row.VariancePct = 100 * row.Variance / budget
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// budgetCategoryTotals totals lines by metric type and category, prorated to
+// [start, end]. With a department, only that department's lines count.
+// Without one, a line across all departments is the budget of its metric
+// type and category, and lines of single departments only count where there
+// is no such line. Count is the number of lines.
+func budgetCategoryTotals(lines []repository.BudgetLineRow, start, end time.Time, department string) []repository.CategoryTotalRow {
+	type key struct{ metricType, category string }
+	global := make(map[key]bool)
+	for _, line := range lines {
+		if line.Department == "" {
+			global[key{line.MetricType, line.Category}] = true
+		}
+	}
+
+	totals := make(map[key]*repository.CategoryTotalRow)
+	var keys []key
+	for _, line := range lines {
+		k := key{line.MetricType, line.Category}
+		if department != "" && line.Department != department {
+			continue
+		}
+		if department == "" && line.Department != "" && global[k] {
+			continue
+		}
+		total, ok := totals[k]
+		if !ok {
+			total = &repository.CategoryTotalRow{MetricType: line.MetricType, Category: line.Category}
+			totals[k] = total
+			keys = append(keys, k)
+		}
+		total.Amount += proratedBudget(line, start, end)
+		total.Count++
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].metricType != keys[j].metricType {
+			return keys[i].metricType < keys[j].metricType
+		}
+		return keys[i].category < keys[j].category
+	})
+
+	rows := make([]repository.CategoryTotalRow, 0, len(keys))
+	for _, k := range keys {
+		rows = append(rows, *totals[k])
+	}
+	return rows
+}
@@ -0,0 +1,235 @@
+package services
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/KalebAsratemedhi/stock-dashboard/backend/internal/fiscal"
+	"github.com/KalebAsratemedhi/stock-dashboard/backend/internal/models"
+	"github.com/KalebAsratemedhi/stock-dashboard/backend/internal/repository"
+	"gorm.io/gorm"
+)
+
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+func TestProratedBudget(t *testing.T) {
+	quarter := repository.BudgetLineRow{
+		MetricType:  "expense",
+		PeriodStart: date(2026, time.April, 1),
+		PeriodEnd:   date(2026, time.July, 1),
+		Amount:      9100,
+	}
+	tests := []struct {
+		name       string
+		line       repository.BudgetLineRow
+		start, end time.Time
+		want       float64
+	}{
+		{"whole period", quarter, date(2026, time.April, 1), date(2026, time.July, 1), 9100},
+		{"range wider than period", quarter, date(2026, time.January, 1), date(2027, time.January, 1), 9100},
+		{"one month of 91 days", quarter, date(2026, time.April, 1), date(2026, time.May, 1), 3000},
+		{"overlapping the start", quarter, date(2026, time.March, 1), date(2026, time.April, 11), 1000},
+		{"overlapping the end", quarter, date(2026, time.June, 21), date(2026, time.August, 1), 1000},
+		{"range before period", quarter, date(2026, time.January, 1), date(2026, time.March, 1), 0},
+		{"range after period", quarter, date(2026, time.July, 1), date(2026, time.August, 1), 0},
+		{"empty period", repository.BudgetLineRow{PeriodStart: date(2026, time.April, 1), PeriodEnd: date(2026, time.April, 1), Amount: 100},
+			date(2026, time.January, 1), date(2027, time.January, 1), 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := proratedBudget(tt.line, tt.start, tt.end)
+			if math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("proratedBudget() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBudgetVsActual(t *testing.T) {
+	start, end := date(2026, time.April, 1), date(2026, time.May, 1)
+	lines := []repository.BudgetLineRow{
+		{Department: "Sales", MetricType: "expense", PeriodStart: start, PeriodEnd: end, Amount: 1000},
+		{Department: "Sales", MetricType: "expense", PeriodStart: end, PeriodEnd: date(2026, time.June, 1), Amount: 5000},
+		{MetricType: "revenue", PeriodStart: date(2026, time.January, 1), PeriodEnd: date(2027, time.January, 1), Amount: 36500},
+	}
+	actuals := []repository.BudgetActualRow{
+		{MetricType: "revenue", Actual: 3300},
+		{Department: "Marketing", MetricType: "expense", Actual: 200},
+		{Department: "Sales", MetricType: "expense", Actual: 1100},
+	}
+
+	got := budgetVsActual(lines, actuals, start, end)
+	want := []repository.BudgetVsActualRow{
+		{MetricType: "revenue", Actual: 3300, Budget: 3000, Variance: 300, VariancePct: 10},
+		{Department: "Marketing", MetricType: "expense", Actual: 200, Variance: 200},
+		{Department: "Sales", MetricType: "expense", Actual: 1100, Budget: 1000, Variance: 100, VariancePct: 10},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("budgetVsActual() returned %d rows, want %d", len(got), len(want))
+	}
+	for i := range want {
+		g, w := got[i], want[i]
+		if g.Department != w.Department || g.Category != w.Category || g.MetricType != w.MetricType {
+			t.Errorf("row %d scope = %q/%q/%q, want %q/%q/%q", i,
+				g.Department, g.Category, g.MetricType, w.Department, w.Category, w.MetricType)
+		}
+		for _, v := range []struct {
+			name      string
+			got, want float64
+		}{
+			{"actual", g.Actual, w.Actual},
+			{"budget", g.Budget, w.Budget},
+			{"variance", g.Variance, w.Variance},
+			{"variance_pct", g.VariancePct, w.VariancePct},
+		} {
+			if math.Abs(v.got-v.want) > 1e-9 {
+				t.Errorf("row %d %s = %v, want %v", i, v.name, v.got, v.want)
+			}
+		}
+	}
+}
+
+func TestBudgetCategoryTotals(t *testing.T) {
+	start, end := date(2026, time.April, 1), date(2026, time.May, 1)
+	line := func(department, metricType, category string, amount float64) repository.BudgetLineRow {
+		return repository.BudgetLineRow{
+			Department:  department,
+			Category:    category,
+			MetricType:  metricType,
+			PeriodStart: start,
+			PeriodEnd:   end,
+			Amount:      amount,
+		}
+	}
+	lines := []repository.BudgetLineRow{
+		line("", "revenue", "", 1000),
+		line("Sales", "revenue", "", 400),
+		line("Sales", "expense", "travel", 50),
+		line("Marketing", "expense", "travel", 30),
+		line("Marketing", "expense", "ads", 200),
+	}
+	tests := []struct {
+		name       string
+		department string
+		want       []repository.CategoryTotalRow
+	}{
+		{
+			name: "all departments prefer the line across departments",
+			want: []repository.CategoryTotalRow{
+				{MetricType: "expense", Category: "ads", Amount: 200, Count: 1},
+				{MetricType: "expense", Category: "travel", Amount: 80, Count: 2},
+				{MetricType: "revenue", Category: "", Amount: 1000, Count: 1},
+			},
+		},
+		{
+			name:       "one department",
+			department: "Sales",
+			want: []repository.CategoryTotalRow{
+				{MetricType: "expense", Category: "travel", Amount: 50, Count: 1},
+				{MetricType: "revenue", Category: "", Amount: 400, Count: 1},
+			},
+		},
+		{
+			name:       "department without lines",
+			department: "Support",
+			want:       []repository.CategoryTotalRow{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := budgetCategoryTotals(lines, start, end, tt.department)
+			if len(got) != len(tt.want) {
+				t.Fatalf("budgetCategoryTotals() = %+v, want %+v", got, tt.want)
+			}
+			for i := range tt.want {
+				if got[i].MetricType != tt.want[i].MetricType || got[i].Category != tt.want[i].Category ||
+					got[i].Count != tt.want[i].Count || math.Abs(got[i].Amount-tt.want[i].Amount) > 1e-9 {
+					t.Errorf("row %d = %+v, want %+v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+// fakeBudgetRepository serves budget lines by ID and records updates.
+type fakeBudgetRepository struct {
+	repository.BudgetRepository
+	budgets map[uint]*models.Budget
+	updated *models.Budget
+}
+
+func (r *fakeBudgetRepository) GetByID(id uint) (*models.Budget, error) {
+	budget, ok := r.budgets[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	stored := *budget
+	return &stored, nil
+}
+
+func (r *fakeBudgetRepository) Update(budget *models.Budget) error {
+	r.updated = budget
+	return nil
+}
+
+func TestUpdateBudget(t *testing.T) {
+	created := date(2026, time.January, 5)
+	original := &models.Budget{ID: 1, Department: "Sales", Category: "travel", MetricType: "expense",
+		Period: "2026-Q2", Version: "original", Amount: 1000, CreatedAt: created}
+	revised := &models.Budget{ID: 2, Department: "Sales", Category: "travel", MetricType: "expense",
+		Period: "2026-Q2", Version: "revised", Amount: 1200, CreatedAt: created}
+	update := func(base *models.Budget, change func(*models.Budget)) models.Budget {
+		budget := *base
+		budget.CreatedAt = time.Time{}
+		change(&budget)
+		return budget
+	}
+
+	tests := []struct {
+		name    string
+		budget  models.Budget
+		wantErr error
+	}{
+		{"amount and notes", update(original, func(b *models.Budget) { b.Amount, b.Notes = 1100, "re-forecast" }), nil},
+		{"empty version keeps the revision", update(revised, func(b *models.Budget) { b.Version, b.Amount = "", 1300 }), nil},
+		{"original to revised", update(original, func(b *models.Budget) { b.Version = "revised" }), ErrInvalidInput},
+		{"revised to original", update(revised, func(b *models.Budget) { b.Version = "original" }), ErrInvalidInput},
+		{"period", update(original, func(b *models.Budget) { b.Period = "2026-Q3" }), ErrInvalidInput},
+		{"department", update(original, func(b *models.Budget) { b.Department = "Marketing" }), ErrInvalidInput},
+		{"category", update(revised, func(b *models.Budget) { b.Category = "" }), ErrInvalidInput},
+		{"metric type", update(original, func(b *models.Budget) { b.MetricType = "revenue" }), ErrInvalidInput},
+		{"negative amount", update(original, func(b *models.Budget) { b.Amount = -1 }), ErrInvalidInput},
+		{"unknown budget", update(original, func(b *models.Budget) { b.ID = 9 }), ErrNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeBudgetRepository{budgets: map[uint]*models.Budget{1: original, 2: revised}}
+			budget := tt.budget
+			err := NewBudgetService(repo, fiscal.Default()).UpdateBudget(&budget)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("UpdateBudget() error = %v, want %v", err, tt.wantErr)
+				}
+				if repo.updated != nil {
+					t.Errorf("UpdateBudget() stored %+v", repo.updated)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("UpdateBudget(): %v", err)
+			}
+			if repo.updated == nil || repo.updated.Amount != tt.budget.Amount || repo.updated.Notes != tt.budget.Notes {
+				t.Fatalf("UpdateBudget() stored %+v", repo.updated)
+			}
+			stored := repo.budgets[budget.ID]
+			if repo.updated.Version != stored.Version || !repo.updated.CreatedAt.Equal(created) {
+				t.Errorf("UpdateBudget() stored version %q created %s, want %q created %s",
+					repo.updated.Version, repo.updated.CreatedAt, stored.Version, created)
+			}
+		})
+	}
+}
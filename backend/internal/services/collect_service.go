@@ -0,0 +1,183 @@
+package services
+
+import (
+	"encoding/json"
+	"regexp"
+	"time"
+
+	"github.com/KalebAsratemedhi/stock-dashboard/backend/internal/enrich"
+	"github.com/KalebAsratemedhi/stock-dashboard/backend/internal/models"
+)
+
+const (
+	// MaxCollectBatch is the most events accepted in one request.
+	MaxCollectBatch = 100
+
+	maxMetadataBytes = 4096
+	maxClientDelay   = 24 * time.Hour
+	maxClientSkew    = 5 * time.Minute
+)
+
+var eventTypePattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,49}$`)
+
+// CollectedEvent is an event as sent by a browser or app. Timestamp is only
+// honoured when it is recent, so that queued beacons keep their original
+// time without letting clients backdate data.
+type CollectedEvent struct {
+	EventType string          `json:"event_type"`
+	UserID    string          `json:"user_id"`
+	SessionID string          `json:"session_id"`
+	Page      string          `json:"page"`
+	Referrer  string          `json:"referrer"`
+	Timestamp *time.Time      `json:"timestamp"`
+	UserAgent string          `json:"user_agent"`
+	Metadata  json.RawMessage `json:"metadata"`
+}
+
+// CollectContext carries request details used for server-side enrichment.
+// RemoteIP must come from the connection or a trusted proxy, never from a
+// header the client controls, since bot detection and geolocation trust it.
+type CollectContext struct {
+	RemoteIP   string
+	UserAgent  string
+	ReceivedAt time.Time
+}
+
+// EventPublisher is the part of queue.Publisher used for ingestion.
+type EventPublisher interface {
+	PublishUserEvent(queue string, data interface{}) error
+}
+
+type CollectService interface {
+	Collect(events []*CollectedEvent, ctx CollectContext) (int, error)
+}
+
+type collectService struct {
+	publisher EventPublisher
+	queueName string
+	geo       *enrich.GeoDB
+	bots      *enrich.BotDetector
+}
+
+// NewCollectService creates the service. publisher may be nil when the queue
+// is unreachable, in which case Collect returns ErrUnavailable. Events that
+// bots flags as automated, or whose session sends requests too quickly, are
+// kept but marked with IsBot.
+func NewCollectService(publisher EventPublisher, queueName string, geo *enrich.GeoDB, bots *enrich.BotDetector) CollectService {
+	return &collectService{publisher: publisher, queueName: queueName, geo: geo, bots: bots}
+}
+
+// Collect validates and enriches the events and publishes them for the worker
+// to persist, returning how many were published. The batch is rejected as a
+// whole if any event is invalid, but events are published one at a time, so
+// when publishing fails the events before the failed one are already queued
+// and the count says how many of them a retry should skip.
+func (s *collectService) Collect(events []*CollectedEvent, ctx CollectContext) (int, error) {
+	if s.publisher == nil {
+		return 0, ErrUnavailable
+	}
+	if len(events) == 0 || len(events) > MaxCollectBatch {
+		return 0, ErrInvalidInput
+	}
+	if ctx.ReceivedAt.IsZero() {
+		ctx.ReceivedAt = time.Now()
+	}
+
+	enriched := make([]*models.UserEvent, 0, len(events))
+	for _, in := range events {
+		event, err := s.enrich(in, ctx)
+		if err != nil {
+			return 0, err
+		}
+		enriched = append(enriched, event)
+	}
+
+	// The session rate is measured on arrival, once per session and batch,
+	// since client timestamps can be backdated and a batch of queued beacons
+	// is a single request.
+	flagged := make(map[string]bool)
+	for _, event := range enriched {
+		if event.SessionID == "" {
+			continue
+		}
+		if _, ok := flagged[event.SessionID]; !ok {
+			flagged[event.SessionID] = s.bots.RecordRequest(event.SessionID, ctx.ReceivedAt)
+		}
+		event.IsBot = event.IsBot || flagged[event.SessionID]
+	}
+
+	for i, event := range enriched {
+		if err := s.publisher.PublishUserEvent(s.queueName, event); err != nil {
+			return i, err
+		}
+	}
+	return len(enriched), nil
+}
+
+func (s *collectService) enrich(in *CollectedEvent, ctx CollectContext) (*models.UserEvent, error) {
+	if in == nil || !eventTypePattern.MatchString(in.EventType) {
+		return nil, ErrInvalidInput
+	}
+	if len(in.UserID) > 50 || len(in.SessionID) > 100 || len(in.Page) > 255 || len(in.Referrer) > 500 {
+		return nil, ErrInvalidInput
+	}
+
+	metadata := "{}"
+	if len(in.Metadata) > 0 && string(in.Metadata) != "null" {
+		if len(in.Metadata) > maxMetadataBytes {
+			return nil, ErrInvalidInput
+		}
+		metadata = string(in.Metadata)
+	}
+	if err := validateMetadata(in.EventType, metadata); err != nil {
+		return nil, err
+	}
+
+	timestamp := ctx.ReceivedAt
+	if in.Timestamp != nil {
+		age := ctx.ReceivedAt.Sub(*in.Timestamp)
+		if age <= maxClientDelay && age >= -maxClientSkew {
+			timestamp = *in.Timestamp
+		}
+	}
+
+	userAgent := in.UserAgent
+	if userAgent == "" {
+		userAgent = ctx.UserAgent
+	}
+
+	event := &models.UserEvent{
+		Timestamp: timestamp,
+		EventType: in.EventType,
+		UserID:    in.UserID,
+		SessionID: in.SessionID,
+		Page:      in.Page,
+		Referrer:  in.Referrer,
+		Metadata:  metadata,
+		UserAgent: userAgent,
+		IsBot:     s.bots.IsBot(userAgent, ctx.RemoteIP),
+	}
+	if location, ok := s.geo.Lookup(ctx.RemoteIP); ok {
+		event.Country = location.Country
+		event.City = location.City
+	}
+	return event, nil
+}
+
+// ApplyUserAgent parses the event's raw UserAgent into its device, browser and
+// OS fields and flags the event as a bot when the user agent is a crawler or
+// HTTP library. Events queued without a raw user agent keep the fields they
+// were sent with.
+func ApplyUserAgent(event *models.UserEvent) {
+	if event.UserAgent == "" {
+		return
+	}
+	ua := enrich.ParseUserAgent(event.UserAgent)
+	event.Device = ua.Device
+	event.Browser = ua.Browser
+	event.BrowserVersion = ua.BrowserVersion
+	event.OS = ua.OS
+	event.OSVersion = ua.OSVersion
+	event.IsBot = event.IsBot || ua.IsBot
+	event.UserAgent = ""
+}
@@ -0,0 +1,194 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/KalebAsratemedhi/stock-dashboard/backend/internal/enrich"
+	"github.com/KalebAsratemedhi/stock-dashboard/backend/internal/models"
+)
+
+// fakeEventPublisher records the events it is asked to publish. With failAt
+// set, that publish and every later one fails, counting from 1.
+type fakeEventPublisher struct {
+	events []*models.UserEvent
+	failAt int
+}
+
+func (p *fakeEventPublisher) PublishUserEvent(queue string, data interface{}) error {
+	if p.failAt > 0 && len(p.events)+1 >= p.failAt {
+		return errors.New("channel closed")
+	}
+	p.events = append(p.events, data.(*models.UserEvent))
+	return nil
+}
+
+func TestCollectAccepted(t *testing.T) {
+	received := time.Date(2026, time.March, 1, 12, 0, 0, 0, time.UTC)
+	events := func(types ...string) []*CollectedEvent {
+		collected := make([]*CollectedEvent, len(types))
+		for i, eventType := range types {
+			collected[i] = &CollectedEvent{EventType: eventType, SessionID: "s1"}
+		}
+		return collected
+	}
+	tests := []struct {
+		name      string
+		events    []*CollectedEvent
+		failAt    int
+		accepted  int
+		published int
+		wantErr   bool
+	}{
+		{"whole batch", events("page_view", "click", "page_view"), 0, 3, 3, false},
+		{"invalid event rejects the batch", events("page_view", "Bad Type", "page_view"), 0, 0, 0, true},
+		{"publish fails part way", events("page_view", "click", "page_view"), 3, 2, 2, true},
+		{"publish fails at once", events("page_view", "click"), 1, 0, 0, true},
+		{"empty batch", events(), 0, 0, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			publisher := &fakeEventPublisher{failAt: tt.failAt}
+			accepted, err := NewCollectService(publisher, "user_events", nil, nil).Collect(tt.events, CollectContext{ReceivedAt: received})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Collect() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if accepted != tt.accepted || len(publisher.events) != tt.published {
+				t.Errorf("Collect() accepted %d and published %d, want %d and %d",
+					accepted, len(publisher.events), tt.accepted, tt.published)
+			}
+		})
+	}
+}
+
+func TestCollectSessionRate(t *testing.T) {
+	received := time.Date(2026, time.March, 1, 12, 0, 0, 0, time.UTC)
+	batch := func(n int, timestamp func(i int) time.Time) []*CollectedEvent {
+		events := make([]*CollectedEvent, n)
+		for i := range events {
+			at := timestamp(i)
+			events[i] = &CollectedEvent{EventType: "page_view", SessionID: "s1", Timestamp: &at}
+		}
+		return events
+	}
+	now := func(int) time.Time { return received }
+
+	tests := []struct {
+		name    string
+		batches [][]*CollectedEvent
+		arrival time.Duration
+		want    bool
+	}{
+		{
+			name:    "one full batch",
+			batches: [][]*CollectedEvent{batch(MaxCollectBatch, now)},
+			arrival: time.Second,
+			want:    false,
+		},
+		{
+			name: "requests spread by backdated timestamps",
+			batches: func() [][]*CollectedEvent {
+				batches := make([][]*CollectedEvent, 31)
+				for i := range batches {
+					batches[i] = batch(1, func(int) time.Time { return received.Add(-time.Duration(i) * time.Hour) })
+				}
+				return batches
+			}(),
+			arrival: 100 * time.Millisecond,
+			want:    true,
+		},
+		{
+			name: "requests with out of order timestamps",
+			batches: func() [][]*CollectedEvent {
+				batches := make([][]*CollectedEvent, 31)
+				for i := range batches {
+					batches[i] = batch(2, func(j int) time.Time { return received.Add(time.Duration((i*7+j)%11-10) * time.Minute) })
+				}
+				return batches
+			}(),
+			arrival: 100 * time.Millisecond,
+			want:    true,
+		},
+		{
+			name: "requests at a human pace",
+			batches: func() [][]*CollectedEvent {
+				batches := make([][]*CollectedEvent, 31)
+				for i := range batches {
+					batches[i] = batch(5, now)
+				}
+				return batches
+			}(),
+			arrival: time.Second,
+			want:    false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bots, err := enrich.LoadBotDetector(strings.NewReader(""))
+			if err != nil {
+				t.Fatal(err)
+			}
+			publisher := &fakeEventPublisher{}
+			s := NewCollectService(publisher, "user_events", nil, bots)
+			for i, events := range tt.batches {
+				ctx := CollectContext{RemoteIP: "198.51.100.7", ReceivedAt: received.Add(time.Duration(i) * tt.arrival)}
+				if _, err := s.Collect(events, ctx); err != nil {
+					t.Fatalf("Collect(): %v", err)
+				}
+			}
+			last := len(tt.batches[len(tt.batches)-1])
+			for _, event := range publisher.events[len(publisher.events)-last:] {
+				if event.IsBot != tt.want {
+					t.Fatalf("IsBot = %v, want %v", event.IsBot, tt.want)
+				}
+			}
+			for _, event := range publisher.events[:len(publisher.events)-last] {
+				if event.IsBot {
+					t.Fatalf("an earlier event was flagged")
+				}
+			}
+		})
+	}
+}
+
+func TestApplyUserAgent(t *testing.T) {
+	tests := []struct {
+		name  string
+		event models.UserEvent
+		want  models.UserEvent
+	}{
+		{
+			name: "parses the raw user agent",
+			event: models.UserEvent{
+				UserAgent: "Mozilla/5.0 (iPhone; CPU iPhone OS 17_2 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.2 Mobile/15E148 Safari/604.1",
+			},
+			want: models.UserEvent{Device: "mobile", Browser: "Safari", BrowserVersion: "17", OS: "iOS", OSVersion: "17.2"},
+		},
+		{
+			name:  "flags bot user agents",
+			event: models.UserEvent{UserAgent: "python-requests/2.31.0"},
+			want:  models.UserEvent{Device: "desktop", Browser: "other", OS: "other", IsBot: true},
+		},
+		{
+			name:  "keeps a bot flag set upstream",
+			event: models.UserEvent{UserAgent: "Mozilla/5.0 (X11; Linux x86_64; rv:121.0) Gecko/20100101 Firefox/121.0", IsBot: true},
+			want:  models.UserEvent{Device: "desktop", Browser: "Firefox", BrowserVersion: "121", OS: "Linux", IsBot: true},
+		},
+		{
+			name:  "keeps fields sent without a raw user agent",
+			event: models.UserEvent{Device: "tv", Browser: "Custom"},
+			want:  models.UserEvent{Device: "tv", Browser: "Custom"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			event := tt.event
+			ApplyUserAgent(&event)
+			if event != tt.want {
+				t.Errorf("ApplyUserAgent() = %+v, want %+v", event, tt.want)
+			}
+		})
+	}
+}
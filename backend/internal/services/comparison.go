@@ -0,0 +1,289 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CompareMode selects the period a range is compared against.
+type CompareMode string
+
+const (
+	ComparePreviousPeriod CompareMode = "previous_period"
+	ComparePreviousYear   CompareMode = "previous_year"
+)
+
+// ParseCompareMode validates a compare query value. An empty value yields an
+// empty mode, meaning no comparison was requested.
+func ParseCompareMode(value string) (CompareMode, error) {
+	switch CompareMode(value) {
+	case "", ComparePreviousPeriod, ComparePreviousYear:
+		return CompareMode(value), nil
+	}
+	return "", ErrInvalidInput
+}
+
+// TimeRange is an inclusive start/end pair.
+type TimeRange struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+}
+
+// ComparisonRange returns the range that [start, end] is compared against.
+//
+// Ranges covering whole calendar months are shifted by calendar months so that
+// e.g. March is compared with February rather than with the 31 days before it.
+// Other ranges are shifted by their own length for previous_period, and by 52
+// weeks for previous_year so that weekdays line up. Ends are inclusive, so a
+// comparison range never ends at the start of the current one; it ends a
+// microsecond, the database's resolution, earlier.
+func ComparisonRange(start, end time.Time, mode CompareMode) TimeRange {
+	if months, boundary, ok := calendarMonths(start, end); ok {
+		offset := boundary.Sub(end)
+		if offset == 0 {
+			offset = time.Microsecond
+		}
+		shift := -months
+		if mode == ComparePreviousYear {
+			shift = -12
+		}
+		return TimeRange{
+			Start: start.AddDate(0, shift, 0),
+			End:   boundary.AddDate(0, shift, 0).Add(-offset),
+		}
+	}
+
+	if mode == ComparePreviousYear {
+		return TimeRange{
+			Start: start.AddDate(0, 0, -364),
+			End:   end.AddDate(0, 0, -364),
+		}
+	}
+
+	length := end.Sub(start)
+	prevEnd := start.Add(-time.Microsecond)
+	return TimeRange{Start: prevEnd.Add(-length), End: prevEnd}
+}
+
+// calendarMonths reports whether [start, end] spans whole calendar months. The
+// end may either be the next month boundary or fall just short of it, as with
+// 23:59:59 style inclusive ends. It returns the month count and the boundary.
+func calendarMonths(start, end time.Time) (int, time.Time, bool) {
+	if !isMonthStart(start) {
+		return 0, time.Time{}, false
+	}
+	for _, eps := range []time.Duration{0, time.Nanosecond, time.Microsecond, time.Millisecond, time.Second} {
+		boundary := end.Add(eps)
+		if !isMonthStart(boundary) {
+			continue
+		}
+		months := (boundary.Year()-start.Year())*12 + int(boundary.Month()) - int(start.Month())
+		if months > 0 {
+			return months, boundary, true
+		}
+	}
+	return 0, time.Time{}, false
+}
+
+func isMonthStart(t time.Time) bool {
+	return t.Day() == 1 && t.Hour() == 0 && t.Minute() == 0 && t.Second() == 0 && t.Nanosecond() == 0
+}
+
+// ValueComparison is a single value measured in two periods.
+type ValueComparison struct {
+	Current    float64  `json:"current"`
+	Comparison float64  `json:"comparison"`
+	Delta      float64  `json:"delta"`
+	DeltaPct   *float64 `json:"delta_pct"`
+}
+
+// NewValueComparison computes the absolute and percentage delta. The
+// percentage is nil when the comparison value is zero.
+func NewValueComparison(current, comparison float64) ValueComparison {
+	vc := ValueComparison{
+		Current:    current,
+		Comparison: comparison,
+		Delta:      current - comparison,
+	}
+	if comparison != 0 {
+		pct := (vc.Delta / comparison) * 100
+		vc.DeltaPct = &pct
+	}
+	return vc
+}
+
+// GroupValue is one aggregated value keyed by its group-by dimensions.
+type GroupValue struct {
+	Key   map[string]string
+	Value float64
+}
+
+// GroupComparison is a grouped value measured in two periods.
+type GroupComparison struct {
+	Key map[string]string `json:"key"`
+	ValueComparison
+}
+
+// PeriodComparison is the response for a compared aggregate. Total is
+// replaced by Totals when groups are not all in the same unit.
+type PeriodComparison struct {
+	Mode            CompareMode       `json:"compare"`
+	CurrentRange    TimeRange         `json:"current_range"`
+	ComparisonRange TimeRange         `json:"comparison_range"`
+	Total           *ValueComparison  `json:"total,omitempty"`
+	Totals          []GroupComparison `json:"totals,omitempty"`
+	Groups          []GroupComparison `json:"groups,omitempty"`
+}
+
+// ComparePeriods runs fetch over the current and comparison ranges and pairs
+// up the groups. Groups present in only one period compare against zero.
+// Groups are ordered by current value, largest first.
+func ComparePeriods(start, end time.Time, mode CompareMode, fetch func(start, end time.Time) ([]GroupValue, error)) (*PeriodComparison, error) {
+	if mode == "" {
+		return nil, ErrInvalidInput
+	}
+	prevRange := ComparisonRange(start, end, mode)
+
+	current, err := fetch(start, end)
+	if err != nil {
+		return nil, err
+	}
+	previous, err := fetch(prevRange.Start, prevRange.End)
+	if err != nil {
+		return nil, err
+	}
+
+	type pair struct {
+		key       map[string]string
+		cur, prev float64
+	}
+	pairs := make(map[string]*pair)
+	var order []string
+	lookup := func(key map[string]string) *pair {
+		id := groupID(key)
+		p, ok := pairs[id]
+		if !ok {
+			p = &pair{key: key}
+			pairs[id] = p
+			order = append(order, id)
+		}
+		return p
+	}
+	var totalCur, totalPrev float64
+	for _, g := range current {
+		lookup(g.Key).cur += g.Value
+		totalCur += g.Value
+	}
+	for _, g := range previous {
+		lookup(g.Key).prev += g.Value
+		totalPrev += g.Value
+	}
+
+	sort.SliceStable(order, func(i, j int) bool {
+		a, b := pairs[order[i]], pairs[order[j]]
+		if a.cur != b.cur {
+			return a.cur > b.cur
+		}
+		return order[i] < order[j]
+	})
+
+	total := NewValueComparison(totalCur, totalPrev)
+	result := &PeriodComparison{
+		Mode:            mode,
+		CurrentRange:    TimeRange{Start: start, End: end},
+		ComparisonRange: prevRange,
+		Total:           &total,
+	}
+	for _, id := range order {
+		p := pairs[id]
+		if len(p.key) == 0 {
+			continue
+		}
+		result.Groups = append(result.Groups, GroupComparison{
+			Key:             p.key,
+			ValueComparison: NewValueComparison(p.cur, p.prev),
+		})
+	}
+	return result, nil
+}
+
+// totalBy replaces the grand total with one total per value of dimension,
+// ordered by that value, for groups whose values cannot be added together.
+func (c *PeriodComparison) totalBy(dimension string) {
+	sums := make(map[string]*ValueComparison)
+	var values []string
+	for _, g := range c.Groups {
+		value := g.Key[dimension]
+		sum, ok := sums[value]
+		if !ok {
+			sum = &ValueComparison{}
+			sums[value] = sum
+			values = append(values, value)
+		}
+		sum.Current += g.Current
+		sum.Comparison += g.Comparison
+	}
+	sort.Strings(values)
+
+	c.Total = nil
+	c.Totals = make([]GroupComparison, 0, len(values))
+	for _, value := range values {
+		c.Totals = append(c.Totals, GroupComparison{
+			Key:             map[string]string{dimension: value},
+			ValueComparison: NewValueComparison(sums[value].Current, sums[value].Comparison),
+		})
+	}
+}
+
+func groupID(key map[string]string) string {
+	names := make([]string, 0, len(key))
+	for name := range key {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var b strings.Builder
+	for _, name := range names {
+		fmt.Fprintf(&b, "%s=%s;", name, key[name])
+	}
+	return b.String()
+}
+
+// numericValue converts a value scanned into a map by the database driver
+// into a float64. Aggregates over DECIMAL columns arrive as strings.
+func numericValue(v interface{}) float64 {
+	switch n := v.(type) {
+	case float64:
+		return n
+	case float32:
+		return float64(n)
+	case int64:
+		return float64(n)
+	case int32:
+		return float64(n)
+	case int:
+		return float64(n)
+	case string:
+		f, _ := strconv.ParseFloat(n, 64)
+		return f
+	case []byte:
+		f, _ := strconv.ParseFloat(string(n), 64)
+		return f
+	}
+	return 0
+}
+
+// stringValue converts a value scanned into a map into a string.
+func stringValue(v interface{}) string {
+	switch s := v.(type) {
+	case string:
+		return s
+	case []byte:
+		return string(s)
+	case nil:
+		return ""
+	}
+	return fmt.Sprint(v)
+}
@@ -0,0 +1,347 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/KalebAsratemedhi/stock-dashboard/backend/internal/models"
+	"github.com/KalebAsratemedhi/stock-dashboard/backend/internal/repository"
+	"gorm.io/gorm"
+)
+
+// CostCenterLevels are the levels of the cost center hierarchy, top first.
+// A parent is always on a higher level than its children, and only
+// companies sit at the root.
+var CostCenterLevels = []string{"company", "division", "department", "team"}
+
+// CostCenterTotal totals one metric type for a cost center and its
+// descendants. Own is the part booked to the cost center itself.
+type CostCenterTotal struct {
+	MetricType string  `json:"metric_type"`
+	Amount     float64 `json:"amount"`
+	Own        float64 `json:"own"`
+	Count      int64   `json:"count"`
+}
+
+// CostCenterNode is a cost center placed in the tree as of a date, with its
+// totals when part of a rollup.
+type CostCenterNode struct {
+	models.CostCenter
+	ParentID *uint             `json:"parent_id"`
+	Totals   []CostCenterTotal `json:"totals,omitempty"`
+	Children []*CostCenterNode `json:"children"`
+}
+
+// CostCenterDetail is a cost center with its parent link history.
+type CostCenterDetail struct {
+	*models.CostCenter
+	Links []*models.CostCenterLink `json:"links"`
+}
+
+// CostCenterRollupQuery selects the metrics of a rollup. AsOf picks the tree
+// and defaults to End. Unless Restate is set, metrics roll up along the
+// links in effect when they were booked, so a reorganization does not move
+// history; with Restate the whole range rolls up along the AsOf tree.
+type CostCenterRollupQuery struct {
+	Start      time.Time
+	End        time.Time
+	AsOf       time.Time
+	Restate    bool
+	MetricType string
+}
+
+// CostCenterRollup is the cost center tree as of a date with financial
+// totals rolled up to every node. Unassigned totals the metrics of
+// departments without a cost center.
+type CostCenterRollup struct {
+	Range      TimeRange         `json:"range"`
+	AsOf       time.Time         `json:"as_of"`
+	Restated   bool              `json:"restated"`
+	Nodes      []*CostCenterNode `json:"nodes"`
+	Unassigned []CostCenterTotal `json:"unassigned"`
+}
+
+type CostCenterService interface {
+	CreateCostCenter(center *models.CostCenter, parentID *uint, effectiveFrom *time.Time) error
+	UpdateCostCenter(center *models.CostCenter) error
+	DeleteCostCenter(id uint) error
+	GetCostCenter(id uint) (*CostCenterDetail, error)
+	GetTree(asOf time.Time) ([]*CostCenterNode, error)
+	MoveCostCenter(id uint, parentID uint, effectiveFrom time.Time) (*models.CostCenterLink, error)
+	GetRollup(query CostCenterRollupQuery) (*CostCenterRollup, error)
+}
+
+type costCenterService struct {
+	repo repository.CostCenterRepository
+}
+
+func NewCostCenterService(repo repository.CostCenterRepository) CostCenterService {
+	return &costCenterService{repo: repo}
+}
+
+// CreateCostCenter adds a cost center under parentID, or at the root for a
+// company, from effectiveFrom on. A nil effectiveFrom places it there since
+// the beginning, which needs a parent that has always existed.
+func (s *costCenterService) CreateCostCenter(center *models.CostCenter, parentID *uint, effectiveFrom *time.Time) error {
+	if err := s.validateCostCenter(center); err != nil {
+		return err
+	}
+	if (center.Level == "company") != (parentID == nil) {
+		return fmt.Errorf("%w: only companies have no parent", ErrInvalidInput)
+	}
+	if parentID != nil {
+		if err := s.checkParent(center, *parentID, effectiveFrom); err != nil {
+			return err
+		}
+	}
+	return s.repo.Create(center, &models.CostCenterLink{ParentID: parentID, EffectiveFrom: effectiveFrom})
+}
+
+// UpdateCostCenter changes the code, name or department of a cost center.
+// The level is fixed, since links depend on it.
+func (s *costCenterService) UpdateCostCenter(center *models.CostCenter) error {
+	existing, err := s.getCostCenter(center.ID)
+	if err != nil {
+		return err
+	}
+	if center.Level == "" {
+		center.Level = existing.Level
+	}
+	if center.Level != existing.Level {
+		return fmt.Errorf("%w: the level of a cost center cannot change", ErrInvalidInput)
+	}
+	if err := s.validateCostCenter(center); err != nil {
+		return err
+	}
+	center.CreatedAt = existing.CreatedAt
+	return s.repo.Update(center)
+}
+
+// DeleteCostCenter removes a cost center that has never had children.
+func (s *costCenterService) DeleteCostCenter(id uint) error {
+	hasChildren, err := s.repo.HasChildren(id)
+	if err != nil {
+		return err
+	}
+	if hasChildren {
+		return fmt.Errorf("%w: cost center %d has or had children", ErrInvalidInput, id)
+	}
+	err = s.repo.Delete(id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrNotFound
+	}
+	return err
+}
+
+func (s *costCenterService) GetCostCenter(id uint) (*CostCenterDetail, error) {
+	center, err := s.getCostCenter(id)
+	if err != nil {
+		return nil, err
+	}
+	links, err := s.repo.GetLinks(id)
+	if err != nil {
+		return nil, err
+	}
+	return &CostCenterDetail{CostCenter: center, Links: links}, nil
+}
+
+// GetTree returns the root cost centers in effect at asOf with their
+// descendants, each level ordered by code.
+func (s *costCenterService) GetTree(asOf time.Time) ([]*CostCenterNode, error) {
+	_, roots, err := s.tree(asOf)
+	return roots, err
+}
+
+// MoveCostCenter reparents a cost center from effectiveFrom on. Its open link
+// is closed there, so metrics booked before then keep rolling up to the old
+// parent. Moves must come after the start of the open link.
+func (s *costCenterService) MoveCostCenter(id uint, parentID uint, effectiveFrom time.Time) (*models.CostCenterLink, error) {
+	center, err := s.getCostCenter(id)
+	if err != nil {
+		return nil, err
+	}
+	if effectiveFrom.IsZero() {
+		return nil, fmt.Errorf("%w: effective_from is required", ErrInvalidInput)
+	}
+	if center.Level == "company" {
+		return nil, fmt.Errorf("%w: companies have no parent", ErrInvalidInput)
+	}
+	links, err := s.repo.GetLinks(id)
+	if err != nil {
+		return nil, err
+	}
+	var open *models.CostCenterLink
+	for _, link := range links {
+		if link.EffectiveTo == nil {
+			open = link
+		}
+	}
+	if open == nil {
+		return nil, fmt.Errorf("%w: cost center %d has no open link", ErrInvalidInput, id)
+	}
+	if open.EffectiveFrom != nil && !effectiveFrom.After(*open.EffectiveFrom) {
+		return nil, fmt.Errorf("%w: effective_from must be after %s", ErrInvalidInput, open.EffectiveFrom.Format(time.RFC3339))
+	}
+	if open.ParentID != nil && *open.ParentID == parentID {
+		return nil, fmt.Errorf("%w: cost center %d is already under %d", ErrInvalidInput, id, parentID)
+	}
+	if err := s.checkParent(center, parentID, &effectiveFrom); err != nil {
+		return nil, err
+	}
+
+	link := &models.CostCenterLink{CostCenterID: id, ParentID: &parentID, EffectiveFrom: &effectiveFrom}
+	if err := s.repo.Move(link); err != nil {
+		return nil, err
+	}
+	return link, nil
+}
+
+// GetRollup totals financial metrics up the cost center tree as of
+// query.AsOf. Node totals include their descendants' metrics.
+func (s *costCenterService) GetRollup(query CostCenterRollupQuery) (*CostCenterRollup, error) {
+	if query.Start.IsZero() || query.End.Before(query.Start) {
+		return nil, ErrInvalidInput
+	}
+	if query.AsOf.IsZero() {
+		query.AsOf = query.End
+	}
+	nodes, roots, err := s.tree(query.AsOf)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := s.repo.GetRollup(query.Start, query.End, query.AsOf, query.Restate, query.MetricType)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &CostCenterRollup{
+		Range:      TimeRange{Start: query.Start, End: query.End},
+		AsOf:       query.AsOf,
+		Restated:   query.Restate,
+		Nodes:      roots,
+		Unassigned: []CostCenterTotal{},
+	}
+	for _, row := range rows {
+		total := CostCenterTotal{MetricType: row.MetricType, Amount: row.Amount, Own: row.Own, Count: row.Count}
+		if row.CostCenterID == 0 {
+			result.Unassigned = append(result.Unassigned, total)
+			continue
+		}
+		// Cost centers outside the as-of tree still roll up into their
+		// ancestors but are not shown themselves.
+		if node, ok := nodes[row.CostCenterID]; ok {
+			node.Totals = append(node.Totals, total)
+		}
+	}
+	return result, nil
+}
+
+// tree builds the cost center tree in effect at asOf, returning every node
+// by ID and the roots.
+func (s *costCenterService) tree(asOf time.Time) (map[uint]*CostCenterNode, []*CostCenterNode, error) {
+	centers, err := s.repo.List()
+	if err != nil {
+		return nil, nil, err
+	}
+	links, err := s.repo.ListLinks()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	nodes := make(map[uint]*CostCenterNode)
+	for _, link := range links {
+		if link.Covers(asOf) {
+			nodes[link.CostCenterID] = &CostCenterNode{ParentID: link.ParentID, Children: []*CostCenterNode{}}
+		}
+	}
+	roots := []*CostCenterNode{}
+	// Centers are ordered by code, so children end up ordered too.
+	for _, center := range centers {
+		node, ok := nodes[center.ID]
+		if !ok {
+			continue
+		}
+		node.CostCenter = *center
+		if node.ParentID == nil {
+			roots = append(roots, node)
+			continue
+		}
+		if parent, ok := nodes[*node.ParentID]; ok {
+			parent.Children = append(parent.Children, node)
+		}
+	}
+	return nodes, roots, nil
+}
+
+// validateCostCenter checks the fields of a cost center and that its code
+// and department are not taken by another one.
+func (s *costCenterService) validateCostCenter(center *models.CostCenter) error {
+	if center.Code == "" || len(center.Code) > 50 {
+		return ErrInvalidInput
+	}
+	if center.Name == "" || len(center.Name) > 100 || len(center.Department) > 100 {
+		return ErrInvalidInput
+	}
+	if !containsString(CostCenterLevels, center.Level) {
+		return fmt.Errorf("%w: level must be one of %v", ErrInvalidInput, CostCenterLevels)
+	}
+	centers, err := s.repo.List()
+	if err != nil {
+		return err
+	}
+	for _, other := range centers {
+		if other.ID == center.ID {
+			continue
+		}
+		if other.Code == center.Code {
+			return fmt.Errorf("%w: code %q is taken", ErrInvalidInput, center.Code)
+		}
+		if center.Department != "" && other.Department == center.Department {
+			return fmt.Errorf("%w: department %q belongs to cost center %s", ErrInvalidInput, center.Department, other.Code)
+		}
+	}
+	return nil
+}
+
+// checkParent checks that parentID is on a higher level than center and in
+// the tree at effectiveFrom, or since the beginning when that is nil.
+func (s *costCenterService) checkParent(center *models.CostCenter, parentID uint, effectiveFrom *time.Time) error {
+	parent, err := s.repo.GetByID(parentID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return fmt.Errorf("%w: parent %d does not exist", ErrInvalidInput, parentID)
+	}
+	if err != nil {
+		return err
+	}
+	if levelRank(parent.Level) >= levelRank(center.Level) {
+		return fmt.Errorf("%w: a %s cannot be under a %s", ErrInvalidInput, center.Level, parent.Level)
+	}
+	links, err := s.repo.GetLinks(parentID)
+	if err != nil {
+		return err
+	}
+	for _, link := range links {
+		if (effectiveFrom == nil && link.EffectiveFrom == nil) || (effectiveFrom != nil && link.Covers(*effectiveFrom)) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: parent %d is not in the tree at effective_from", ErrInvalidInput, parentID)
+}
+
+func (s *costCenterService) getCostCenter(id uint) (*models.CostCenter, error) {
+	center, err := s.repo.GetByID(id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrNotFound
+	}
+	return center, err
+}
+
+func levelRank(level string) int {
+	for i, l := range CostCenterLevels {
+		if l == level {
+			return i
+		}
+	}
+	return len(CostCenterLevels)
+}
@@ -0,0 +1,188 @@
+package services
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/KalebAsratemedhi/stock-dashboard/backend/internal/models"
+	"github.com/KalebAsratemedhi/stock-dashboard/backend/internal/repository"
+	"gorm.io/gorm"
+)
+
+// fakeCostCenterRepository keeps cost centers and links in memory. Move
+// closes the open link and adds the new one, as the repository does in one
+// transaction.
+type fakeCostCenterRepository struct {
+	repository.CostCenterRepository
+	centers []*models.CostCenter
+	links   []*models.CostCenterLink
+}
+
+func (r *fakeCostCenterRepository) GetByID(id uint) (*models.CostCenter, error) {
+	for _, center := range r.centers {
+		if center.ID == id {
+			return center, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *fakeCostCenterRepository) List() ([]*models.CostCenter, error) {
+	centers := append([]*models.CostCenter(nil), r.centers...)
+	sort.Slice(centers, func(i, j int) bool { return centers[i].Code < centers[j].Code })
+	return centers, nil
+}
+
+func (r *fakeCostCenterRepository) GetLinks(id uint) ([]*models.CostCenterLink, error) {
+	var links []*models.CostCenterLink
+	for _, link := range r.links {
+		if link.CostCenterID == id {
+			links = append(links, link)
+		}
+	}
+	return links, nil
+}
+
+func (r *fakeCostCenterRepository) ListLinks() ([]*models.CostCenterLink, error) {
+	return r.links, nil
+}
+
+func (r *fakeCostCenterRepository) Move(link *models.CostCenterLink) error {
+	for _, open := range r.links {
+		if open.CostCenterID == link.CostCenterID && open.EffectiveTo == nil {
+			open.EffectiveTo = link.EffectiveFrom
+		}
+	}
+	r.links = append(r.links, link)
+	return nil
+}
+
+// costCenterFixture is ACME with the EMEA division, its Sales department and
+// Sales' team since the beginning, and the AMER division from April 2026.
+func costCenterFixture() *fakeCostCenterRepository {
+	id := func(v uint) *uint { return &v }
+	april := date(2026, time.April, 1)
+	return &fakeCostCenterRepository{
+		centers: []*models.CostCenter{
+			{ID: 1, Code: "ACME", Level: "company"},
+			{ID: 2, Code: "EMEA", Level: "division"},
+			{ID: 3, Code: "AMER", Level: "division"},
+			{ID: 4, Code: "SALES", Level: "department"},
+			{ID: 5, Code: "TEAM-A", Level: "team"},
+		},
+		links: []*models.CostCenterLink{
+			{CostCenterID: 1},
+			{CostCenterID: 2, ParentID: id(1)},
+			{CostCenterID: 3, ParentID: id(1), EffectiveFrom: &april},
+			{CostCenterID: 4, ParentID: id(2)},
+			{CostCenterID: 5, ParentID: id(4)},
+		},
+	}
+}
+
+// formatTree renders nodes as CODE(CHILD,...) in order.
+func formatTree(nodes []*CostCenterNode) string {
+	parts := make([]string, len(nodes))
+	for i, node := range nodes {
+		parts[i] = node.Code
+		if len(node.Children) > 0 {
+			parts[i] += "(" + formatTree(node.Children) + ")"
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+func TestGetCostCenterTree(t *testing.T) {
+	tests := []struct {
+		name string
+		asOf time.Time
+		want string
+	}{
+		{"before a division is added", date(2026, time.March, 31), "ACME(EMEA(SALES(TEAM-A)))"},
+		{"when it is added", date(2026, time.April, 1), "ACME(AMER,EMEA(SALES(TEAM-A)))"},
+		{"long before", date(2000, time.January, 1), "ACME(EMEA(SALES(TEAM-A)))"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			roots, err := NewCostCenterService(costCenterFixture()).GetTree(tt.asOf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := formatTree(roots); got != tt.want {
+				t.Errorf("GetTree(%s) = %s, want %s", tt.asOf.Format(time.DateOnly), got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMoveCostCenter(t *testing.T) {
+	cutover := date(2026, time.July, 1)
+	tests := []struct {
+		name          string
+		id, parentID  uint
+		effectiveFrom time.Time
+		wantErr       error
+		before, after string
+	}{
+		{"department to another division", 4, 3, cutover, nil,
+			"ACME(AMER,EMEA(SALES(TEAM-A)))", "ACME(AMER(SALES(TEAM-A)),EMEA)"},
+		{"into its own descendant", 2, 4, cutover, ErrInvalidInput, "", ""},
+		{"under a cost center of its level", 2, 3, cutover, ErrInvalidInput, "", ""},
+		{"under its own child", 4, 5, cutover, ErrInvalidInput, "", ""},
+		{"under a parent not yet in the tree", 4, 3, date(2026, time.March, 1), ErrInvalidInput, "", ""},
+		{"to its current parent", 4, 2, cutover, ErrInvalidInput, "", ""},
+		{"a company", 1, 2, cutover, ErrInvalidInput, "", ""},
+		{"without an effective date", 4, 3, time.Time{}, ErrInvalidInput, "", ""},
+		{"to an unknown parent", 4, 9, cutover, ErrInvalidInput, "", ""},
+		{"an unknown cost center", 9, 3, cutover, ErrNotFound, "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := costCenterFixture()
+			s := NewCostCenterService(repo)
+			link, err := s.MoveCostCenter(tt.id, tt.parentID, tt.effectiveFrom)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("MoveCostCenter() error = %v, want %v", err, tt.wantErr)
+				}
+				if len(repo.links) != 5 {
+					t.Errorf("MoveCostCenter() stored %d links, want 5", len(repo.links))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("MoveCostCenter(): %v", err)
+			}
+			if *link.ParentID != tt.parentID || !link.EffectiveFrom.Equal(tt.effectiveFrom) || link.EffectiveTo != nil {
+				t.Errorf("MoveCostCenter() = %+v", link)
+			}
+
+			links, _ := repo.GetLinks(tt.id)
+			if len(links) != 2 || links[0].EffectiveTo == nil || !links[0].EffectiveTo.Equal(tt.effectiveFrom) {
+				t.Fatalf("old link was not closed at the cutover: %+v", links[0])
+			}
+			for _, check := range []struct {
+				asOf time.Time
+				want string
+			}{
+				{tt.effectiveFrom.Add(-time.Nanosecond), tt.before},
+				{tt.effectiveFrom, tt.after},
+			} {
+				roots, err := s.GetTree(check.asOf)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if got := formatTree(roots); got != check.want {
+					t.Errorf("GetTree(%s) = %s, want %s", check.asOf, got, check.want)
+				}
+			}
+
+			if _, err := s.MoveCostCenter(tt.id, 2, tt.effectiveFrom); !errors.Is(err, ErrInvalidInput) {
+				t.Errorf("moving again at the same date: error = %v, want ErrInvalidInput", err)
+			}
+		})
+	}
+}
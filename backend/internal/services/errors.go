@@ -6,4 +6,7 @@ var (
 	ErrInvalidInput = errors.New("invalid input")
 	ErrNotFound     = errors.New("not found")
 	ErrInternal     = errors.New("internal error")
+	ErrUnavailable  = errors.New("service unavailable")
+	// ErrPeriodClosed rejects writes into a closed financial period.
+	ErrPeriodClosed = errors.New("period closed")
 )
\ No newline at end of file
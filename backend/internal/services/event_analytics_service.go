@@ -0,0 +1,445 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/KalebAsratemedhi/stock-dashboard/backend/internal/repository"
+)
+
+const maxFunnelSteps = 20
+
+// FunnelRequest describes an ordered funnel. Actors enter on the first step
+// within [Start, End] and must complete every later step, in order, within
+// Window of entering.
+type FunnelRequest struct {
+	Start     time.Time                `json:"start"`
+	End       time.Time                `json:"end"`
+	Steps     []repository.EventFilter `json:"steps"`
+	Window    time.Duration            `json:"-"`
+	Identity  string                   `json:"identity"`
+	Breakdown string                   `json:"breakdown"`
+}
+
+// FunnelStep is the outcome of one funnel step.
+type FunnelStep struct {
+	Index                int                    `json:"index"`
+	Filter               repository.EventFilter `json:"filter"`
+	Count                int64                  `json:"count"`
+	ConversionRate       float64                `json:"conversion_rate"`
+	StepConversionRate   float64                `json:"step_conversion_rate"`
+	DropOff              int64                  `json:"drop_off"`
+	DropOffRate          float64                `json:"drop_off_rate"`
+	MedianSecondsToReach *float64               `json:"median_seconds_from_previous"`
+}
+
+// FunnelSegment is the funnel for one breakdown value.
+type FunnelSegment struct {
+	Segment string        `json:"segment"`
+	Steps   []*FunnelStep `json:"steps"`
+}
+
+// FunnelResult is the response of GetFunnel.
+type FunnelResult struct {
+	Range         TimeRange        `json:"range"`
+	Identity      string           `json:"identity"`
+	WindowSeconds float64          `json:"window_seconds"`
+	Steps         []*FunnelStep    `json:"steps"`
+	Breakdown     string           `json:"breakdown,omitempty"`
+	Segments      []*FunnelSegment `json:"segments,omitempty"`
+}
+
+type EventAnalyticsService interface {
+	IncludeBots() EventAnalyticsService
+	GetFunnel(req *FunnelRequest) (*FunnelResult, error)
+	GetPaths(start, end time.Time, page, direction string, steps, linksPerStep int) (*PathReport, error)
+	GetRetention(start, end time.Time, period, cohortEvent, returnEvent string, periods int) (*RetentionReport, error)
+}
+
+type eventAnalyticsService struct {
+	repo repository.UserEventRepository
+}
+
+func NewEventAnalyticsService(repo repository.UserEventRepository) EventAnalyticsService {
+	return &eventAnalyticsService{repo: repo}
+}
+
+// IncludeBots returns a service whose queries also count bot traffic.
+func (s *eventAnalyticsService) IncludeBots() EventAnalyticsService {
+	return &eventAnalyticsService{repo: s.repo.IncludeBots()}
+}
+
+func (s *eventAnalyticsService) GetFunnel(req *FunnelRequest) (*FunnelResult, error) {
+	if len(req.Steps) == 0 || len(req.Steps) > maxFunnelSteps {
+		return nil, ErrInvalidInput
+	}
+	if !req.End.After(req.Start) || req.Window <= 0 {
+		return nil, ErrInvalidInput
+	}
+	if req.Identity == "" {
+		req.Identity = "user"
+	}
+	if _, ok := repository.EventIdentityColumns[req.Identity]; !ok {
+		return nil, ErrInvalidInput
+	}
+	if _, ok := repository.EventDimensionColumns[req.Breakdown]; req.Breakdown != "" && !ok {
+		return nil, ErrInvalidInput
+	}
+
+	// Steps after the entry step may happen up to Window past End.
+	rows, err := s.repo.GetFunnelEvents(req.Start, req.End.Add(req.Window), req.Identity, req.Breakdown, req.Steps)
+	if err != nil {
+		return nil, err
+	}
+
+	overall := newFunnelTally(len(req.Steps))
+	segments := make(map[string]*funnelTally)
+	var segmentOrder []string
+	for i := 0; i < len(rows); {
+		j := i
+		for j < len(rows) && rows[j].Actor == rows[i].Actor {
+			j++
+		}
+		path, segment := walkFunnel(rows[i:j], req)
+		i = j
+		if path == nil {
+			continue
+		}
+		overall.add(path)
+		if req.Breakdown != "" {
+			tally, ok := segments[segment]
+			if !ok {
+				tally = newFunnelTally(len(req.Steps))
+				segments[segment] = tally
+				segmentOrder = append(segmentOrder, segment)
+			}
+			tally.add(path)
+		}
+	}
+
+	result := &FunnelResult{
+		Range:         TimeRange{Start: req.Start, End: req.End},
+		Identity:      req.Identity,
+		WindowSeconds: req.Window.Seconds(),
+		Steps:         overall.steps(req.Steps),
+		Breakdown:     req.Breakdown,
+	}
+	sort.Slice(segmentOrder, func(i, j int) bool {
+		a, b := segments[segmentOrder[i]].reached[0], segments[segmentOrder[j]].reached[0]
+		if a != b {
+			return a > b
+		}
+		return segmentOrder[i] < segmentOrder[j]
+	})
+	for _, segment := range segmentOrder {
+		result.Segments = append(result.Segments, &FunnelSegment{
+			Segment: segment,
+			Steps:   segments[segment].steps(req.Steps),
+		})
+	}
+	return result, nil
+}
+
+// walkFunnel finds the deepest path through the funnel for one actor's
+// time-ordered events. Every entry event inside the range is tried, and the
+// earliest entry reaching the greatest depth wins. It returns the timestamps
+// at which each reached step happened and the breakdown segment of the entry.
+func walkFunnel(events []repository.FunnelEventRow, req *FunnelRequest) ([]time.Time, string) {
+	var best []time.Time
+	var bestSegment string
+	for i, entry := range events {
+		if entry.Steps&1 == 0 || entry.Timestamp.Before(req.Start) || entry.Timestamp.After(req.End) {
+			continue
+		}
+		deadline := entry.Timestamp.Add(req.Window)
+		path := []time.Time{entry.Timestamp}
+		for _, event := range events[i+1:] {
+			if len(path) == len(req.Steps) || event.Timestamp.After(deadline) {
+				break
+			}
+			if event.Steps&(int64(1)<<len(path)) != 0 {
+				path = append(path, event.Timestamp)
+			}
+		}
+		if len(path) > len(best) {
+			best, bestSegment = path, entry.Segment
+		}
+		if len(best) == len(req.Steps) {
+			break
+		}
+	}
+	return best, bestSegment
+}
+
+type funnelTally struct {
+	reached   []int64
+	durations [][]float64
+}
+
+func newFunnelTally(steps int) *funnelTally {
+	return &funnelTally{
+		reached:   make([]int64, steps),
+		durations: make([][]float64, steps),
+	}
+}
+
+func (t *funnelTally) add(path []time.Time) {
+	for i, at := range path {
+		t.reached[i]++
+		if i > 0 {
+			t.durations[i] = append(t.durations[i], at.Sub(path[i-1]).Seconds())
+		}
+	}
+}
+
+func (t *funnelTally) steps(filters []repository.EventFilter) []*FunnelStep {
+	steps := make([]*FunnelStep, len(filters))
+	for i, filter := range filters {
+		step := &FunnelStep{Index: i, Filter: filter, Count: t.reached[i]}
+		if t.reached[0] > 0 {
+			step.ConversionRate = float64(t.reached[i]) / float64(t.reached[0]) * 100
+		}
+		if i == 0 {
+			step.StepConversionRate = 100
+			if t.reached[0] == 0 {
+				step.StepConversionRate = 0
+			}
+		} else if t.reached[i-1] > 0 {
+			step.StepConversionRate = float64(t.reached[i]) / float64(t.reached[i-1]) * 100
+		}
+		if i+1 < len(filters) {
+			step.DropOff = t.reached[i] - t.reached[i+1]
+			if t.reached[i] > 0 {
+				step.DropOffRate = float64(step.DropOff) / float64(t.reached[i]) * 100
+			}
+		}
+		step.MedianSecondsToReach = median(t.durations[i])
+		steps[i] = step
+	}
+	return steps
+}
+
+func median(values []float64) *float64 {
+	if len(values) == 0 {
+		return nil
+	}
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	mid := len(sorted) / 2
+	m := sorted[mid]
+	if len(sorted)%2 == 0 {
+		m = (sorted[mid-1] + sorted[mid]) / 2
+	}
+	return &m
+}
+
+const (
+	defaultPathSteps = 3
+	maxPathSteps     = 10
+)
+
+// SankeyNode is a page at a given step of the path. Step is relative to the
+// anchor page, negative for pages leading to it.
+type SankeyNode struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	Step int    `json:"step"`
+}
+
+// SankeyLink connects two nodes by index into the nodes slice.
+type SankeyLink struct {
+	Source int   `json:"source"`
+	Target int   `json:"target"`
+	Value  int64 `json:"value"`
+}
+
+// PathReport is the response of GetPaths, ready to feed a Sankey chart.
+type PathReport struct {
+	Range     TimeRange            `json:"range"`
+	Page      string               `json:"page,omitempty"`
+	Direction string               `json:"direction"`
+	Steps     int                  `json:"steps"`
+	Nodes     []SankeyNode         `json:"nodes"`
+	Links     []SankeyLink         `json:"links"`
+	TopPaths  []repository.PathRow `json:"top_paths"`
+}
+
+// GetPaths builds page-to-page flows for sessions, starting from (direction
+// "from") or ending at (direction "to") the given page. Without a page, flows
+// start at each session's entry page. At most linksPerStep links are kept per
+// step so that the diagram stays readable.
+func (s *eventAnalyticsService) GetPaths(start, end time.Time, page, direction string, steps, linksPerStep int) (*PathReport, error) {
+	if end.Before(start) {
+		return nil, ErrInvalidInput
+	}
+	if direction == "" {
+		direction = "from"
+	}
+	if direction != "from" && direction != "to" {
+		return nil, ErrInvalidInput
+	}
+	reverse := direction == "to"
+	if reverse && page == "" {
+		return nil, ErrInvalidInput
+	}
+	if steps <= 0 {
+		steps = defaultPathSteps
+	}
+	if steps > maxPathSteps {
+		return nil, ErrInvalidInput
+	}
+	if linksPerStep <= 0 {
+		linksPerStep = 10
+	}
+
+	links, err := s.repo.GetPathLinks(start, end, page, reverse, steps)
+	if err != nil {
+		return nil, err
+	}
+	paths, err := s.repo.GetTopPaths(start, end, page, reverse, steps, linksPerStep)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &PathReport{
+		Range:     TimeRange{Start: start, End: end},
+		Page:      page,
+		Direction: direction,
+		Steps:     steps,
+		Nodes:     []SankeyNode{},
+		Links:     []SankeyLink{},
+		TopPaths:  paths,
+	}
+	nodeIndex := make(map[string]int)
+	node := func(name string, step int) int {
+		id := fmt.Sprintf("%d:%s", step, name)
+		if i, ok := nodeIndex[id]; ok {
+			return i
+		}
+		nodeIndex[id] = len(report.Nodes)
+		report.Nodes = append(report.Nodes, SankeyNode{ID: id, Name: name, Step: step})
+		return nodeIndex[id]
+	}
+
+	// Rows arrive ordered by offset and then by count, so the first
+	// linksPerStep rows of each offset are its strongest links.
+	perStep := make(map[int]int)
+	for _, row := range links {
+		if perStep[row.Offset] >= linksPerStep {
+			continue
+		}
+		perStep[row.Offset]++
+		report.Links = append(report.Links, SankeyLink{
+			Source: node(row.Page, row.Offset),
+			Target: node(row.NextPage, row.Offset+1),
+			Value:  row.Sessions,
+		})
+	}
+	return report, nil
+}
+
+const (
+	defaultRetentionPeriods = 12
+	maxRetentionPeriods     = 52
+)
+
+// RetentionCohort is one row of the retention triangle. Retained[i] is the
+// number of cohort users returning i periods after joining; Retained[0] is
+// the cohort size. Periods that have not started yet are omitted.
+type RetentionCohort struct {
+	Cohort   time.Time `json:"cohort"`
+	Size     int64     `json:"size"`
+	Retained []int64   `json:"retained"`
+	Rates    []float64 `json:"rates"`
+}
+
+// RetentionReport is the response of GetRetention.
+type RetentionReport struct {
+	Range       TimeRange          `json:"range"`
+	Period      string             `json:"period"`
+	CohortEvent string             `json:"cohort_event,omitempty"`
+	ReturnEvent string             `json:"return_event,omitempty"`
+	Periods     int                `json:"periods"`
+	Cohorts     []*RetentionCohort `json:"cohorts"`
+}
+
+// GetRetention builds weekly or monthly retention cohorts for users whose
+// first cohortEvent falls within [start, end]. An empty cohortEvent or
+// returnEvent matches any event type.
+func (s *eventAnalyticsService) GetRetention(start, end time.Time, period, cohortEvent, returnEvent string, periods int) (*RetentionReport, error) {
+	if end.Before(start) {
+		return nil, ErrInvalidInput
+	}
+	if period == "" {
+		period = "week"
+	}
+	if period != "week" && period != "month" {
+		return nil, ErrInvalidInput
+	}
+	if periods <= 0 {
+		periods = defaultRetentionPeriods
+	}
+	if periods > maxRetentionPeriods {
+		return nil, ErrInvalidInput
+	}
+
+	rows, err := s.repo.GetRetention(start, end, period, cohortEvent, returnEvent)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &RetentionReport{
+		Range:       TimeRange{Start: start, End: end},
+		Period:      period,
+		CohortEvent: cohortEvent,
+		ReturnEvent: returnEvent,
+		Periods:     periods,
+		Cohorts:     []*RetentionCohort{},
+	}
+	now := time.Now()
+	cohorts := make(map[time.Time]*RetentionCohort)
+	for _, row := range rows {
+		cohort, ok := cohorts[row.CohortPeriod]
+		if !ok {
+			elapsed := periodOffset(period, row.CohortPeriod, now) + 1
+			cohort = &RetentionCohort{
+				Cohort:   row.CohortPeriod,
+				Retained: make([]int64, min(elapsed, periods)),
+			}
+			cohorts[row.CohortPeriod] = cohort
+			report.Cohorts = append(report.Cohorts, cohort)
+		}
+		if row.ActivePeriod == nil {
+			cohort.Size = row.Users
+			if len(cohort.Retained) > 0 {
+				cohort.Retained[0] = row.Users
+			}
+			continue
+		}
+		if offset := periodOffset(period, row.CohortPeriod, *row.ActivePeriod); offset < len(cohort.Retained) {
+			cohort.Retained[offset] = row.Users
+		}
+	}
+
+	for _, cohort := range report.Cohorts {
+		cohort.Rates = make([]float64, len(cohort.Retained))
+		if cohort.Size == 0 {
+			continue
+		}
+		for i, users := range cohort.Retained {
+			cohort.Rates[i] = float64(users) / float64(cohort.Size) * 100
+		}
+	}
+	return report, nil
+}
+
+// periodOffset returns the number of whole weeks or calendar months from a
+// period start to t.
+func periodOffset(period string, from, t time.Time) int {
+	if period == "month" {
+		t = t.In(from.Location())
+		return (t.Year()-from.Year())*12 + int(t.Month()) - int(from.Month())
+	}
+	return int(t.Sub(from) / (7 * 24 * time.Hour))
+}
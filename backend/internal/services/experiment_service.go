@@ -0,0 +1,407 @@
+package services
+
+import (
+	"errors"
+	"math"
+	"regexp"
+	"time"
+
+	"github.com/KalebAsratemedhi/stock-dashboard/backend/internal/models"
+	"github.com/KalebAsratemedhi/stock-dashboard/backend/internal/repository"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultConfidence = 0.95
+
+	// defaultSequentialTau is the mSPRT mixing standard deviation of
+	// experiments created without one: a one point difference in conversion
+	// rate.
+	defaultSequentialTau = 0.01
+)
+
+var experimentKeyPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,99}$`)
+
+// ExperimentResultsQuery configures GetResults. Confidence defaults to 0.95.
+// Sequential adds an always-valid mSPRT test whose p-value and interval stay
+// valid however often the results are checked. It uses the experiment's
+// SequentialTau, which is fixed before any data is seen.
+type ExperimentResultsQuery struct {
+	Confidence float64
+	Sequential bool
+}
+
+// SequentialTest is the mixture sequential probability ratio test of a
+// variant against control. LikelihoodRatio is that of the latest look; the
+// p-value and interval cover every look since the experiment started.
+type SequentialTest struct {
+	Tau             float64    `json:"tau"`
+	LikelihoodRatio float64    `json:"likelihood_ratio"`
+	PValue          float64    `json:"p_value"`
+	DifferenceCI    [2]float64 `json:"difference_ci"`
+	Significant     bool       `json:"significant"`
+}
+
+// VariantComparison compares a variant's conversion rate with control using a
+// two-proportion z-test. Lift is relative to the control rate and is nil when
+// that rate is zero.
+type VariantComparison struct {
+	Difference   float64         `json:"difference"`
+	DifferenceCI [2]float64      `json:"difference_ci"`
+	Lift         *float64        `json:"lift"`
+	LiftCI       *[2]float64     `json:"lift_ci"`
+	ZScore       float64         `json:"z_score"`
+	PValue       float64         `json:"p_value"`
+	Significant  bool            `json:"significant"`
+	Sequential   *SequentialTest `json:"sequential,omitempty"`
+}
+
+// VariantResult is the outcome of one variant. Comparison is nil for control
+// and for variants without exposures on either side.
+type VariantResult struct {
+	Variant        string             `json:"variant"`
+	Control        bool               `json:"control"`
+	Exposures      int64              `json:"exposures"`
+	Conversions    int64              `json:"conversions"`
+	ConversionRate float64            `json:"conversion_rate"`
+	ConversionCI   [2]float64         `json:"conversion_rate_ci"`
+	Comparison     *VariantComparison `json:"comparison,omitempty"`
+}
+
+// ExperimentResults is the response of GetResults. Actors exposed to more
+// than one variant are excluded and counted in MixedExposures; exposures to
+// variants not defined on the experiment are counted in UnknownExposures.
+type ExperimentResults struct {
+	Experiment       *models.Experiment `json:"experiment"`
+	AsOf             time.Time          `json:"as_of"`
+	Confidence       float64            `json:"confidence"`
+	Method           string             `json:"method"`
+	Exposures        int64              `json:"exposures"`
+	MixedExposures   int64              `json:"mixed_exposures"`
+	UnknownExposures int64              `json:"unknown_exposures"`
+	Variants         []*VariantResult   `json:"variants"`
+}
+
+type ExperimentService interface {
+	IncludeBots() ExperimentService
+	CreateExperiment(experiment *models.Experiment) error
+	UpdateExperiment(experiment *models.Experiment) error
+	DeleteExperiment(id uint) error
+	GetExperimentByID(id uint) (*models.Experiment, error)
+	ListExperiments() ([]*models.Experiment, error)
+	GetResults(id uint, now time.Time, query ExperimentResultsQuery) (*ExperimentResults, error)
+}
+
+type experimentService struct {
+	repo repository.ExperimentRepository
+}
+
+func NewExperimentService(repo repository.ExperimentRepository) ExperimentService {
+	return &experimentService{repo: repo}
+}
+
+// IncludeBots returns a service whose results also count bot traffic.
+func (s *experimentService) IncludeBots() ExperimentService {
+	return &experimentService{repo: s.repo.IncludeBots()}
+}
+
+func (s *experimentService) CreateExperiment(experiment *models.Experiment) error {
+	if err := validateExperiment(experiment); err != nil {
+		return err
+	}
+	return s.repo.Create(experiment)
+}
+
+func (s *experimentService) UpdateExperiment(experiment *models.Experiment) error {
+	if err := validateExperiment(experiment); err != nil {
+		return err
+	}
+	existing, err := s.GetExperimentByID(experiment.ID)
+	if err != nil {
+		return err
+	}
+	experiment.CreatedAt = existing.CreatedAt
+	experiment.SequentialTau = existing.SequentialTau
+	return s.repo.Update(experiment)
+}
+
+func (s *experimentService) DeleteExperiment(id uint) error {
+	if err := s.repo.Delete(id); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+func (s *experimentService) GetExperimentByID(id uint) (*models.Experiment, error) {
+	experiment, err := s.repo.GetByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return experiment, nil
+}
+
+func (s *experimentService) ListExperiments() ([]*models.Experiment, error) {
+	return s.repo.List()
+}
+
+// GetResults analyses the experiment from its start until its end, or now
+// while it is running.
+func (s *experimentService) GetResults(id uint, now time.Time, query ExperimentResultsQuery) (*ExperimentResults, error) {
+	if query.Confidence == 0 {
+		query.Confidence = defaultConfidence
+	}
+	if query.Confidence <= 0 || query.Confidence >= 1 {
+		return nil, ErrInvalidInput
+	}
+	experiment, err := s.GetExperimentByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	asOf := now
+	if experiment.EndAt != nil && experiment.EndAt.Before(now) {
+		asOf = *experiment.EndAt
+	}
+	counts, err := s.repo.GetVariantCounts(experiment, asOf)
+	if err != nil {
+		return nil, err
+	}
+
+	results := &ExperimentResults{
+		Experiment: experiment,
+		AsOf:       asOf,
+		Confidence: query.Confidence,
+		Method:     "fixed",
+		Variants:   make([]*VariantResult, 0, len(experiment.Variants)),
+	}
+	if query.Sequential {
+		results.Method = "sequential"
+	}
+
+	byVariant := make(map[string]*VariantResult, len(experiment.Variants))
+	for _, variant := range experiment.Variants {
+		result := &VariantResult{Variant: variant, Control: variant == experiment.Control}
+		byVariant[variant] = result
+		results.Variants = append(results.Variants, result)
+	}
+	for _, row := range counts {
+		result, ok := byVariant[row.Variant]
+		switch {
+		case row.Variant == "":
+			results.MixedExposures += row.Exposures
+		case !ok:
+			results.UnknownExposures += row.Exposures
+		default:
+			result.Exposures = row.Exposures
+			result.Conversions = row.Conversions
+			results.Exposures += row.Exposures
+		}
+	}
+
+	z := normalQuantile(query.Confidence)
+	for _, result := range results.Variants {
+		if result.Exposures > 0 {
+			result.ConversionRate = float64(result.Conversions) / float64(result.Exposures)
+			result.ConversionCI = wilsonInterval(result.Conversions, result.Exposures, z)
+		}
+	}
+	var looks map[string][]sequentialLook
+	if query.Sequential {
+		rows, err := s.repo.GetVariantLooks(experiment, asOf)
+		if err != nil {
+			return nil, err
+		}
+		looks = sequentialLooks(rows, experiment.Control)
+	}
+
+	control := byVariant[experiment.Control]
+	for _, result := range results.Variants {
+		if result.Control || result.Exposures == 0 || control.Exposures == 0 {
+			continue
+		}
+		result.Comparison = compareProportions(control, result, z)
+		if query.Sequential {
+			result.Comparison.Sequential = sequentialTest(looks[result.Variant], experiment.SequentialTau, 1-query.Confidence)
+		}
+	}
+	return results, nil
+}
+
+// sequentialLook holds the cumulative counts of control and a variant at one
+// look.
+type sequentialLook struct {
+	ControlExposures   int64
+	ControlConversions int64
+	Exposures          int64
+	Conversions        int64
+}
+
+// sequentialLooks accumulates the per-look counts of every variant against
+// control, keyed by variant. A variant gets an entry at every look from its
+// first exposure on at which any count changed.
+func sequentialLooks(rows []repository.VariantLookRow, control string) map[string][]sequentialLook {
+	exposures := map[string]int64{}
+	conversions := map[string]int64{}
+	looks := map[string][]sequentialLook{}
+	for i, row := range rows {
+		exposures[row.Variant] += row.Exposures
+		conversions[row.Variant] += row.Conversions
+		if i+1 < len(rows) && rows[i+1].Look.Equal(row.Look) {
+			continue
+		}
+		// Last row of this look: record every variant seen so far.
+		for variant := range exposures {
+			if variant == control || variant == "" {
+				continue
+			}
+			looks[variant] = append(looks[variant], sequentialLook{
+				ControlExposures:   exposures[control],
+				ControlConversions: conversions[control],
+				Exposures:          exposures[variant],
+				Conversions:        conversions[variant],
+			})
+		}
+	}
+	return looks
+}
+
+// compareProportions runs a two-sided two-proportion z-test with a pooled
+// standard error; the interval uses the unpooled standard error.
+func compareProportions(control, variant *VariantResult, z float64) *VariantComparison {
+	pc, pv := control.ConversionRate, variant.ConversionRate
+	nc, nv := float64(control.Exposures), float64(variant.Exposures)
+	diff := pv - pc
+
+	comparison := &VariantComparison{Difference: diff, PValue: 1}
+	pooled := float64(control.Conversions+variant.Conversions) / (nc + nv)
+	if se := math.Sqrt(pooled * (1 - pooled) * (1/nc + 1/nv)); se > 0 {
+		comparison.ZScore = diff / se
+		comparison.PValue = math.Erfc(math.Abs(comparison.ZScore) / math.Sqrt2)
+	}
+	se := math.Sqrt(pc*(1-pc)/nc + pv*(1-pv)/nv)
+	comparison.DifferenceCI = [2]float64{diff - z*se, diff + z*se}
+	comparison.Significant = comparison.PValue < 2*(1-normalCDF(z))
+
+	if pc > 0 {
+		lift := diff / pc
+		comparison.Lift = &lift
+		comparison.LiftCI = &[2]float64{comparison.DifferenceCI[0] / pc, comparison.DifferenceCI[1] / pc}
+	}
+	return comparison
+}
+
+// sequentialTest is the mSPRT of Johari et al. with a normal mixture of
+// standard deviation tau over the rate difference, run over the looks in
+// order. The always-valid p-value is the running minimum of 1/Λ, capped at
+// 1, and the interval the running intersection of the per-look intervals,
+// both at level 1-alpha. Looks without exposures on either side or without
+// any variance are skipped.
+func sequentialTest(looks []sequentialLook, tau, alpha float64) *SequentialTest {
+	test := &SequentialTest{Tau: tau, LikelihoodRatio: 1, PValue: 1}
+	if tau <= 0 {
+		return test
+	}
+	t2 := tau * tau
+	bounded := false
+	for _, look := range looks {
+		if look.ControlExposures == 0 || look.Exposures == 0 {
+			continue
+		}
+		pc := float64(look.ControlConversions) / float64(look.ControlExposures)
+		pv := float64(look.Conversions) / float64(look.Exposures)
+		v := pc*(1-pc)/float64(look.ControlExposures) + pv*(1-pv)/float64(look.Exposures)
+		if v == 0 {
+			continue
+		}
+		diff := pv - pc
+		test.LikelihoodRatio = math.Sqrt(v/(v+t2)) * math.Exp(t2*diff*diff/(2*v*(v+t2)))
+		test.PValue = math.Min(test.PValue, 1/test.LikelihoodRatio)
+
+		half := math.Sqrt(v * (v + t2) / t2 * (math.Log((v+t2)/v) - 2*math.Log(alpha)))
+		lower, upper := diff-half, diff+half
+		if bounded {
+			// Disjoint intervals can only follow a rejection; keep the last
+			// non-empty one rather than report an empty interval.
+			lower, upper = math.Max(lower, test.DifferenceCI[0]), math.Min(upper, test.DifferenceCI[1])
+			if lower > upper {
+				continue
+			}
+		}
+		test.DifferenceCI = [2]float64{lower, upper}
+		bounded = true
+	}
+	test.Significant = test.PValue < alpha
+	return test
+}
+
+// wilsonInterval is the Wilson score interval of a proportion.
+func wilsonInterval(successes, trials int64, z float64) [2]float64 {
+	n := float64(trials)
+	p := float64(successes) / n
+	denominator := 1 + z*z/n
+	center := (p + z*z/(2*n)) / denominator
+	half := z * math.Sqrt(p*(1-p)/n+z*z/(4*n*n)) / denominator
+	return [2]float64{center - half, center + half}
+}
+
+// normalQuantile returns z such that a two-sided interval of ±z covers
+// confidence of a standard normal distribution.
+func normalQuantile(confidence float64) float64 {
+	return math.Sqrt2 * math.Erfinv(confidence)
+}
+
+func normalCDF(x float64) float64 {
+	return 0.5 * math.Erfc(-x/math.Sqrt2)
+}
+
+func validateExperiment(experiment *models.Experiment) error {
+	if experiment.SequentialTau == 0 {
+		experiment.SequentialTau = defaultSequentialTau
+	}
+	if experiment.SequentialTau < 0 || experiment.SequentialTau > 1 {
+		return ErrInvalidInput
+	}
+	if !experimentKeyPattern.MatchString(experiment.Key) || experiment.Name == "" {
+		return ErrInvalidInput
+	}
+	if experiment.GoalEvent == "" || !eventTypePattern.MatchString(experiment.GoalEvent) {
+		return ErrInvalidInput
+	}
+	if experiment.Identity == "" {
+		experiment.Identity = "user"
+	}
+	if _, ok := repository.EventIdentityColumns[experiment.Identity]; !ok {
+		return ErrInvalidInput
+	}
+
+	if len(experiment.Variants) < 2 {
+		return ErrInvalidInput
+	}
+	seen := make(map[string]bool, len(experiment.Variants))
+	for _, variant := range experiment.Variants {
+		if variant == "" || len(variant) > 100 || seen[variant] {
+			return ErrInvalidInput
+		}
+		seen[variant] = true
+	}
+	if experiment.Control == "" {
+		experiment.Control = experiment.Variants[0]
+	}
+	if !seen[experiment.Control] {
+		return ErrInvalidInput
+	}
+
+	if experiment.StartAt.IsZero() {
+		return ErrInvalidInput
+	}
+	if experiment.EndAt != nil && !experiment.EndAt.After(experiment.StartAt) {
+		return ErrInvalidInput
+	}
+	return nil
+}
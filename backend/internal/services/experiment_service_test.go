@@ -0,0 +1,97 @@
+package services
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/KalebAsratemedhi/stock-dashboard/backend/internal/repository"
+)
+
+func TestSequentialLooks(t *testing.T) {
+	day1, day2, day3 := date(2026, time.March, 1), date(2026, time.March, 2), date(2026, time.March, 3)
+	rows := []repository.VariantLookRow{
+		{Look: day1, Variant: "control", Exposures: 10, Conversions: 1},
+		{Look: day1, Variant: "b", Exposures: 8},
+		{Look: day2, Variant: "", Exposures: 2},
+		{Look: day2, Variant: "c", Exposures: 4, Conversions: 1},
+		{Look: day2, Variant: "control", Exposures: 5, Conversions: 1},
+		{Look: day3, Variant: "b", Exposures: 2, Conversions: 1},
+	}
+	want := map[string][]sequentialLook{
+		"b": {
+			{ControlExposures: 10, ControlConversions: 1, Exposures: 8},
+			{ControlExposures: 15, ControlConversions: 2, Exposures: 8},
+			{ControlExposures: 15, ControlConversions: 2, Exposures: 10, Conversions: 1},
+		},
+		"c": {
+			{ControlExposures: 15, ControlConversions: 2, Exposures: 4, Conversions: 1},
+			{ControlExposures: 15, ControlConversions: 2, Exposures: 4, Conversions: 1},
+		},
+	}
+
+	got := sequentialLooks(rows, "control")
+	if len(got) != len(want) {
+		t.Fatalf("sequentialLooks() = %+v, want %+v", got, want)
+	}
+	for variant, looks := range want {
+		if len(got[variant]) != len(looks) {
+			t.Errorf("%s: got %+v, want %+v", variant, got[variant], looks)
+			continue
+		}
+		for i := range looks {
+			if got[variant][i] != looks[i] {
+				t.Errorf("%s look %d = %+v, want %+v", variant, i, got[variant][i], looks[i])
+			}
+		}
+	}
+}
+
+func TestSequentialTest(t *testing.T) {
+	lift := sequentialLook{ControlExposures: 1000, ControlConversions: 100, Exposures: 1000, Conversions: 150}
+	flat := sequentialLook{ControlExposures: 2000, ControlConversions: 200, Exposures: 2000, Conversions: 200}
+	tests := []struct {
+		name        string
+		looks       []sequentialLook
+		tau         float64
+		ratio       float64
+		pValue      float64
+		ci          [2]float64
+		significant bool
+	}{
+		{"no looks", nil, 0.01, 1, 1, [2]float64{}, false},
+		{"no mixing", []sequentialLook{lift}, 0, 1, 1, [2]float64{}, false},
+		{"one look", []sequentialLook{lift}, 0.01, 5.058040113, 0.1977050355,
+			[2]float64{-0.01632274124, 0.1163227412}, false},
+		{"p-value keeps its minimum and intervals intersect", []sequentialLook{lift, flat}, 0.01, 0.6882472016, 0.1977050355,
+			[2]float64{-0.01632274124, 0.03394575232}, false},
+		{"looks without exposures or variance are skipped", []sequentialLook{
+			{Exposures: 10, Conversions: 1},
+			{ControlExposures: 10, Exposures: 10},
+			lift,
+		}, 0.01, 5.058040113, 0.1977050355, [2]float64{-0.01632274124, 0.1163227412}, false},
+		{"significant", []sequentialLook{
+			{ControlExposures: 10000, ControlConversions: 1000, Exposures: 10000, Conversions: 1300},
+		}, 0.01, 40904738.51, 2.444704541e-08, [2]float64{0.01622066834, 0.04377933166}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := sequentialTest(tt.looks, tt.tau, 0.05)
+			if math.Abs(got.LikelihoodRatio-tt.ratio) > 1e-6*tt.ratio {
+				t.Errorf("LikelihoodRatio = %v, want %v", got.LikelihoodRatio, tt.ratio)
+			}
+			if math.Abs(got.PValue-tt.pValue) > 1e-6*tt.pValue {
+				t.Errorf("PValue = %v, want %v", got.PValue, tt.pValue)
+			}
+			for i := range tt.ci {
+				if math.Abs(got.DifferenceCI[i]-tt.ci[i]) > 1e-9 {
+					t.Errorf("DifferenceCI = %v, want %v", got.DifferenceCI, tt.ci)
+					break
+				}
+			}
+			if got.Significant != tt.significant {
+				t.Errorf("Significant = %v, want %v", got.Significant, tt.significant)
+			}
+		})
+	}
+}
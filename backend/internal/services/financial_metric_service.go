@@ -1,12 +1,68 @@
 package services
 
 import (
+	"fmt"
+	"regexp"
 	"time"
 
+	"github.com/KalebAsratemedhi/stock-dashboard/backend/internal/fiscal"
 	"github.com/KalebAsratemedhi/stock-dashboard/backend/internal/models"
 	"github.com/KalebAsratemedhi/stock-dashboard/backend/internal/repository"
 )
 
+const defaultFinancialInterval = "1 day"
+
+// financialIntervalPattern restricts time series buckets to whole numbers of
+// minutes through months.
+var financialIntervalPattern = regexp.MustCompile(`^[1-9][0-9]{0,3} (minute|hour|day|week|month)s?$`)
+
+// FiscalIntervals are the time series intervals that bucket by fiscal
+// calendar periods rather than fixed lengths.
+var FiscalIntervals = map[string]fiscal.Granularity{
+	"fiscal_week":    fiscal.Week,
+	"fiscal_month":   fiscal.Month,
+	"fiscal_quarter": fiscal.Quarter,
+	"fiscal_year":    fiscal.Year,
+}
+
+// FinancialSeriesQuery selects the bucket size, grouping and filters of a
+// financial metric time series. Interval is a length such as "1 week" or a
+// key of FiscalIntervals. GroupBy is empty or a key of
+// repository.FinancialGroupColumns; grouping by anything but metric_type
+// requires a MetricType.
+type FinancialSeriesQuery struct {
+	Interval   string
+	GroupBy    string
+	MetricType string
+	Department string
+}
+
+// FinancialSeriesPoint is one bucket of a financial metric series. Period is
+// the fiscal period label of fiscal interval buckets.
+type FinancialSeriesPoint struct {
+	Bucket time.Time `json:"bucket"`
+	Period string    `json:"period,omitempty"`
+	Amount float64   `json:"amount"`
+	Count  int64     `json:"count"`
+}
+
+// FinancialSeries is the bucketed totals of one group. Buckets without
+// metrics are omitted.
+type FinancialSeries struct {
+	Group  string                 `json:"group"`
+	Total  float64                `json:"total"`
+	Points []FinancialSeriesPoint `json:"points"`
+}
+
+// FinancialTimeSeries is the response of GetMetricsByTimeRangeAggregated,
+// with one series per group ordered by group.
+type FinancialTimeSeries struct {
+	Range    TimeRange          `json:"range"`
+	Interval string             `json:"interval"`
+	GroupBy  string             `json:"group_by,omitempty"`
+	Series   []*FinancialSeries `json:"series"`
+}
+
 type FinancialMetricService interface {
 	CreateMetric(metric *models.FinancialMetric) error
 	BatchCreateMetrics(metrics []*models.FinancialMetric) error
@@ -16,18 +72,25 @@ type FinancialMetricService interface {
 	GetMetricsByType(metricType string, limit int) ([]*models.FinancialMetric, error)
 	GetMetricsByDepartment(department string, limit int) ([]*models.FinancialMetric, error)
 	GetTotalByType(metricType string, start, end time.Time) (float64, error)
-	GetMetricsGroupedByDepartment(start, end time.Time) ([]map[string]interface{}, error)
-	GetBudgetVsActual(start, end time.Time) ([]map[string]interface{}, error)
-	GetVarianceByDepartment(start, end time.Time) ([]map[string]interface{}, error)
-	GetMetricsByTimeRangeAggregated(start, end time.Time, interval string) ([]map[string]interface{}, error)
+	GetMetricsGroupedByDepartment(start, end time.Time) ([]repository.DepartmentMetricRow, error)
+	CompareMetricsGroupedByDepartment(start, end time.Time, mode CompareMode) (*PeriodComparison, error)
+	GetBudgetVsActual(start, end time.Time, version string) ([]repository.BudgetVsActualRow, error)
+	GetVarianceByDepartment(start, end time.Time) ([]repository.BudgetVsActualRow, error)
+	GetMetricsByTimeRangeAggregated(start, end time.Time, query FinancialSeriesQuery) (*FinancialTimeSeries, error)
+	ResolvePeriod(label string) (TimeRange, error)
+	StampFiscalPeriods() (int64, error)
 }
 
 type financialMetricService struct {
-	repo repository.FinancialMetricRepository
+	repo     repository.FinancialMetricRepository
+	calendar *fiscal.Calendar
 }
 
-func NewFinancialMetricService(repo repository.FinancialMetricRepository) FinancialMetricService {
-	return &financialMetricService{repo: repo}
+// NewFinancialMetricService creates a financial metric service that labels
+// metrics and resolves periods with the fiscal calendar, and refuses metrics
+// for closed periods.
+func NewFinancialMetricService(repo repository.FinancialMetricRepository, calendar *fiscal.Calendar) FinancialMetricService {
+	return &financialMetricService{repo: repo, calendar: calendar}
 }
 
 func (s *financialMetricService) CreateMetric(metric *models.FinancialMetric) error {
@@ -40,11 +103,15 @@ func (s *financialMetricService) CreateMetric(metric *models.FinancialMetric) er
 	if metric.Timestamp.IsZero() {
 		metric.Timestamp = time.Now()
 	}
+	metric.FiscalPeriod = s.calendar.PeriodOf(metric.Timestamp, fiscal.Month).Label
 	if metric.Budget > 0 {
 		metric.Variance = metric.Amount - metric.Budget
 		metric.VariancePct = (metric.Variance / metric.Budget) * 100
 	}
-	return s.repo.Create(metric)
+	metrics := []*models.FinancialMetric{metric}
+	return s.repo.CreateUnlessClosed(metrics, func(closes []*models.PeriodClose) error {
+		return checkOpen(metrics, closes)
+	})
 }
 
 func (s *financialMetricService) BatchCreateMetrics(metrics []*models.FinancialMetric) error {
@@ -58,12 +125,28 @@ func (s *financialMetricService) BatchCreateMetrics(metrics []*models.FinancialM
 		if metric.Timestamp.IsZero() {
 			metric.Timestamp = time.Now()
 		}
+		metric.FiscalPeriod = s.calendar.PeriodOf(metric.Timestamp, fiscal.Month).Label
 		if metric.Budget > 0 {
 			metric.Variance = metric.Amount - metric.Budget
 			metric.VariancePct = (metric.Variance / metric.Budget) * 100
 		}
 	}
-	return s.repo.BatchCreate(metrics)
+	return s.repo.CreateUnlessClosed(metrics, func(closes []*models.PeriodClose) error {
+		return checkOpen(metrics, closes)
+	})
+}
+
+// checkOpen returns ErrPeriodClosed if any metric falls in one of closes, so
+// that a batch is written whole or not at all.
+func checkOpen(metrics []*models.FinancialMetric, closes []*models.PeriodClose) error {
+	for _, metric := range metrics {
+		for _, periodClose := range closes {
+			if !metric.Timestamp.Before(periodClose.PeriodStart) && metric.Timestamp.Before(periodClose.PeriodEnd) {
+				return fmt.Errorf("%w: %s was closed by %s", ErrPeriodClosed, periodClose.Period, periodClose.ClosedBy)
+			}
+		}
+	}
+	return nil
 }
 
 func (s *financialMetricService) GetMetricByID(id uint) (*models.FinancialMetric, error) {
@@ -100,50 +183,167 @@ func (s *financialMetricService) GetTotalByType(metricType string, start, end ti
 	return s.repo.GetTotalByType(metricType, start, end)
 }
 
-func (s *financialMetricService) GetMetricsGroupedByDepartment(start, end time.Time) ([]map[string]interface{}, error) {
+func (s *financialMetricService) GetMetricsGroupedByDepartment(start, end time.Time) ([]repository.DepartmentMetricRow, error) {
+	if end.Before(start) {
+		return nil, ErrInvalidInput
+	}
 	return s.repo.GetMetricsByDepartment(start, end)
 }
 
-func (s *financialMetricService) GetBudgetVsActual(start, end time.Time) ([]map[string]interface{}, error) {
-	metrics, err := s.repo.GetByTimeRange(start, end)
+// CompareMetricsGroupedByDepartment compares totals per department and
+// metric type. Metric types are in different units, so the comparison is
+// totalled per metric type rather than overall.
+func (s *financialMetricService) CompareMetricsGroupedByDepartment(start, end time.Time, mode CompareMode) (*PeriodComparison, error) {
+	comparison, err := ComparePeriods(start, end, mode, func(start, end time.Time) ([]GroupValue, error) {
+		rows, err := s.repo.GetMetricsByDepartment(start, end)
+		if err != nil {
+			return nil, err
+		}
+		values := make([]GroupValue, 0, len(rows))
+		for _, row := range rows {
+			values = append(values, GroupValue{
+				Key: map[string]string{
+					"department":  row.Department,
+					"metric_type": row.MetricType,
+				},
+				Value: row.TotalAmount,
+			})
+		}
+		return values, nil
+	})
 	if err != nil {
 		return nil, err
 	}
-	result := make(map[string]map[string]float64)
-	for _, m := range metrics {
-		if m.Department == "" {
-			continue
-		}
-		if result[m.Department] == nil {
-			result[m.Department] = make(map[string]float64)
-		}
-		result[m.Department]["actual"] += m.Amount
-		if m.Budget > 0 {
-			result[m.Department]["budget"] += m.Budget
+	comparison.totalBy("metric_type")
+	return comparison, nil
+}
+
+// GetBudgetVsActual compares actuals with the budget lines of the budgets
+// table, prorated to [start, end]. version is "original" or "revised", the
+// default, which prefers revised lines over their originals.
+func (s *financialMetricService) GetBudgetVsActual(start, end time.Time, version string) ([]repository.BudgetVsActualRow, error) {
+	if end.Before(start) {
+		return nil, ErrInvalidInput
+	}
+	if version == "" {
+		version = "revised"
+	}
+	if !containsString(BudgetVersions, version) {
+		return nil, ErrInvalidInput
+	}
+	lines, err := s.repo.GetBudgetLines(start, end, version)
+	if err != nil {
+		return nil, err
+	}
+	actuals, err := s.repo.GetBudgetActuals(start, end, version)
+	if err != nil {
+		return nil, err
+	}
+	return budgetVsActual(lines, actuals, start, end), nil
+}
+
+func (s *financialMetricService) GetVarianceByDepartment(start, end time.Time) ([]repository.BudgetVsActualRow, error) {
+	return s.GetBudgetVsActual(start, end, "")
+}
+
+// GetMetricsByTimeRangeAggregated totals metrics per time bucket, one series
+// per group. The interval defaults to one day, and the grouping to
+// metric_type unless a metric type is selected. Fiscal intervals total daily
+// buckets per fiscal period, each point starting where its period does.
+func (s *financialMetricService) GetMetricsByTimeRangeAggregated(start, end time.Time, query FinancialSeriesQuery) (*FinancialTimeSeries, error) {
+	if end.Before(start) {
+		return nil, ErrInvalidInput
+	}
+	if query.Interval == "" {
+		query.Interval = defaultFinancialInterval
+	}
+	granularity, fiscalInterval := FiscalIntervals[query.Interval]
+	if !fiscalInterval && !financialIntervalPattern.MatchString(query.Interval) {
+		return nil, ErrInvalidInput
+	}
+	if _, ok := repository.FinancialGroupColumns[query.GroupBy]; query.GroupBy != "" && !ok {
+		return nil, ErrInvalidInput
+	}
+	// Amounts of different metric types are not in the same unit, so a
+	// series never adds them together.
+	if query.MetricType == "" && query.GroupBy != "metric_type" {
+		if query.GroupBy != "" {
+			return nil, fmt.Errorf("%w: grouping by %s requires a metric type", ErrInvalidInput, query.GroupBy)
 		}
+		query.GroupBy = "metric_type"
 	}
-	var output []map[string]interface{}
-	for dept, values := range result {
-		variance := values["actual"] - values["budget"]
-		var variancePct float64
-		if values["budget"] > 0 {
-			variancePct = (variance / values["budget"]) * 100
+
+	interval := query.Interval
+	if fiscalInterval {
+		interval = "1 day"
+	}
+	rows, err := s.repo.GetTimeSeries(start, end, interval, query.GroupBy, repository.FinancialFilter{
+		MetricType: query.MetricType,
+		Department: query.Department,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := &FinancialTimeSeries{
+		Range:    TimeRange{Start: start, End: end},
+		Interval: query.Interval,
+		GroupBy:  query.GroupBy,
+		Series:   []*FinancialSeries{},
+	}
+	// Rows arrive ordered by group, so each group's rows are contiguous.
+	var series *FinancialSeries
+	for _, row := range rows {
+		if series == nil || series.Group != row.Group {
+			series = &FinancialSeries{Group: row.Group}
+			result.Series = append(result.Series, series)
 		}
-		output = append(output, map[string]interface{}{
-			"department":   dept,
-			"actual":       values["actual"],
-			"budget":       values["budget"],
-			"variance":     variance,
-			"variance_pct": variancePct,
+		series.Total += row.Amount
+		if fiscalInterval {
+			period := s.calendar.PeriodOf(row.Bucket, granularity)
+			if last := len(series.Points) - 1; last >= 0 && series.Points[last].Period == period.Label {
+				series.Points[last].Amount += row.Amount
+				series.Points[last].Count += row.Count
+				continue
+			}
+			series.Points = append(series.Points, FinancialSeriesPoint{
+				Bucket: period.Start,
+				Period: period.Label,
+				Amount: row.Amount,
+				Count:  row.Count,
+			})
+			continue
+		}
+		series.Points = append(series.Points, FinancialSeriesPoint{
+			Bucket: row.Bucket,
+			Amount: row.Amount,
+			Count:  row.Count,
 		})
 	}
-	return output, nil
+	return result, nil
 }
 
-func (s *financialMetricService) GetVarianceByDepartment(start, end time.Time) ([]map[string]interface{}, error) {
-	return s.GetBudgetVsActual(start, end)
+// ResolvePeriod returns the range of a calendar or fiscal period label, as
+// ParseFinancialPeriod does with the service's fiscal calendar.
+func (s *financialMetricService) ResolvePeriod(label string) (TimeRange, error) {
+	return ParseFinancialPeriod(s.calendar, label)
 }
 
-func (s *financialMetricService) GetMetricsByTimeRangeAggregated(start, end time.Time, interval string) ([]map[string]interface{}, error) {
-	return []map[string]interface{}{}, ErrInternal
-}
\ No newline at end of file
+// StampFiscalPeriods sets the fiscal period of metrics stored without one,
+// such as those ingested before fiscal periods were stamped, one fiscal
+// month at a time. It returns how many metrics it stamped.
+func (s *financialMetricService) StampFiscalPeriods() (int64, error) {
+	first, last, err := s.repo.GetUnstampedRange()
+	if err != nil || first == nil {
+		return 0, err
+	}
+	var stamped int64
+	for period := s.calendar.PeriodOf(*first, fiscal.Month); !period.Start.After(*last); period = s.calendar.Shift(period, 1) {
+		n, err := s.repo.StampFiscalPeriod(period.Start, period.End, period.Label)
+		if err != nil {
+			return stamped, err
+		}
+		stamped += n
+	}
+	return stamped, nil
+}
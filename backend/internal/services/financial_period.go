@@ -0,0 +1,42 @@
+package services
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/KalebAsratemedhi/stock-dashboard/backend/internal/fiscal"
+)
+
+var financialPeriodPattern = regexp.MustCompile(`^(\d{4})(?:-Q([1-4])|-(0[1-9]|1[0-2]))?$`)
+
+// ParseFinancialPeriod returns the range a period label covers: a calendar
+// year (2026), quarter (2026-Q3) or month (2026-07) in UTC, or a fiscal
+// period of calendar (FY2026, FY2026-Q3, FY2026-M07 or FY2026-W05). End is
+// the start of the following period.
+func ParseFinancialPeriod(calendar *fiscal.Calendar, label string) (TimeRange, error) {
+	if fiscal.IsLabel(label) {
+		period, err := calendar.Parse(label)
+		if err != nil {
+			return TimeRange{}, fmt.Errorf("%w: %v", ErrInvalidInput, err)
+		}
+		return TimeRange{Start: period.Start, End: period.End}, nil
+	}
+	match := financialPeriodPattern.FindStringSubmatch(label)
+	if match == nil {
+		return TimeRange{}, fmt.Errorf("%w: invalid financial period %q", ErrInvalidInput, label)
+	}
+	year, _ := strconv.Atoi(match[1])
+	month, months := 1, 12
+	switch {
+	case match[2] != "":
+		quarter, _ := strconv.Atoi(match[2])
+		month, months = 3*(quarter-1)+1, 3
+	case match[3] != "":
+		month, _ = strconv.Atoi(match[3])
+		months = 1
+	}
+	start := time.Date(year, time.Month(month), 1, 0, 0, 0, 0, time.UTC)
+	return TimeRange{Start: start, End: start.AddDate(0, months, 0)}, nil
+}
@@ -0,0 +1,53 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/KalebAsratemedhi/stock-dashboard/backend/internal/fiscal"
+)
+
+func TestParseFinancialPeriod(t *testing.T) {
+	calendar, err := fiscal.NewCalendar(time.July, "calendar", time.Monday)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		label   string
+		start   time.Time
+		end     time.Time
+		wantErr bool
+	}{
+		{"2026", date(2026, time.January, 1), date(2027, time.January, 1), false},
+		{"2026-Q3", date(2026, time.July, 1), date(2026, time.October, 1), false},
+		{"2026-Q4", date(2026, time.October, 1), date(2027, time.January, 1), false},
+		{"2026-07", date(2026, time.July, 1), date(2026, time.August, 1), false},
+		{"2026-12", date(2026, time.December, 1), date(2027, time.January, 1), false},
+		{"FY2026", date(2025, time.July, 1), date(2026, time.July, 1), false},
+		{"FY2026-Q3", date(2026, time.January, 1), date(2026, time.April, 1), false},
+		{"FY2026-M01", date(2025, time.July, 1), date(2025, time.August, 1), false},
+		{"2026-13", time.Time{}, time.Time{}, true},
+		{"2026-Q0", time.Time{}, time.Time{}, true},
+		{"2026-7", time.Time{}, time.Time{}, true},
+		{"FY2026-Q9", time.Time{}, time.Time{}, true},
+		{"last-month", time.Time{}, time.Time{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.label, func(t *testing.T) {
+			got, err := ParseFinancialPeriod(calendar, tt.label)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidInput) {
+					t.Errorf("ParseFinancialPeriod(%q) error = %v, want ErrInvalidInput", tt.label, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseFinancialPeriod(%q): %v", tt.label, err)
+			}
+			if !got.Start.Equal(tt.start) || !got.End.Equal(tt.end) {
+				t.Errorf("ParseFinancialPeriod(%q) = [%s, %s), want [%s, %s)", tt.label, got.Start, got.End, tt.start, tt.end)
+			}
+		})
+	}
+}
@@ -0,0 +1,63 @@
+package services
+
+import (
+	"github.com/KalebAsratemedhi/stock-dashboard/backend/internal/geo"
+	"github.com/KalebAsratemedhi/stock-dashboard/backend/internal/repository"
+)
+
+// EventGeoJSON renders a country-level event geo breakdown as GeoJSON, at
+// the bundled country centroids. Cities have no bundled coordinates, so the
+// city level is only served as JSON rows.
+func EventGeoJSON(rows []repository.GeoRow) *geo.FeatureCollection {
+	fc := geo.NewFeatureCollection()
+	for _, row := range rows {
+		properties := map[string]interface{}{
+			"name":      row.Location,
+			"events":    row.Events,
+			"users":     row.Users,
+			"sessions":  row.Sessions,
+			"purchases": row.Purchases,
+		}
+		c, ok := geo.LookupCountry(row.Location)
+		if !ok {
+			fc.Add(row.Location, 0, 0, false, properties)
+			continue
+		}
+		properties["name"] = c.Name
+		properties["region"] = c.Region
+		fc.Add(c.Code, c.Lat, c.Lon, true, properties)
+	}
+	return fc
+}
+
+// SalesGeoJSON renders a sales geo breakdown as GeoJSON, at region
+// centroids or, when drilling into a region, at country centroids.
+func SalesGeoJSON(rows []repository.SalesGeoRow, region string) *geo.FeatureCollection {
+	fc := geo.NewFeatureCollection()
+	for _, row := range rows {
+		properties := map[string]interface{}{
+			"name":      row.Location,
+			"revenue":   row.Revenue,
+			"orders":    row.Orders,
+			"quantity":  row.Quantity,
+			"customers": row.Customers,
+		}
+		if region == "" {
+			if r, ok := geo.LookupRegion(row.Location); ok {
+				fc.Add(r.Name, r.Lat, r.Lon, true, properties)
+			} else {
+				fc.Add(row.Location, 0, 0, false, properties)
+			}
+			continue
+		}
+		properties["region"] = region
+		c, ok := geo.LookupCountry(row.Location)
+		if !ok {
+			fc.Add(row.Location, 0, 0, false, properties)
+			continue
+		}
+		properties["name"] = c.Name
+		fc.Add(c.Code, c.Lat, c.Lon, true, properties)
+	}
+	return fc
+}
@@ -0,0 +1,322 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/KalebAsratemedhi/stock-dashboard/backend/internal/fiscal"
+	"github.com/KalebAsratemedhi/stock-dashboard/backend/internal/models"
+	"github.com/KalebAsratemedhi/stock-dashboard/backend/internal/repository"
+)
+
+const (
+	defaultStatementRowLimit = 100
+	maxStatementRowLimit     = 1000
+)
+
+// CostOfRevenueCategories are the expense categories reported as cost of
+// revenue, above gross profit. Other expense categories are operating
+// expenses.
+var CostOfRevenueCategories = []string{"Infrastructure", "Operations"}
+
+// Statement line kinds. Sections hold category lines and total them;
+// subtotals and ratios are derived from sections.
+const (
+	StatementSection  = "section"
+	StatementLineItem = "line"
+	StatementSubtotal = "subtotal"
+	StatementRatio    = "ratio"
+)
+
+// StatementQuery selects the range of an income statement. Period labels the
+// range; when it is a fiscal period the prior range is the previous or same
+// fiscal period rather than a calendar shift. Compare defaults to the
+// previous period and BudgetVersion to the revised budget.
+type StatementQuery struct {
+	Period        string
+	Start         time.Time
+	End           time.Time
+	Department    string
+	Compare       CompareMode
+	BudgetVersion string
+}
+
+// StatementAmounts are the columns of a statement line. Variances are actual
+// minus budget or prior; their percentages are nil when the base is zero.
+// On ratio lines every value is a percentage and variances are in points.
+type StatementAmounts struct {
+	Actual            float64  `json:"actual"`
+	Budget            float64  `json:"budget"`
+	BudgetVariance    float64  `json:"budget_variance"`
+	BudgetVariancePct *float64 `json:"budget_variance_pct"`
+	Prior             float64  `json:"prior"`
+	PriorChange       float64  `json:"prior_change"`
+	PriorChangePct    *float64 `json:"prior_change_pct"`
+}
+
+// StatementLine is a row of the income statement. Line items have an ID of
+// the form metric_type:category, which GetStatementRows drills into.
+type StatementLine struct {
+	ID    string `json:"id"`
+	Label string `json:"label"`
+	Kind  string `json:"kind"`
+	Level int    `json:"level"`
+	StatementAmounts
+	Lines []*StatementLine `json:"lines,omitempty"`
+}
+
+// IncomeStatement is a profit and loss statement built from financial
+// metrics: revenue, cost of revenue, gross profit, operating expenses and
+// operating profit, with gross and operating margins.
+type IncomeStatement struct {
+	Period        string           `json:"period,omitempty"`
+	Range         TimeRange        `json:"range"`
+	PriorRange    TimeRange        `json:"prior_range"`
+	Compare       CompareMode      `json:"compare"`
+	Department    string           `json:"department,omitempty"`
+	BudgetVersion string           `json:"budget_version"`
+	Lines         []*StatementLine `json:"lines"`
+}
+
+// Flatten returns the statement lines depth-first, as printed.
+func (s *IncomeStatement) Flatten() []*StatementLine {
+	var lines []*StatementLine
+	var walk func([]*StatementLine)
+	walk = func(children []*StatementLine) {
+		for _, line := range children {
+			lines = append(lines, line)
+			walk(line.Lines)
+		}
+	}
+	walk(s.Lines)
+	return lines
+}
+
+type IncomeStatementService interface {
+	GetIncomeStatement(query StatementQuery) (*IncomeStatement, error)
+	GetStatementRows(query StatementQuery, lineID string, limit int) ([]*models.FinancialMetric, error)
+}
+
+type incomeStatementService struct {
+	repo     repository.FinancialMetricRepository
+	calendar *fiscal.Calendar
+}
+
+func NewIncomeStatementService(repo repository.FinancialMetricRepository, calendar *fiscal.Calendar) IncomeStatementService {
+	return &incomeStatementService{repo: repo, calendar: calendar}
+}
+
+// statementKey identifies a line item.
+type statementKey struct {
+	metricType string
+	category   string
+}
+
+// statementValues are the raw column values of a line item.
+type statementValues struct {
+	actual, budget, prior float64
+}
+
+func (s *incomeStatementService) GetIncomeStatement(query StatementQuery) (*IncomeStatement, error) {
+	query, err := normalizeStatementQuery(query)
+	if err != nil {
+		return nil, err
+	}
+	prior := s.priorRange(query)
+
+	values := make(map[statementKey]*statementValues)
+	add := func(rows []repository.CategoryTotalRow, column func(*statementValues) *float64) {
+		for _, row := range rows {
+			key := statementKey{row.MetricType, row.Category}
+			if values[key] == nil {
+				values[key] = &statementValues{}
+			}
+			*column(values[key]) += row.Amount
+		}
+	}
+
+	actuals, err := s.repo.GetCategoryTotals(query.Start, query.End, query.Department)
+	if err != nil {
+		return nil, err
+	}
+	add(actuals, func(v *statementValues) *float64 { return &v.actual })
+	priors, err := s.repo.GetCategoryTotals(prior.Start, prior.End, query.Department)
+	if err != nil {
+		return nil, err
+	}
+	add(priors, func(v *statementValues) *float64 { return &v.prior })
+	lines, err := s.repo.GetBudgetLines(query.Start, query.End, query.BudgetVersion)
+	if err != nil {
+		return nil, err
+	}
+	add(budgetCategoryTotals(lines, query.Start, query.End, query.Department), func(v *statementValues) *float64 { return &v.budget })
+
+	revenue := statementSection("revenue", "Revenue", values, func(key statementKey) bool {
+		return key.metricType == "revenue"
+	})
+	costOfRevenue := statementSection("cost_of_revenue", "Cost of revenue", values, func(key statementKey) bool {
+		return key.metricType == "expense" && containsString(CostOfRevenueCategories, key.category)
+	})
+	operatingExpenses := statementSection("operating_expenses", "Operating expenses", values, func(key statementKey) bool {
+		return key.metricType == "expense" && !containsString(CostOfRevenueCategories, key.category)
+	})
+	grossProfit := statementSubtotal("gross_profit", "Gross profit", revenue, costOfRevenue)
+	operatingProfit := statementSubtotal("operating_profit", "Operating profit", grossProfit, operatingExpenses)
+
+	return &IncomeStatement{
+		Period:        query.Period,
+		Range:         TimeRange{Start: query.Start, End: query.End},
+		PriorRange:    prior,
+		Compare:       query.Compare,
+		Department:    query.Department,
+		BudgetVersion: query.BudgetVersion,
+		Lines: []*StatementLine{
+			revenue,
+			costOfRevenue,
+			grossProfit,
+			statementRatio("gross_margin", "Gross margin %", grossProfit, revenue),
+			operatingExpenses,
+			operatingProfit,
+			statementRatio("operating_margin", "Operating margin %", operatingProfit, revenue),
+		},
+	}, nil
+}
+
+// GetStatementRows returns the metrics behind a line item, largest first.
+func (s *incomeStatementService) GetStatementRows(query StatementQuery, lineID string, limit int) ([]*models.FinancialMetric, error) {
+	query, err := normalizeStatementQuery(query)
+	if err != nil {
+		return nil, err
+	}
+	metricType, category, ok := strings.Cut(lineID, ":")
+	if !ok || (metricType != "revenue" && metricType != "expense") {
+		return nil, fmt.Errorf("%w: %q is not a statement line item", ErrInvalidInput, lineID)
+	}
+	if limit <= 0 {
+		limit = defaultStatementRowLimit
+	}
+	if limit > maxStatementRowLimit {
+		limit = maxStatementRowLimit
+	}
+	return s.repo.GetContributingMetrics(query.Start, query.End, metricType, category, query.Department, limit)
+}
+
+// priorRange returns the comparison range of a statement. Fiscal months and
+// weeks vary in length, so fiscal periods compare with the preceding period
+// or the same period of the previous fiscal year.
+func (s *incomeStatementService) priorRange(query StatementQuery) TimeRange {
+	if fiscal.IsLabel(query.Period) {
+		if period, err := s.calendar.Parse(query.Period); err == nil {
+			prior := s.calendar.Shift(period, -1)
+			if query.Compare == ComparePreviousYear {
+				prior = s.calendar.YearAgo(period)
+			}
+			return TimeRange{Start: prior.Start, End: prior.End.Add(-time.Microsecond)}
+		}
+	}
+	return ComparisonRange(query.Start, query.End, query.Compare)
+}
+
+func normalizeStatementQuery(query StatementQuery) (StatementQuery, error) {
+	if query.Start.IsZero() || query.End.Before(query.Start) {
+		return query, ErrInvalidInput
+	}
+	if query.Compare == "" {
+		query.Compare = ComparePreviousPeriod
+	}
+	if query.BudgetVersion == "" {
+		query.BudgetVersion = "revised"
+	}
+	if !containsString(BudgetVersions, query.BudgetVersion) {
+		return query, ErrInvalidInput
+	}
+	return query, nil
+}
+
+// statementSection builds a section with one line item per matching
+// category, ordered by category, and their total.
+func statementSection(id, label string, values map[statementKey]*statementValues, match func(statementKey) bool) *StatementLine {
+	var keys []statementKey
+	for key := range values {
+		if match(key) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].category < keys[j].category })
+
+	section := &StatementLine{ID: id, Label: label, Kind: StatementSection, Lines: []*StatementLine{}}
+	var total statementValues
+	for _, key := range keys {
+		v := values[key]
+		total.actual += v.actual
+		total.budget += v.budget
+		total.prior += v.prior
+		itemLabel := key.category
+		if itemLabel == "" {
+			itemLabel = "Unallocated"
+		}
+		section.Lines = append(section.Lines, &StatementLine{
+			ID:               key.metricType + ":" + key.category,
+			Label:            itemLabel,
+			Kind:             StatementLineItem,
+			Level:            1,
+			StatementAmounts: newStatementAmounts(*v),
+		})
+	}
+	section.StatementAmounts = newStatementAmounts(total)
+	return section
+}
+
+// statementSubtotal is minuend less subtrahend, column by column.
+func statementSubtotal(id, label string, minuend, subtrahend *StatementLine) *StatementLine {
+	return &StatementLine{
+		ID:    id,
+		Label: label,
+		Kind:  StatementSubtotal,
+		StatementAmounts: newStatementAmounts(statementValues{
+			actual: minuend.Actual - subtrahend.Actual,
+			budget: minuend.Budget - subtrahend.Budget,
+			prior:  minuend.Prior - subtrahend.Prior,
+		}),
+	}
+}
+
+// statementRatio is numerator as a percentage of denominator, column by
+// column, with variances in percentage points.
+func statementRatio(id, label string, numerator, denominator *StatementLine) *StatementLine {
+	pct := func(n, d float64) float64 {
+		if d == 0 {
+			return 0
+		}
+		return n / d * 100
+	}
+	line := &StatementLine{
+		ID:    id,
+		Label: label,
+		Kind:  StatementRatio,
+		StatementAmounts: StatementAmounts{
+			Actual: pct(numerator.Actual, denominator.Actual),
+			Budget: pct(numerator.Budget, denominator.Budget),
+			Prior:  pct(numerator.Prior, denominator.Prior),
+		},
+	}
+	line.BudgetVariance = line.Actual - line.Budget
+	line.PriorChange = line.Actual - line.Prior
+	return line
+}
+
+func newStatementAmounts(v statementValues) StatementAmounts {
+	budget := NewValueComparison(v.actual, v.budget)
+	prior := NewValueComparison(v.actual, v.prior)
+	return StatementAmounts{
+		Actual:            v.actual,
+		Budget:            v.budget,
+		BudgetVariance:    budget.Delta,
+		BudgetVariancePct: budget.DeltaPct,
+		Prior:             v.prior,
+		PriorChange:       prior.Delta,
+		PriorChangePct:    prior.DeltaPct,
+	}
+}
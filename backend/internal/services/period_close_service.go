@@ -0,0 +1,207 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/KalebAsratemedhi/stock-dashboard/backend/internal/fiscal"
+	"github.com/KalebAsratemedhi/stock-dashboard/backend/internal/models"
+	"github.com/KalebAsratemedhi/stock-dashboard/backend/internal/repository"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultAdjustmentLimit = 100
+	maxAdjustmentLimit     = 1000
+)
+
+// PeriodCloseInput names the period to close or reopen, who is doing it and
+// why. Period is any label ParseFinancialPeriod accepts.
+type PeriodCloseInput struct {
+	Period string `json:"period"`
+	Actor  string `json:"actor"`
+	Reason string `json:"reason"`
+}
+
+// AdjustmentQuery selects the adjustments of an adjustments report by the
+// timestamp of their metrics.
+type AdjustmentQuery struct {
+	Start      time.Time
+	End        time.Time
+	Department string
+	Limit      int
+}
+
+// AdjustmentReport lists the metrics that arrived for closed periods, with
+// totals per period and metric type over every matching adjustment; the
+// list itself is capped by the query limit.
+type AdjustmentReport struct {
+	Range       TimeRange                       `json:"range"`
+	Department  string                          `json:"department,omitempty"`
+	Totals      []repository.AdjustmentTotalRow `json:"totals"`
+	Adjustments []*models.FinancialAdjustment   `json:"adjustments"`
+}
+
+// PeriodCloseService closes and reopens financial periods and records the
+// metrics that arrive for closed periods as adjustments. The lock itself is
+// enforced by FinancialMetricService.
+type PeriodCloseService interface {
+	ClosePeriod(input PeriodCloseInput) (*models.PeriodClose, error)
+	ReopenPeriod(input PeriodCloseInput) (*models.PeriodClose, error)
+	ListCloses(period string) ([]*models.PeriodClose, error)
+	RecordAdjustment(metric *models.FinancialMetric) (*models.FinancialAdjustment, error)
+	GetAdjustmentReport(query AdjustmentQuery) (*AdjustmentReport, error)
+}
+
+type periodCloseService struct {
+	repo     repository.PeriodCloseRepository
+	calendar *fiscal.Calendar
+}
+
+func NewPeriodCloseService(repo repository.PeriodCloseRepository, calendar *fiscal.Calendar) PeriodCloseService {
+	return &periodCloseService{repo: repo, calendar: calendar}
+}
+
+// ClosePeriod locks a period against new metrics. A period can only be
+// closed once until it is reopened, and not while a period overlapping it,
+// such as its quarter or the fiscal label of the same month, is closed;
+// otherwise reopening one label would leave its range locked by the other.
+func (s *periodCloseService) ClosePeriod(input PeriodCloseInput) (*models.PeriodClose, error) {
+	if err := validatePeriodCloseInput(input); err != nil {
+		return nil, err
+	}
+	period, err := ParseFinancialPeriod(s.calendar, input.Period)
+	if err != nil {
+		return nil, err
+	}
+
+	periodClose := &models.PeriodClose{
+		Period:      input.Period,
+		PeriodStart: period.Start,
+		PeriodEnd:   period.End,
+		ClosedBy:    input.Actor,
+		CloseReason: input.Reason,
+		ClosedAt:    time.Now(),
+	}
+	err = s.repo.CreateExclusive(periodClose, func(overlapping []*models.PeriodClose) error {
+		return checkOverlap(input.Period, overlapping)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return periodClose, nil
+}
+
+// checkOverlap rejects closing period while any overlapping close is in
+// force.
+func checkOverlap(period string, overlapping []*models.PeriodClose) error {
+	for _, periodClose := range overlapping {
+		if periodClose.Period == period {
+			return fmt.Errorf("%w: %s is already closed", ErrInvalidInput, period)
+		}
+	}
+	if len(overlapping) > 0 {
+		return fmt.Errorf("%w: %s overlaps %s, which is closed", ErrInvalidInput, period, overlapping[0].Period)
+	}
+	return nil
+}
+
+// ReopenPeriod lifts the close of a period. Adjustments recorded while it
+// was closed are kept for review rather than posted.
+func (s *periodCloseService) ReopenPeriod(input PeriodCloseInput) (*models.PeriodClose, error) {
+	if err := validatePeriodCloseInput(input); err != nil {
+		return nil, err
+	}
+	periodClose, err := s.repo.GetOpen(input.Period)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("%w: %s is not closed", ErrNotFound, input.Period)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	periodClose.ReopenedBy = &input.Actor
+	periodClose.ReopenReason = &input.Reason
+	periodClose.ReopenedAt = &now
+	if err := s.repo.Update(periodClose); err != nil {
+		return nil, err
+	}
+	return periodClose, nil
+}
+
+func (s *periodCloseService) ListCloses(period string) ([]*models.PeriodClose, error) {
+	return s.repo.List(period)
+}
+
+// RecordAdjustment keeps a metric that arrived for a closed period. It is
+// filed under the close in force at its timestamp, or under its fiscal month
+// if the period has been reopened since.
+func (s *periodCloseService) RecordAdjustment(metric *models.FinancialMetric) (*models.FinancialAdjustment, error) {
+	if metric.MetricType == "" || metric.Timestamp.IsZero() {
+		return nil, ErrInvalidInput
+	}
+	closes, err := s.repo.FindClosed(metric.Timestamp, metric.Timestamp)
+	if err != nil {
+		return nil, err
+	}
+
+	adjustment := &models.FinancialAdjustment{
+		Period:     s.calendar.PeriodOf(metric.Timestamp, fiscal.Month).Label,
+		Timestamp:  metric.Timestamp,
+		MetricType: metric.MetricType,
+		Department: metric.Department,
+		Category:   metric.Category,
+		Amount:     metric.Amount,
+		Metric:     metric,
+	}
+	if len(closes) > 0 {
+		adjustment.PeriodCloseID = &closes[0].ID
+		adjustment.Period = closes[0].Period
+	}
+	if err := s.repo.CreateAdjustment(adjustment); err != nil {
+		return nil, err
+	}
+	return adjustment, nil
+}
+
+func (s *periodCloseService) GetAdjustmentReport(query AdjustmentQuery) (*AdjustmentReport, error) {
+	if query.Start.IsZero() || query.End.Before(query.Start) {
+		return nil, ErrInvalidInput
+	}
+	if query.Limit <= 0 {
+		query.Limit = defaultAdjustmentLimit
+	}
+	if query.Limit > maxAdjustmentLimit {
+		query.Limit = maxAdjustmentLimit
+	}
+
+	totals, err := s.repo.GetAdjustmentTotals(query.Start, query.End, query.Department)
+	if err != nil {
+		return nil, err
+	}
+	adjustments, err := s.repo.ListAdjustments(query.Start, query.End, query.Department, query.Limit)
+	if err != nil {
+		return nil, err
+	}
+	return &AdjustmentReport{
+		Range:       TimeRange{Start: query.Start, End: query.End},
+		Department:  query.Department,
+		Totals:      totals,
+		Adjustments: adjustments,
+	}, nil
+}
+
+func validatePeriodCloseInput(input PeriodCloseInput) error {
+	if input.Period == "" {
+		return ErrInvalidInput
+	}
+	if input.Actor == "" || len(input.Actor) > 255 {
+		return fmt.Errorf("%w: actor is required", ErrInvalidInput)
+	}
+	if input.Reason == "" {
+		return fmt.Errorf("%w: reason is required", ErrInvalidInput)
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/KalebAsratemedhi/stock-dashboard/backend/internal/fiscal"
+	"github.com/KalebAsratemedhi/stock-dashboard/backend/internal/models"
+	"github.com/KalebAsratemedhi/stock-dashboard/backend/internal/repository"
+)
+
+// fakePeriodCloseRepository keeps closes in memory and finds overlapping
+// ones the way the SQL does: half-open ranges of closes not reopened.
+type fakePeriodCloseRepository struct {
+	repository.PeriodCloseRepository
+	closes []*models.PeriodClose
+}
+
+func (r *fakePeriodCloseRepository) CreateExclusive(periodClose *models.PeriodClose, check func(overlapping []*models.PeriodClose) error) error {
+	var overlapping []*models.PeriodClose
+	for _, existing := range r.closes {
+		if existing.ReopenedAt == nil && existing.PeriodStart.Before(periodClose.PeriodEnd) && existing.PeriodEnd.After(periodClose.PeriodStart) {
+			overlapping = append(overlapping, existing)
+		}
+	}
+	if err := check(overlapping); err != nil {
+		return err
+	}
+	r.closes = append(r.closes, periodClose)
+	return nil
+}
+
+func TestClosePeriodOverlap(t *testing.T) {
+	calendar, err := fiscal.NewCalendar(time.July, "calendar", time.Monday)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reopened := date(2026, time.February, 1)
+	tests := []struct {
+		name    string
+		closed  []string
+		period  string
+		wantErr string
+	}{
+		{"nothing closed", nil, "2026-03", ""},
+		{"same period", []string{"2026-03"}, "2026-03", "2026-03 is already closed"},
+		{"month inside a closed quarter", []string{"2026-Q1"}, "2026-02", "2026-02 overlaps 2026-Q1, which is closed"},
+		{"quarter around a closed month", []string{"2026-02"}, "2026-Q1", "2026-Q1 overlaps 2026-02, which is closed"},
+		{"fiscal label of a closed month", []string{"2026-01"}, "FY2026-M07", "FY2026-M07 overlaps 2026-01, which is closed"},
+		{"fiscal year across calendar years", []string{"2025-12"}, "FY2026", "FY2026 overlaps 2025-12, which is closed"},
+		{"adjacent months", []string{"2026-02", "2026-04"}, "2026-03", ""},
+		{"adjacent fiscal quarter", []string{"2026-Q1"}, "FY2026-Q4", ""},
+		{"reopened period", []string{"reopened:2026-03"}, "2026-03", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakePeriodCloseRepository{}
+			for _, label := range tt.closed {
+				periodClose := &models.PeriodClose{Period: strings.TrimPrefix(label, "reopened:")}
+				period, err := ParseFinancialPeriod(calendar, periodClose.Period)
+				if err != nil {
+					t.Fatal(err)
+				}
+				periodClose.PeriodStart, periodClose.PeriodEnd = period.Start, period.End
+				if strings.HasPrefix(label, "reopened:") {
+					periodClose.ReopenedAt = &reopened
+				}
+				repo.closes = append(repo.closes, periodClose)
+			}
+
+			input := PeriodCloseInput{Period: tt.period, Actor: "controller", Reason: "month end"}
+			got, err := NewPeriodCloseService(repo, calendar).ClosePeriod(input)
+			if tt.wantErr != "" {
+				if !errors.Is(err, ErrInvalidInput) || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("ClosePeriod(%q) error = %v, want %q", tt.period, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ClosePeriod(%q): %v", tt.period, err)
+			}
+			period, _ := ParseFinancialPeriod(calendar, tt.period)
+			if got.Period != tt.period || !got.PeriodStart.Equal(period.Start) || !got.PeriodEnd.Equal(period.End) {
+				t.Errorf("ClosePeriod(%q) = %s [%s, %s)", tt.period, got.Period, got.PeriodStart, got.PeriodEnd)
+			}
+		})
+	}
+}
+
+func TestCheckOpen(t *testing.T) {
+	closes := []*models.PeriodClose{{
+		Period:      "2026-03",
+		PeriodStart: date(2026, time.March, 1),
+		PeriodEnd:   date(2026, time.April, 1),
+		ClosedBy:    "controller",
+	}}
+	metric := func(at time.Time) *models.FinancialMetric {
+		return &models.FinancialMetric{Timestamp: at}
+	}
+	tests := []struct {
+		name    string
+		metrics []*models.FinancialMetric
+		closes  []*models.PeriodClose
+		closed  bool
+	}{
+		{"no closes", []*models.FinancialMetric{metric(date(2026, time.March, 15))}, nil, false},
+		{"inside the period", []*models.FinancialMetric{metric(date(2026, time.March, 15))}, closes, true},
+		{"at the start", []*models.FinancialMetric{metric(date(2026, time.March, 1))}, closes, true},
+		{"at the end", []*models.FinancialMetric{metric(date(2026, time.April, 1))}, closes, false},
+		{"just before the start", []*models.FinancialMetric{metric(date(2026, time.March, 1).Add(-time.Nanosecond))}, closes, false},
+		{"one of a batch", []*models.FinancialMetric{
+			metric(date(2026, time.April, 2)),
+			metric(date(2026, time.March, 31)),
+		}, closes, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkOpen(tt.metrics, tt.closes)
+			if errors.Is(err, ErrPeriodClosed) != tt.closed {
+				t.Errorf("checkOpen() error = %v, want closed %v", err, tt.closed)
+			}
+		})
+	}
+}
@@ -0,0 +1,257 @@
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/KalebAsratemedhi/stock-dashboard/backend/internal/enrich"
+	"github.com/KalebAsratemedhi/stock-dashboard/backend/internal/models"
+	"github.com/KalebAsratemedhi/stock-dashboard/backend/internal/repository"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultPrivacyRequestLimit = 100
+	maxPrivacyRequestLimit     = 1000
+	erasureBatchSize           = 20
+)
+
+// PrivacySubjectTypes are the kinds of ID a privacy request may name.
+var PrivacySubjectTypes = []string{"user", "customer"}
+
+// PrivacyRequestInput names the data subject of a privacy request and who
+// asked for it. SubjectID is the raw user or customer ID, before
+// pseudonymization.
+type PrivacyRequestInput struct {
+	SubjectType string `json:"subject_type"`
+	SubjectID   string `json:"subject_id"`
+	RequestedBy string `json:"requested_by"`
+	Reason      string `json:"reason"`
+}
+
+// PrivacyExport is the response of Export: the audit record and every row
+// held about the subject.
+type PrivacyExport struct {
+	Request *models.PrivacyRequest  `json:"request"`
+	Data    *repository.SubjectData `json:"data"`
+}
+
+// PrivacyService exports and erases the data held about a user or customer
+// and pseudonymizes user and customer IDs at ingest. Every request is audited
+// in privacy_requests. In Redis, only the realtime sets hold IDs that can be
+// tied to a subject, and erasures remove them.
+type PrivacyService interface {
+	PseudonymizeEvent(event *models.UserEvent)
+	PseudonymizeSale(sale *models.Sale)
+	PseudonymizeID(id string) string
+	Export(input PrivacyRequestInput) (*PrivacyExport, error)
+	RequestErasure(input PrivacyRequestInput) (*models.PrivacyRequest, error)
+	ProcessErasures() (int, error)
+	GetRequestByID(id uint) (*models.PrivacyRequest, error)
+	ListRequests(kind, status string, limit int) ([]*models.PrivacyRequest, error)
+}
+
+type privacyService struct {
+	repo          repository.PrivacyRepository
+	pseudonymizer *enrich.Pseudonymizer
+	realtime      RealtimeService
+}
+
+// NewPrivacyService creates the service. realtime is used to forget erased
+// subjects' recent activity.
+func NewPrivacyService(repo repository.PrivacyRepository, pseudonymizer *enrich.Pseudonymizer, realtime RealtimeService) PrivacyService {
+	return &privacyService{repo: repo, pseudonymizer: pseudonymizer, realtime: realtime}
+}
+
+// PseudonymizeEvent replaces the event's user ID and the customer_id in its
+// metadata with their pseudonyms under the current key. Metadata that cannot
+// be parsed is left alone; ingestion validates it.
+func (s *privacyService) PseudonymizeEvent(event *models.UserEvent) {
+	event.UserID = s.PseudonymizeID(event.UserID)
+
+	var metadata map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(event.Metadata), &metadata); err != nil {
+		return
+	}
+	var customerID string
+	if err := json.Unmarshal(metadata["customer_id"], &customerID); err != nil || customerID == "" {
+		return
+	}
+	pseudonym, _ := json.Marshal(s.PseudonymizeID(customerID))
+	metadata["customer_id"] = pseudonym
+	if encoded, err := json.Marshal(metadata); err == nil {
+		event.Metadata = string(encoded)
+	}
+}
+
+// PseudonymizeSale replaces the sale's customer ID with its pseudonym, the
+// same one events carry in their metadata.
+func (s *privacyService) PseudonymizeSale(sale *models.Sale) {
+	sale.CustomerID = s.PseudonymizeID(sale.CustomerID)
+}
+
+// PseudonymizeID returns the form a user or customer ID is stored under, for
+// inputs that reference them outside of events and sales.
+func (s *privacyService) PseudonymizeID(id string) string {
+	return s.pseudonymizer.Pseudonymize(id)
+}
+
+func (s *privacyService) Export(input PrivacyRequestInput) (*PrivacyExport, error) {
+	request, err := newPrivacyRequest("export", input)
+	if err != nil {
+		return nil, err
+	}
+	subject, err := s.resolve(input.SubjectType, input.SubjectID)
+	if err != nil {
+		return nil, err
+	}
+	data, err := s.repo.ExportSubject(subject)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	request.Status = "completed"
+	request.CompletedAt = &now
+	request.Result = map[string]int64{
+		"user_events":         int64(len(data.UserEvents)),
+		"user_sessions":       int64(len(data.Sessions)),
+		"customer_identities": int64(len(data.Identities)),
+		"sales":               int64(len(data.Sales)),
+	}
+	if err := s.repo.CreateRequest(request); err != nil {
+		return nil, err
+	}
+	return &PrivacyExport{Request: request, Data: data}, nil
+}
+
+// RequestErasure queues an erasure for ProcessErasures. The subject ID is
+// kept sealed under the pseudonym key until the erasure has run.
+func (s *privacyService) RequestErasure(input PrivacyRequestInput) (*models.PrivacyRequest, error) {
+	request, err := newPrivacyRequest("erasure", input)
+	if err != nil {
+		return nil, err
+	}
+	subject, err := s.pseudonymizer.Seal(input.SubjectID)
+	if err != nil {
+		return nil, err
+	}
+	request.Subject = &subject
+	if err := s.repo.CreateRequest(request); err != nil {
+		return nil, err
+	}
+	return request, nil
+}
+
+// ProcessErasures runs pending erasures in request order and returns how many
+// completed. Each request is marked completed or failed and its sealed
+// subject ID is cleared either way.
+func (s *privacyService) ProcessErasures() (int, error) {
+	requests, err := s.repo.ListPendingErasures(erasureBatchSize)
+	if err != nil {
+		return 0, err
+	}
+	completed := 0
+	for _, request := range requests {
+		counts, err := s.erase(request)
+		now := time.Now()
+		request.Subject = nil
+		request.CompletedAt = &now
+		if err != nil {
+			log.Printf("Error erasing data for privacy request %d: %v", request.ID, err)
+			request.Status = "failed"
+			request.Error = err.Error()
+		} else {
+			request.Status = "completed"
+			request.Result = counts
+			completed++
+		}
+		if err := s.repo.UpdateRequest(request); err != nil {
+			return completed, err
+		}
+	}
+	return completed, nil
+}
+
+// erase deletes the subject's rows and forgets their realtime activity
+// first, so that a failure never leaves them on the live dashboard.
+func (s *privacyService) erase(request *models.PrivacyRequest) (map[string]int64, error) {
+	if request.Subject == nil {
+		return nil, errors.New("subject ID is no longer available")
+	}
+	id, err := s.pseudonymizer.Open(*request.Subject)
+	if err != nil {
+		return nil, fmt.Errorf("subject ID cannot be unsealed: %w", err)
+	}
+	subject, err := s.resolve(request.SubjectType, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.realtime.Forget(subject.UserIDs, subject.SessionIDs); err != nil {
+		return nil, err
+	}
+	return s.repo.EraseSubject(subject)
+}
+
+func (s *privacyService) GetRequestByID(id uint) (*models.PrivacyRequest, error) {
+	request, err := s.repo.GetRequestByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return request, nil
+}
+
+func (s *privacyService) ListRequests(kind, status string, limit int) ([]*models.PrivacyRequest, error) {
+	if kind != "" && kind != "export" && kind != "erasure" {
+		return nil, ErrInvalidInput
+	}
+	if status != "" && status != "pending" && status != "completed" && status != "failed" {
+		return nil, ErrInvalidInput
+	}
+	if limit <= 0 {
+		limit = defaultPrivacyRequestLimit
+	}
+	if limit > maxPrivacyRequestLimit {
+		limit = maxPrivacyRequestLimit
+	}
+	return s.repo.ListRequests(kind, status, limit)
+}
+
+// resolve finds every stored ID of the subject. IDs are looked up in their
+// raw form and under each pseudonym key, since rows may predate
+// pseudonymization or a key rotation.
+func (s *privacyService) resolve(subjectType, id string) (*repository.PrivacySubject, error) {
+	if subjectType == "user" {
+		return s.repo.ResolveSubject(s.pseudonymizer.Candidates(id), nil)
+	}
+	return s.repo.ResolveSubject(nil, s.pseudonymizer.Candidates(id))
+}
+
+func newPrivacyRequest(kind string, input PrivacyRequestInput) (*models.PrivacyRequest, error) {
+	if !containsString(PrivacySubjectTypes, input.SubjectType) {
+		return nil, ErrInvalidInput
+	}
+	if input.SubjectID == "" || len(input.SubjectID) > 50 {
+		return nil, ErrInvalidInput
+	}
+	if input.RequestedBy == "" || len(input.RequestedBy) > 255 {
+		return nil, ErrInvalidInput
+	}
+	hash := sha256.Sum256([]byte(input.SubjectID))
+	return &models.PrivacyRequest{
+		Kind:        kind,
+		SubjectType: input.SubjectType,
+		SubjectHash: hex.EncodeToString(hash[:]),
+		RequestedBy: input.RequestedBy,
+		Reason:      input.Reason,
+		Status:      "pending",
+	}, nil
+}
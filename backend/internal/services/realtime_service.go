@@ -0,0 +1,258 @@
+package services
+
+import (
+	"encoding/json"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/KalebAsratemedhi/stock-dashboard/backend/internal/models"
+	"github.com/KalebAsratemedhi/stock-dashboard/backend/internal/redis"
+)
+
+const (
+	realtimeBreakdownLimit = 10
+	realtimeKeyPrefix      = "realtime:active:"
+)
+
+// RealtimeWindows are the sliding windows reported by the realtime service.
+// The longest window also bounds how long an ID stays in Redis.
+var RealtimeWindows = []struct {
+	Name     string
+	Duration time.Duration
+}{
+	{"1m", time.Minute},
+	{"5m", 5 * time.Minute},
+	{"30m", 30 * time.Minute},
+}
+
+// realtimeAttrs is the latest page, country and device seen for an ID.
+type realtimeAttrs struct {
+	Page    string `json:"page"`
+	Country string `json:"country"`
+	Device  string `json:"device"`
+}
+
+// RealtimeCount is the number of active users and sessions for one value of a
+// breakdown dimension.
+type RealtimeCount struct {
+	Value    string `json:"value"`
+	Users    int64  `json:"users"`
+	Sessions int64  `json:"sessions"`
+}
+
+// RealtimeWindow counts IDs active within the window, with the top values of
+// each dimension by active sessions.
+type RealtimeWindow struct {
+	Window    string          `json:"window"`
+	Users     int64           `json:"users"`
+	Sessions  int64           `json:"sessions"`
+	Pages     []RealtimeCount `json:"pages"`
+	Countries []RealtimeCount `json:"countries"`
+	Devices   []RealtimeCount `json:"devices"`
+}
+
+// RealtimeSnapshot is published on redis.RealtimeUsersChannel.
+type RealtimeSnapshot struct {
+	AsOf    time.Time         `json:"as_of"`
+	Windows []*RealtimeWindow `json:"windows"`
+}
+
+type RealtimeService interface {
+	RecordEvent(event *models.UserEvent) error
+	Forget(userIDs, sessionIDs []string) error
+	Snapshot(now time.Time) (*RealtimeSnapshot, error)
+}
+
+type realtimeService struct {
+	redisClient *redis.Client
+}
+
+// NewRealtimeService creates the service. redisClient may be nil, in which
+// case events are ignored and snapshots are empty.
+func NewRealtimeService(redisClient *redis.Client) RealtimeService {
+	return &realtimeService{redisClient: redisClient}
+}
+
+// RecordEvent marks the event's user and session as active at the event time.
+// Each ID is kept in a sorted set scored by last activity, which a late,
+// redelivered event never moves back, alongside a hash of its latest
+// attributes. Bot traffic is never recorded.
+func (s *realtimeService) RecordEvent(event *models.UserEvent) error {
+	if s.redisClient == nil || event.IsBot {
+		return nil
+	}
+	seen := event.Timestamp
+	if now := time.Now(); seen.After(now) {
+		seen = now
+	}
+	if time.Since(seen) > realtimeRetention() {
+		return nil
+	}
+
+	attrs, err := json.Marshal(realtimeAttrs{Page: event.Page, Country: event.Country, Device: event.Device})
+	if err != nil {
+		return err
+	}
+	for kind, id := range map[string]string{"users": event.UserID, "sessions": event.SessionID} {
+		if id == "" {
+			continue
+		}
+		if err := s.redisClient.ZAddGT(realtimeKeyPrefix+kind, float64(seen.Unix()), id); err != nil {
+			return err
+		}
+		if err := s.redisClient.HSet(realtimeKeyPrefix+kind+":attrs", id, string(attrs)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Forget removes users and sessions from the realtime sets, e.g. when their
+// data is erased.
+func (s *realtimeService) Forget(userIDs, sessionIDs []string) error {
+	if s.redisClient == nil {
+		return nil
+	}
+	for kind, ids := range map[string][]string{"users": userIDs, "sessions": sessionIDs} {
+		if len(ids) == 0 {
+			continue
+		}
+		key := realtimeKeyPrefix + kind
+		if err := s.redisClient.ZRem(key, ids...); err != nil {
+			return err
+		}
+		if err := s.redisClient.HDel(key+":attrs", ids...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Snapshot prunes IDs idle for longer than the longest window and counts the
+// remaining IDs per window and dimension.
+func (s *realtimeService) Snapshot(now time.Time) (*RealtimeSnapshot, error) {
+	snapshot := &RealtimeSnapshot{AsOf: now, Windows: make([]*RealtimeWindow, 0, len(RealtimeWindows))}
+	active := map[string]map[string]float64{}
+	attrs := map[string]map[string]realtimeAttrs{}
+	if s.redisClient != nil {
+		cutoff := now.Add(-realtimeRetention())
+		for _, kind := range []string{"users", "sessions"} {
+			if err := s.prune(kind, cutoff); err != nil {
+				return nil, err
+			}
+			scores, err := s.redisClient.ZRangeByScoreWithScores(realtimeKeyPrefix+kind, strconv.FormatInt(cutoff.Unix(), 10), "+inf")
+			if err != nil {
+				return nil, err
+			}
+			active[kind] = scores
+			attrs[kind], err = s.loadAttrs(kind, scores)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	for _, w := range RealtimeWindows {
+		since := float64(now.Add(-w.Duration).Unix())
+		window := &RealtimeWindow{Window: w.Name}
+		pages := map[string]*RealtimeCount{}
+		countries := map[string]*RealtimeCount{}
+		devices := map[string]*RealtimeCount{}
+		for kind, scores := range active {
+			for id, score := range scores {
+				if score < since {
+					continue
+				}
+				a := attrs[kind][id]
+				realtimeTally(pages, a.Page, kind)
+				realtimeTally(countries, a.Country, kind)
+				realtimeTally(devices, a.Device, kind)
+				if kind == "users" {
+					window.Users++
+				} else {
+					window.Sessions++
+				}
+			}
+		}
+		window.Pages = topRealtimeCounts(pages)
+		window.Countries = topRealtimeCounts(countries)
+		window.Devices = topRealtimeCounts(devices)
+		snapshot.Windows = append(snapshot.Windows, window)
+	}
+	return snapshot, nil
+}
+
+func (s *realtimeService) prune(kind string, cutoff time.Time) error {
+	key := realtimeKeyPrefix + kind
+	expired, err := s.redisClient.ZRangeByScore(key, "-inf", "("+strconv.FormatInt(cutoff.Unix(), 10))
+	if err != nil || len(expired) == 0 {
+		return err
+	}
+	if err := s.redisClient.ZRem(key, expired...); err != nil {
+		return err
+	}
+	return s.redisClient.HDel(key+":attrs", expired...)
+}
+
+func (s *realtimeService) loadAttrs(kind string, scores map[string]float64) (map[string]realtimeAttrs, error) {
+	ids := make([]string, 0, len(scores))
+	for id := range scores {
+		ids = append(ids, id)
+	}
+	result := make(map[string]realtimeAttrs, len(ids))
+	if len(ids) == 0 {
+		return result, nil
+	}
+	values, err := s.redisClient.HMGet(realtimeKeyPrefix+kind+":attrs", ids...)
+	if err != nil {
+		return nil, err
+	}
+	for i, value := range values {
+		var a realtimeAttrs
+		if value != "" {
+			_ = json.Unmarshal([]byte(value), &a)
+		}
+		result[ids[i]] = a
+	}
+	return result, nil
+}
+
+func realtimeRetention() time.Duration {
+	return RealtimeWindows[len(RealtimeWindows)-1].Duration
+}
+
+func realtimeTally(counts map[string]*RealtimeCount, value, kind string) {
+	c, ok := counts[value]
+	if !ok {
+		c = &RealtimeCount{Value: value}
+		counts[value] = c
+	}
+	if kind == "users" {
+		c.Users++
+	} else {
+		c.Sessions++
+	}
+}
+
+// topRealtimeCounts orders counts by sessions, then users, then value, and
+// keeps the top realtimeBreakdownLimit.
+func topRealtimeCounts(counts map[string]*RealtimeCount) []RealtimeCount {
+	result := make([]RealtimeCount, 0, len(counts))
+	for _, c := range counts {
+		result = append(result, *c)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Sessions != result[j].Sessions {
+			return result[i].Sessions > result[j].Sessions
+		}
+		if result[i].Users != result[j].Users {
+			return result[i].Users > result[j].Users
+		}
+		return result[i].Value < result[j].Value
+	})
+	if len(result) > realtimeBreakdownLimit {
+		result = result[:realtimeBreakdownLimit]
+	}
+	return result
+}
@@ -3,6 +3,7 @@ package services
 import (
 	"time"
 
+	"github.com/KalebAsratemedhi/stock-dashboard/backend/internal/geo"
 	"github.com/KalebAsratemedhi/stock-dashboard/backend/internal/models"
 	"github.com/KalebAsratemedhi/stock-dashboard/backend/internal/repository"
 )
@@ -18,6 +19,10 @@ type SaleService interface {
 	GetSalesByRegion(region string, limit int) ([]*models.Sale, error)
 	GetTotalRevenue(start, end time.Time) (float64, error)
 	GetRevenueByCategory(start, end time.Time) ([]map[string]interface{}, error)
+	CompareTotalRevenue(start, end time.Time, mode CompareMode) (*PeriodComparison, error)
+	CompareRevenueByCategory(start, end time.Time, mode CompareMode) (*PeriodComparison, error)
+	GetRevenueByRegion(start, end time.Time) ([]repository.SalesGeoRow, error)
+	GetRevenueByCountry(start, end time.Time, region string) ([]repository.SalesGeoRow, error)
 }
 
 type saleService struct {
@@ -130,4 +135,45 @@ func (s *saleService) GetTotalRevenue(start, end time.Time) (float64, error) {
 
 func (s *saleService) GetRevenueByCategory(start, end time.Time) ([]map[string]interface{}, error) {
 	return s.repo.GetRevenueByCategory(start, end)
-}
\ No newline at end of file
+}
+
+func (s *saleService) CompareTotalRevenue(start, end time.Time, mode CompareMode) (*PeriodComparison, error) {
+	return ComparePeriods(start, end, mode, func(start, end time.Time) ([]GroupValue, error) {
+		revenue, err := s.repo.GetRevenueByTimeRange(start, end)
+		if err != nil {
+			return nil, err
+		}
+		return []GroupValue{{Value: revenue}}, nil
+	})
+}
+
+func (s *saleService) CompareRevenueByCategory(start, end time.Time, mode CompareMode) (*PeriodComparison, error) {
+	return ComparePeriods(start, end, mode, func(start, end time.Time) ([]GroupValue, error) {
+		rows, err := s.repo.GetRevenueByCategory(start, end)
+		if err != nil {
+			return nil, err
+		}
+		values := make([]GroupValue, 0, len(rows))
+		for _, row := range rows {
+			values = append(values, GroupValue{
+				Key:   map[string]string{"category": stringValue(row["category"])},
+				Value: numericValue(row["total_revenue"]),
+			})
+		}
+		return values, nil
+	})
+}
+
+func (s *saleService) GetRevenueByRegion(start, end time.Time) ([]repository.SalesGeoRow, error) {
+	return s.repo.GetRevenueByGeo(start, end, "")
+}
+
+func (s *saleService) GetRevenueByCountry(start, end time.Time, region string) ([]repository.SalesGeoRow, error) {
+	if region == "" {
+		return nil, ErrInvalidInput
+	}
+	if r, ok := geo.LookupRegion(region); ok {
+		region = r.Name
+	}
+	return s.repo.GetRevenueByGeo(start, end, region)
+}
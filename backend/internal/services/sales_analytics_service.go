@@ -0,0 +1,319 @@
+package services
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/KalebAsratemedhi/stock-dashboard/backend/internal/repository"
+)
+
+// DiscountBand summarises sales of one group within a discount band.
+type DiscountBand struct {
+	Band             string  `json:"band"`
+	Orders           int64   `json:"orders"`
+	Quantity         int64   `json:"quantity"`
+	Revenue          float64 `json:"revenue"`
+	GrossRevenue     float64 `json:"gross_revenue"`
+	RevenueLost      float64 `json:"revenue_lost"`
+	AvgPrice         float64 `json:"avg_effective_price"`
+	AvgOrderQuantity float64 `json:"avg_order_quantity"`
+	VolumeShare      float64 `json:"volume_share"`
+	RevenueShare     float64 `json:"revenue_share"`
+}
+
+// PriceElasticity is the slope of ln(quantity) against ln(effective price).
+// Values below -1 indicate elastic demand. Fields are nil when there is too
+// little price variation to fit a line.
+type PriceElasticity struct {
+	Coefficient  *float64 `json:"coefficient"`
+	Intercept    *float64 `json:"intercept"`
+	RSquared     *float64 `json:"r_squared"`
+	Observations int64    `json:"observations"`
+}
+
+// DiscountGroup is the discount analysis for one product or category.
+type DiscountGroup struct {
+	Key          string           `json:"key"`
+	Label        string           `json:"label"`
+	Revenue      float64          `json:"revenue"`
+	GrossRevenue float64          `json:"gross_revenue"`
+	RevenueLost  float64          `json:"revenue_lost"`
+	Bands        []*DiscountBand  `json:"bands"`
+	Elasticity   *PriceElasticity `json:"elasticity"`
+}
+
+// DiscountAnalysis is the response of GetDiscountAnalysis.
+type DiscountAnalysis struct {
+	Range          TimeRange        `json:"range"`
+	GroupBy        string           `json:"group_by"`
+	Revenue        float64          `json:"revenue"`
+	GrossRevenue   float64          `json:"gross_revenue"`
+	RevenueLost    float64          `json:"revenue_lost"`
+	RevenueLostPct float64          `json:"revenue_lost_pct"`
+	Groups         []*DiscountGroup `json:"groups"`
+}
+
+// SegmentDriver attributes part of a revenue change to one segment. The
+// price, volume and mix effects sum to the segment's delta.
+type SegmentDriver struct {
+	Segment         string  `json:"segment"`
+	RevenueA        float64 `json:"revenue_a"`
+	RevenueB        float64 `json:"revenue_b"`
+	QuantityA       int64   `json:"quantity_a"`
+	QuantityB       int64   `json:"quantity_b"`
+	Delta           float64 `json:"delta"`
+	ContributionPct float64 `json:"contribution_pct"`
+	PriceEffect     float64 `json:"price_effect"`
+	VolumeEffect    float64 `json:"volume_effect"`
+	MixEffect       float64 `json:"mix_effect"`
+}
+
+// DimensionDrivers is the decomposition of a revenue change along one dimension.
+type DimensionDrivers struct {
+	Dimension    string           `json:"dimension"`
+	PriceEffect  float64          `json:"price_effect"`
+	VolumeEffect float64          `json:"volume_effect"`
+	MixEffect    float64          `json:"mix_effect"`
+	Segments     []*SegmentDriver `json:"segments"`
+}
+
+// RevenueDrivers explains the revenue change from period A to period B.
+type RevenueDrivers struct {
+	PeriodA    TimeRange           `json:"period_a"`
+	PeriodB    TimeRange           `json:"period_b"`
+	RevenueA   float64             `json:"revenue_a"`
+	RevenueB   float64             `json:"revenue_b"`
+	Delta      float64             `json:"delta"`
+	DeltaPct   *float64            `json:"delta_pct"`
+	Dimensions []*DimensionDrivers `json:"dimensions"`
+}
+
+type SalesAnalyticsService interface {
+	GetDiscountAnalysis(start, end time.Time, groupBy string) (*DiscountAnalysis, error)
+	GetRevenueDrivers(periodA, periodB TimeRange, dimensions []string, limit int) (*RevenueDrivers, error)
+}
+
+type salesAnalyticsService struct {
+	repo repository.SaleRepository
+}
+
+func NewSalesAnalyticsService(repo repository.SaleRepository) SalesAnalyticsService {
+	return &salesAnalyticsService{repo: repo}
+}
+
+func (s *salesAnalyticsService) GetDiscountAnalysis(start, end time.Time, groupBy string) (*DiscountAnalysis, error) {
+	if groupBy == "" {
+		groupBy = "category"
+	}
+	if _, ok := repository.SaleGroupColumns[groupBy]; !ok {
+		return nil, ErrInvalidInput
+	}
+	if !end.After(start) {
+		return nil, ErrInvalidInput
+	}
+
+	bands, err := s.repo.GetDiscountBands(start, end, groupBy)
+	if err != nil {
+		return nil, err
+	}
+	elasticities, err := s.repo.GetPriceElasticity(start, end, groupBy)
+	if err != nil {
+		return nil, err
+	}
+
+	analysis := &DiscountAnalysis{
+		Range:   TimeRange{Start: start, End: end},
+		GroupBy: groupBy,
+		Groups:  []*DiscountGroup{},
+	}
+	groups := make(map[string]*DiscountGroup)
+	for _, row := range bands {
+		group, ok := groups[row.GroupKey]
+		if !ok {
+			group = &DiscountGroup{Key: row.GroupKey, Label: row.GroupLabel}
+			groups[row.GroupKey] = group
+			analysis.Groups = append(analysis.Groups, group)
+		}
+		band := &DiscountBand{
+			Band:         row.Band,
+			Orders:       row.Orders,
+			Quantity:     row.Quantity,
+			Revenue:      row.Revenue,
+			GrossRevenue: row.GrossRevenue,
+			RevenueLost:  row.GrossRevenue - row.Revenue,
+			AvgPrice:     row.AvgPrice,
+		}
+		if row.Orders > 0 {
+			band.AvgOrderQuantity = float64(row.Quantity) / float64(row.Orders)
+		}
+		group.Bands = append(group.Bands, band)
+		group.Revenue += band.Revenue
+		group.GrossRevenue += band.GrossRevenue
+		group.RevenueLost += band.RevenueLost
+	}
+
+	for _, group := range analysis.Groups {
+		var quantity int64
+		for _, band := range group.Bands {
+			quantity += band.Quantity
+		}
+		for _, band := range group.Bands {
+			if quantity > 0 {
+				band.VolumeShare = (float64(band.Quantity) / float64(quantity)) * 100
+			}
+			if group.Revenue > 0 {
+				band.RevenueShare = (band.Revenue / group.Revenue) * 100
+			}
+		}
+		analysis.Revenue += group.Revenue
+		analysis.GrossRevenue += group.GrossRevenue
+		analysis.RevenueLost += group.RevenueLost
+	}
+	if analysis.GrossRevenue > 0 {
+		analysis.RevenueLostPct = (analysis.RevenueLost / analysis.GrossRevenue) * 100
+	}
+
+	for _, row := range elasticities {
+		group, ok := groups[row.GroupKey]
+		if !ok {
+			continue
+		}
+		group.Elasticity = &PriceElasticity{
+			Coefficient:  row.Elasticity,
+			Intercept:    row.Intercept,
+			RSquared:     row.RSquared,
+			Observations: row.Observations,
+		}
+	}
+
+	return analysis, nil
+}
+
+func (s *salesAnalyticsService) GetRevenueDrivers(periodA, periodB TimeRange, dimensions []string, limit int) (*RevenueDrivers, error) {
+	if !periodA.End.After(periodA.Start) || !periodB.End.After(periodB.Start) {
+		return nil, ErrInvalidInput
+	}
+	if len(dimensions) == 0 {
+		dimensions = []string{"category", "region", "product_id"}
+	}
+	for _, dimension := range dimensions {
+		if _, ok := repository.SaleGroupColumns[dimension]; !ok {
+			return nil, ErrInvalidInput
+		}
+	}
+
+	revenueA, err := s.repo.GetRevenueByTimeRange(periodA.Start, periodA.End)
+	if err != nil {
+		return nil, err
+	}
+	revenueB, err := s.repo.GetRevenueByTimeRange(periodB.Start, periodB.End)
+	if err != nil {
+		return nil, err
+	}
+	total := NewValueComparison(revenueB, revenueA)
+
+	drivers := &RevenueDrivers{
+		PeriodA:  periodA,
+		PeriodB:  periodB,
+		RevenueA: revenueA,
+		RevenueB: revenueB,
+		Delta:    total.Delta,
+		DeltaPct: total.DeltaPct,
+	}
+	for _, dimension := range dimensions {
+		rowsA, err := s.repo.GetRevenueBySegment(periodA.Start, periodA.End, dimension)
+		if err != nil {
+			return nil, err
+		}
+		rowsB, err := s.repo.GetRevenueBySegment(periodB.Start, periodB.End, dimension)
+		if err != nil {
+			return nil, err
+		}
+		result := decomposeRevenue(rowsA, rowsB, total.Delta)
+		result.Dimension = dimension
+		if limit > 0 && len(result.Segments) > limit {
+			result.Segments = result.Segments[:limit]
+		}
+		drivers.Dimensions = append(drivers.Dimensions, result)
+	}
+	return drivers, nil
+}
+
+// decomposeRevenue splits each segment's revenue change into
+//
+//	volume: (Q_b - Q_a) * share_a * price_a   the overall volume change
+//	mix:    Q_b * (share_b - share_a) * price_a  the shift between segments
+//	price:  q_b * (price_b - price_a)           the change in effective price
+//
+// where Q is total quantity, share is the segment's share of Q and price is
+// revenue per unit. Segments absent from A take their B price as the base so
+// that all of their revenue is reported as mix.
+func decomposeRevenue(rowsA, rowsB []repository.SegmentRevenueRow, totalDelta float64) *DimensionDrivers {
+	segments := make(map[string]*SegmentDriver)
+	var order []string
+	lookup := func(name string) *SegmentDriver {
+		segment, ok := segments[name]
+		if !ok {
+			segment = &SegmentDriver{Segment: name}
+			segments[name] = segment
+			order = append(order, name)
+		}
+		return segment
+	}
+	var totalQtyA, totalQtyB int64
+	for _, row := range rowsA {
+		segment := lookup(row.Segment)
+		segment.RevenueA += row.Revenue
+		segment.QuantityA += row.Quantity
+		totalQtyA += row.Quantity
+	}
+	for _, row := range rowsB {
+		segment := lookup(row.Segment)
+		segment.RevenueB += row.Revenue
+		segment.QuantityB += row.Quantity
+		totalQtyB += row.Quantity
+	}
+
+	result := &DimensionDrivers{Segments: make([]*SegmentDriver, 0, len(order))}
+	for _, name := range order {
+		segment := segments[name]
+		var priceA, priceB, shareA, shareB float64
+		if segment.QuantityB > 0 {
+			priceB = segment.RevenueB / float64(segment.QuantityB)
+		}
+		if segment.QuantityA > 0 {
+			priceA = segment.RevenueA / float64(segment.QuantityA)
+		} else {
+			priceA = priceB
+		}
+		if totalQtyA > 0 {
+			shareA = float64(segment.QuantityA) / float64(totalQtyA)
+		}
+		if totalQtyB > 0 {
+			shareB = float64(segment.QuantityB) / float64(totalQtyB)
+		}
+
+		segment.Delta = segment.RevenueB - segment.RevenueA
+		segment.VolumeEffect = float64(totalQtyB-totalQtyA) * shareA * priceA
+		segment.MixEffect = float64(totalQtyB) * (shareB - shareA) * priceA
+		segment.PriceEffect = float64(segment.QuantityB) * (priceB - priceA)
+		if totalDelta != 0 {
+			segment.ContributionPct = (segment.Delta / totalDelta) * 100
+		}
+
+		result.VolumeEffect += segment.VolumeEffect
+		result.MixEffect += segment.MixEffect
+		result.PriceEffect += segment.PriceEffect
+		result.Segments = append(result.Segments, segment)
+	}
+
+	sort.SliceStable(result.Segments, func(i, j int) bool {
+		a, b := math.Abs(result.Segments[i].Delta), math.Abs(result.Segments[j].Delta)
+		if a != b {
+			return a > b
+		}
+		return result.Segments[i].Segment < result.Segments[j].Segment
+	})
+	return result
+}
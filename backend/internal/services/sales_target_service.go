@@ -0,0 +1,193 @@
+package services
+
+import (
+	"errors"
+	"time"
+
+	"github.com/KalebAsratemedhi/stock-dashboard/backend/internal/models"
+	"github.com/KalebAsratemedhi/stock-dashboard/backend/internal/repository"
+	"gorm.io/gorm"
+)
+
+// SalesAttainment compares actual revenue against a target as of a point in time.
+type SalesAttainment struct {
+	Target           *models.SalesTarget `json:"target"`
+	AsOf             time.Time           `json:"as_of"`
+	Actual           float64             `json:"actual"`
+	AttainmentPct    float64             `json:"attainment_pct"`
+	Remaining        float64             `json:"remaining"`
+	ElapsedPct       float64             `json:"elapsed_pct"`
+	ProjectedRevenue float64             `json:"projected_revenue"`
+	ProjectedPct     float64             `json:"projected_pct"`
+}
+
+type SalesTargetService interface {
+	CreateTarget(target *models.SalesTarget) error
+	UpdateTarget(target *models.SalesTarget) error
+	DeleteTarget(id uint) error
+	GetTargetByID(id uint) (*models.SalesTarget, error)
+	ListTargets(region, category string) ([]*models.SalesTarget, error)
+	GetAttainment(at time.Time, region, category string) ([]*SalesAttainment, error)
+	GetAttainmentForSale(sale *models.Sale) ([]*SalesAttainment, error)
+}
+
+type salesTargetService struct {
+	repo repository.SalesTargetRepository
+}
+
+func NewSalesTargetService(repo repository.SalesTargetRepository) SalesTargetService {
+	return &salesTargetService{repo: repo}
+}
+
+func (s *salesTargetService) CreateTarget(target *models.SalesTarget) error {
+	if err := validateSalesTarget(target); err != nil {
+		return err
+	}
+	return s.repo.Create(target)
+}
+
+func (s *salesTargetService) UpdateTarget(target *models.SalesTarget) error {
+	if err := validateSalesTarget(target); err != nil {
+		return err
+	}
+	existing, err := s.GetTargetByID(target.ID)
+	if err != nil {
+		return err
+	}
+	target.CreatedAt = existing.CreatedAt
+	return s.repo.Update(target)
+}
+
+func (s *salesTargetService) DeleteTarget(id uint) error {
+	if err := s.repo.Delete(id); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+func (s *salesTargetService) GetTargetByID(id uint) (*models.SalesTarget, error) {
+	target, err := s.repo.GetByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return target, nil
+}
+
+func (s *salesTargetService) ListTargets(region, category string) ([]*models.SalesTarget, error) {
+	return s.repo.List(region, category)
+}
+
+func (s *salesTargetService) GetAttainment(at time.Time, region, category string) ([]*SalesAttainment, error) {
+	if at.IsZero() {
+		at = time.Now()
+	}
+	targets, err := s.repo.GetActive(at)
+	if err != nil {
+		return nil, err
+	}
+	var matched []*models.SalesTarget
+	for _, target := range targets {
+		if region != "" && target.Region != region {
+			continue
+		}
+		if category != "" && target.Category != category {
+			continue
+		}
+		matched = append(matched, target)
+	}
+	output, err := s.attainments(matched, at)
+	if output == nil && err == nil {
+		output = []*SalesAttainment{}
+	}
+	return output, err
+}
+
+func (s *salesTargetService) GetAttainmentForSale(sale *models.Sale) ([]*SalesAttainment, error) {
+	at := sale.Timestamp
+	if at.IsZero() {
+		at = time.Now()
+	}
+	targets, err := s.repo.GetActive(at)
+	if err != nil {
+		return nil, err
+	}
+	var matched []*models.SalesTarget
+	for _, target := range targets {
+		if target.Matches(sale.Region, sale.Category) {
+			matched = append(matched, target)
+		}
+	}
+	return s.attainments(matched, at)
+}
+
+// attainments computes the attainment of the targets as of at, fetching the
+// revenue of all of them in one query.
+func (s *salesTargetService) attainments(targets []*models.SalesTarget, at time.Time) ([]*SalesAttainment, error) {
+	if len(targets) == 0 {
+		return nil, nil
+	}
+	ids := make([]uint, len(targets))
+	for i, target := range targets {
+		ids[i] = target.ID
+	}
+	revenue, err := s.repo.GetRevenue(ids, at)
+	if err != nil {
+		return nil, err
+	}
+	output := make([]*SalesAttainment, 0, len(targets))
+	for _, target := range targets {
+		output = append(output, computeAttainment(target, revenue[target.ID], at))
+	}
+	return output, nil
+}
+
+func computeAttainment(target *models.SalesTarget, actual float64, at time.Time) *SalesAttainment {
+	asOf := at
+	if asOf.After(target.PeriodEnd) {
+		asOf = target.PeriodEnd
+	}
+
+	attainment := &SalesAttainment{
+		Target:    target,
+		AsOf:      asOf,
+		Actual:    actual,
+		Remaining: target.TargetRevenue - actual,
+	}
+	if attainment.Remaining < 0 {
+		attainment.Remaining = 0
+	}
+	if target.TargetRevenue > 0 {
+		attainment.AttainmentPct = (actual / target.TargetRevenue) * 100
+	}
+
+	// Project to period end by extrapolating the revenue rate observed so far.
+	total := target.PeriodEnd.Sub(target.PeriodStart)
+	elapsed := asOf.Sub(target.PeriodStart)
+	if elapsed > 0 && total > 0 {
+		attainment.ElapsedPct = (float64(elapsed) / float64(total)) * 100
+		attainment.ProjectedRevenue = actual * float64(total) / float64(elapsed)
+	}
+	if target.TargetRevenue > 0 {
+		attainment.ProjectedPct = (attainment.ProjectedRevenue / target.TargetRevenue) * 100
+	}
+	return attainment
+}
+
+func validateSalesTarget(target *models.SalesTarget) error {
+	if target.TargetRevenue <= 0 {
+		return ErrInvalidInput
+	}
+	if target.PeriodStart.IsZero() || target.PeriodEnd.IsZero() {
+		return ErrInvalidInput
+	}
+	if !target.PeriodEnd.After(target.PeriodStart) {
+		return ErrInvalidInput
+	}
+	return nil
+}
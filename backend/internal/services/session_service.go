@@ -0,0 +1,93 @@
+package services
+
+import (
+	"time"
+
+	"github.com/KalebAsratemedhi/stock-dashboard/backend/internal/models"
+	"github.com/KalebAsratemedhi/stock-dashboard/backend/internal/repository"
+)
+
+const (
+	// SessionTimeout is the inactivity after which a session is rolled up.
+	SessionTimeout = 30 * time.Minute
+
+	// sessionLookback bounds how far back each incremental rollup scans. It
+	// must exceed the longest expected session.
+	sessionLookback = 6 * time.Hour
+)
+
+// SessionReport is the response of GetSessionReport.
+type SessionReport struct {
+	Range    TimeRange                      `json:"range"`
+	Page     string                         `json:"page,omitempty"`
+	Summary  *repository.SessionSummaryRow  `json:"summary"`
+	Series   []repository.SessionSummaryRow `json:"series,omitempty"`
+	Pages    []repository.SessionSummaryRow `json:"pages,omitempty"`
+	Sessions []*models.UserSession          `json:"sessions,omitempty"`
+}
+
+type SessionService interface {
+	IncludeBots() SessionService
+	RollupSessions(now time.Time) (int64, error)
+	BackfillSessions(since, now time.Time) (int64, error)
+	GetSessionReport(start, end time.Time, interval, page string, limit int) (*SessionReport, error)
+}
+
+type sessionService struct {
+	repo repository.SessionRepository
+}
+
+func NewSessionService(repo repository.SessionRepository) SessionService {
+	return &sessionService{repo: repo}
+}
+
+// IncludeBots returns a service whose reports also count sessions with bot
+// traffic.
+func (s *sessionService) IncludeBots() SessionService {
+	return &sessionService{repo: s.repo.IncludeBots()}
+}
+
+// RollupSessions rolls up sessions that went idle within the lookback window.
+func (s *sessionService) RollupSessions(now time.Time) (int64, error) {
+	return s.BackfillSessions(now.Add(-SessionTimeout-sessionLookback), now)
+}
+
+// BackfillSessions rolls up every idle session with events since `since`.
+func (s *sessionService) BackfillSessions(since, now time.Time) (int64, error) {
+	return s.repo.Rollup(since, now.Add(-SessionTimeout))
+}
+
+func (s *sessionService) GetSessionReport(start, end time.Time, interval, page string, limit int) (*SessionReport, error) {
+	if end.Before(start) {
+		return nil, ErrInvalidInput
+	}
+	summary, err := s.repo.GetSummary(start, end, page)
+	if err != nil {
+		return nil, err
+	}
+	report := &SessionReport{
+		Range:   TimeRange{Start: start, End: end},
+		Page:    page,
+		Summary: summary,
+	}
+
+	if interval != "" {
+		report.Series, err = s.repo.GetSummaryByBucket(start, end, interval, page)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if page == "" {
+		report.Pages, err = s.repo.GetSummaryByEntryPage(start, end, 0)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if limit > 0 {
+		report.Sessions, err = s.repo.GetByTimeRange(start, end, limit)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return report, nil
+}
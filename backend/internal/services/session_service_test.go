@@ -0,0 +1,114 @@
+package services
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/KalebAsratemedhi/stock-dashboard/backend/internal/models"
+	"github.com/KalebAsratemedhi/stock-dashboard/backend/internal/repository"
+)
+
+// fakeSessionRepository records the rollup window and which report queries
+// were run.
+type fakeSessionRepository struct {
+	since, idleBefore time.Time
+	queries           []string
+}
+
+func (r *fakeSessionRepository) IncludeBots() repository.SessionRepository {
+	return &fakeSessionRepository{}
+}
+
+func (r *fakeSessionRepository) Rollup(since, idleBefore time.Time) (int64, error) {
+	r.since, r.idleBefore = since, idleBefore
+	return 3, nil
+}
+
+func (r *fakeSessionRepository) GetByTimeRange(start, end time.Time, limit int) ([]*models.UserSession, error) {
+	r.queries = append(r.queries, "sessions")
+	return []*models.UserSession{}, nil
+}
+
+func (r *fakeSessionRepository) GetSummary(start, end time.Time, page string) (*repository.SessionSummaryRow, error) {
+	r.queries = append(r.queries, "summary")
+	return &repository.SessionSummaryRow{Page: page}, nil
+}
+
+func (r *fakeSessionRepository) GetSummaryByBucket(start, end time.Time, interval, page string) ([]repository.SessionSummaryRow, error) {
+	r.queries = append(r.queries, "series")
+	return []repository.SessionSummaryRow{}, nil
+}
+
+func (r *fakeSessionRepository) GetSummaryByEntryPage(start, end time.Time, limit int) ([]repository.SessionSummaryRow, error) {
+	r.queries = append(r.queries, "pages")
+	return []repository.SessionSummaryRow{}, nil
+}
+
+func TestSessionRollupWindow(t *testing.T) {
+	now := time.Date(2026, time.March, 15, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name       string
+		run        func(SessionService) (int64, error)
+		since      time.Time
+		idleBefore time.Time
+	}{
+		{
+			name:       "rollup scans the lookback before the timeout",
+			run:        func(s SessionService) (int64, error) { return s.RollupSessions(now) },
+			since:      time.Date(2026, time.March, 15, 5, 30, 0, 0, time.UTC),
+			idleBefore: time.Date(2026, time.March, 15, 11, 30, 0, 0, time.UTC),
+		},
+		{
+			name:       "backfill scans from since",
+			run:        func(s SessionService) (int64, error) { return s.BackfillSessions(now.Add(-30*24*time.Hour), now) },
+			since:      time.Date(2026, time.February, 13, 12, 0, 0, 0, time.UTC),
+			idleBefore: time.Date(2026, time.March, 15, 11, 30, 0, 0, time.UTC),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeSessionRepository{}
+			n, err := tt.run(NewSessionService(repo))
+			if err != nil || n != 3 {
+				t.Fatalf("got %d, %v, want 3, nil", n, err)
+			}
+			if !repo.since.Equal(tt.since) || !repo.idleBefore.Equal(tt.idleBefore) {
+				t.Errorf("rolled up [%s, %s), want [%s, %s)", repo.since, repo.idleBefore, tt.since, tt.idleBefore)
+			}
+		})
+	}
+}
+
+func TestGetSessionReport(t *testing.T) {
+	start, end := date(2026, time.March, 1), date(2026, time.March, 8)
+	tests := []struct {
+		name       string
+		start, end time.Time
+		interval   string
+		page       string
+		limit      int
+		queries    string
+		wantErr    bool
+	}{
+		{"summary and entry pages", start, end, "", "", 0, "summary,pages", false},
+		{"with series and sessions", start, end, "1 day", "", 10, "summary,series,pages,sessions", false},
+		{"one page skips entry pages", start, end, "1 day", "/pricing", 0, "summary,series", false},
+		{"end before start", end, start, "", "", 0, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeSessionRepository{}
+			report, err := NewSessionService(repo).GetSessionReport(tt.start, tt.end, tt.interval, tt.page, tt.limit)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GetSessionReport() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if queries := strings.Join(repo.queries, ","); queries != tt.queries {
+				t.Errorf("ran %q, want %q", queries, tt.queries)
+			}
+			if err == nil && (report.Page != tt.page || report.Summary == nil) {
+				t.Errorf("report = %+v", report)
+			}
+		})
+	}
+}
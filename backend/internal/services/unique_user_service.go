@@ -0,0 +1,303 @@
+package services
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/KalebAsratemedhi/stock-dashboard/backend/internal/models"
+	"github.com/KalebAsratemedhi/stock-dashboard/backend/internal/redis"
+	"github.com/KalebAsratemedhi/stock-dashboard/backend/internal/repository"
+)
+
+const (
+	// exactUniqueRange is the longest range counted with COUNT(DISTINCT).
+	// Longer ranges merge per-day HyperLogLog sketches instead.
+	exactUniqueRange = 7 * 24 * time.Hour
+
+	sketchTTL       = 400 * 24 * time.Hour
+	sketchBatchSize = 1000
+
+	// sketchRefresh is how long the backfill of the current day is trusted
+	// before it is topped up from the database again.
+	sketchRefresh = 10 * time.Minute
+)
+
+// sketchIntervalPattern matches the bucket intervals that are whole days and
+// can therefore be served from daily sketches.
+var sketchIntervalPattern = regexp.MustCompile(`^([1-9][0-9]{0,2}) (day|week)s?$`)
+
+// bucketOrigin is the origin TimescaleDB's time_bucket aligns day and week
+// buckets to, a Monday.
+var bucketOrigin = time.Date(2000, time.January, 3, 0, 0, 0, 0, time.UTC)
+
+// UniqueCounts is the number of distinct users and sessions in a range.
+// Approximate counts come from merged daily sketches, whose range is widened
+// to whole UTC days.
+type UniqueCounts struct {
+	Range       TimeRange `json:"range"`
+	Users       int64     `json:"users"`
+	Sessions    int64     `json:"sessions"`
+	Approximate bool      `json:"approximate"`
+}
+
+// ActiveUsers reports DAU, WAU and MAU over calendar days ending on AsOf.
+type ActiveUsers struct {
+	AsOf       time.Time     `json:"as_of"`
+	DAU        *UniqueCounts `json:"dau"`
+	WAU        *UniqueCounts `json:"wau"`
+	MAU        *UniqueCounts `json:"mau"`
+	Stickiness float64       `json:"stickiness"`
+}
+
+type UniqueUserService interface {
+	IncludeBots() UniqueUserService
+	RecordEvent(event *models.UserEvent) error
+	CountUnique(start, end time.Time) (*UniqueCounts, error)
+	CountUniqueByBucket(start, end time.Time, interval string) ([]repository.DistinctCountRow, error)
+	GetActiveUsers(at time.Time) (*ActiveUsers, error)
+}
+
+type uniqueUserService struct {
+	repo        repository.UserEventRepository
+	redisClient *redis.Client
+}
+
+// NewUniqueUserService creates the service. redisClient may be nil, in which
+// case every count is exact.
+func NewUniqueUserService(repo repository.UserEventRepository, redisClient *redis.Client) UniqueUserService {
+	return &uniqueUserService{repo: repo, redisClient: redisClient}
+}
+
+// IncludeBots returns a service whose counts also include bot traffic. The
+// sketches only hold human traffic, so every count is exact.
+func (s *uniqueUserService) IncludeBots() UniqueUserService {
+	return &uniqueUserService{repo: s.repo.IncludeBots()}
+}
+
+// RecordEvent adds the event's user and session to the day's sketches. Bot
+// traffic is never recorded.
+func (s *uniqueUserService) RecordEvent(event *models.UserEvent) error {
+	if s.redisClient == nil || event.IsBot {
+		return nil
+	}
+	day := event.Timestamp.UTC()
+	for kind, id := range map[string]string{"users": event.UserID, "sessions": event.SessionID} {
+		if id == "" {
+			continue
+		}
+		key := sketchKey(kind, day)
+		if err := s.redisClient.PFAdd(key, id); err != nil {
+			return err
+		}
+		if err := s.redisClient.Expire(key, sketchTTL); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *uniqueUserService) CountUnique(start, end time.Time) (*UniqueCounts, error) {
+	if end.Before(start) {
+		return nil, ErrInvalidInput
+	}
+	if s.redisClient == nil || end.Sub(start) <= exactUniqueRange {
+		return s.countExact(start, end)
+	}
+	return s.countSketches(truncateDay(start), truncateDay(end))
+}
+
+// CountUniqueByBucket counts distinct users and sessions per time bucket.
+// Over ranges too long to count exactly, whole-day intervals are served from
+// the daily sketches, with buckets aligned as time_bucket aligns them.
+func (s *uniqueUserService) CountUniqueByBucket(start, end time.Time, interval string) ([]repository.DistinctCountRow, error) {
+	if interval == "" {
+		interval = "1 day"
+	}
+	if end.Before(start) {
+		return nil, ErrInvalidInput
+	}
+	days, ok := sketchIntervalDays(interval)
+	if s.redisClient == nil || !ok || end.Sub(start) <= exactUniqueRange {
+		return s.repo.CountDistinctByBucket(start, end, interval)
+	}
+
+	last := truncateDay(end)
+	var rows []repository.DistinctCountRow
+	for bucket := bucketStart(start, days); !bucket.After(last); bucket = bucket.AddDate(0, 0, days) {
+		bucketEnd := bucket.AddDate(0, 0, days-1)
+		if bucketEnd.After(last) {
+			bucketEnd = last
+		}
+		counts, err := s.countSketches(bucket, bucketEnd)
+		if err != nil {
+			return nil, err
+		}
+		if counts.Users == 0 && counts.Sessions == 0 {
+			continue
+		}
+		rows = append(rows, repository.DistinctCountRow{Bucket: bucket, Users: counts.Users, Sessions: counts.Sessions})
+	}
+	return rows, nil
+}
+
+func (s *uniqueUserService) GetActiveUsers(at time.Time) (*ActiveUsers, error) {
+	if at.IsZero() {
+		at = time.Now()
+	}
+	dayEnd := truncateDay(at).AddDate(0, 0, 1).Add(-time.Microsecond)
+
+	windows := []int{1, 7, 30}
+	counts := make([]*UniqueCounts, len(windows))
+	for i, days := range windows {
+		start := truncateDay(at).AddDate(0, 0, 1-days)
+		c, err := s.CountUnique(start, dayEnd)
+		if err != nil {
+			return nil, err
+		}
+		counts[i] = c
+	}
+
+	active := &ActiveUsers{AsOf: at, DAU: counts[0], WAU: counts[1], MAU: counts[2]}
+
+	// Both sides of the ratio must come from the same kind of count, so an
+	// approximate MAU is paired with the day's sketch rather than the exact
+	// DAU.
+	dau := active.DAU
+	if active.MAU.Approximate && !dau.Approximate {
+		day := truncateDay(at)
+		var err error
+		if dau, err = s.countSketches(day, day); err != nil {
+			return nil, err
+		}
+	}
+	if active.MAU.Users > 0 {
+		active.Stickiness = float64(dau.Users) / float64(active.MAU.Users)
+	}
+	return active, nil
+}
+
+func (s *uniqueUserService) countExact(start, end time.Time) (*UniqueCounts, error) {
+	row, err := s.repo.CountDistinct(start, end)
+	if err != nil {
+		return nil, err
+	}
+	return &UniqueCounts{
+		Range:    TimeRange{Start: start, End: end},
+		Users:    row.Users,
+		Sessions: row.Sessions,
+	}, nil
+}
+
+// countSketches merges the daily sketches of the days from first to last.
+func (s *uniqueUserService) countSketches(first, last time.Time) (*UniqueCounts, error) {
+	var userKeys, sessionKeys []string
+	for day := first; !day.After(last); day = day.AddDate(0, 0, 1) {
+		if err := s.ensureSketches(day); err != nil {
+			return nil, err
+		}
+		userKeys = append(userKeys, sketchKey("users", day))
+		sessionKeys = append(sessionKeys, sketchKey("sessions", day))
+	}
+
+	users, err := s.redisClient.PFCount(userKeys...)
+	if err != nil {
+		return nil, err
+	}
+	sessions, err := s.redisClient.PFCount(sessionKeys...)
+	if err != nil {
+		return nil, err
+	}
+	return &UniqueCounts{
+		Range:       TimeRange{Start: first, End: last.AddDate(0, 0, 1)},
+		Users:       users,
+		Sessions:    sessions,
+		Approximate: true,
+	}, nil
+}
+
+// ensureSketches backfills the sketches for a day from the database unless
+// the day is marked complete. The worker only adds the events it processes,
+// so a sketch can exist yet miss events loaded by the data-generator or
+// stored before the worker started. Adding IDs is idempotent, so the
+// backfill merges with whatever the worker recorded. Past days stay marked
+// for as long as their sketches live; the current day is topped up again
+// after sketchRefresh.
+func (s *uniqueUserService) ensureSketches(day time.Time) error {
+	now := time.Now()
+	if day.After(now) {
+		return nil
+	}
+	marker := sketchKey("complete", day)
+	complete, err := s.redisClient.Exists(marker)
+	if err != nil {
+		return err
+	}
+	if complete {
+		return nil
+	}
+
+	for kind, column := range map[string]string{"users": "user_id", "sessions": "session_id"} {
+		key := sketchKey(kind, day)
+		ids, err := s.repo.GetDistinctIDs(day, day.AddDate(0, 0, 1), column)
+		if err != nil {
+			return err
+		}
+		if len(ids) == 0 {
+			// Create an empty sketch so that counting the day never fails.
+			if err := s.redisClient.PFAdd(key); err != nil {
+				return err
+			}
+		}
+		for i := 0; i < len(ids); i += sketchBatchSize {
+			batch := ids[i:min(i+sketchBatchSize, len(ids))]
+			if err := s.redisClient.PFAdd(key, batch...); err != nil {
+				return err
+			}
+		}
+		if err := s.redisClient.Expire(key, sketchTTL); err != nil {
+			return err
+		}
+	}
+
+	ttl := sketchTTL
+	if day.AddDate(0, 0, 1).After(now) {
+		ttl = sketchRefresh
+	}
+	return s.redisClient.Set(marker, "1", ttl)
+}
+
+// sketchIntervalDays returns the length in days of a bucket interval that
+// is a whole number of days or weeks.
+func sketchIntervalDays(interval string) (int, bool) {
+	match := sketchIntervalPattern.FindStringSubmatch(interval)
+	if match == nil {
+		return 0, false
+	}
+	n, _ := strconv.Atoi(match[1])
+	if match[2] == "week" {
+		n *= 7
+	}
+	return n, true
+}
+
+// bucketStart returns the start of the bucket of the given number of days
+// containing t.
+func bucketStart(t time.Time, days int) time.Time {
+	elapsed := int(truncateDay(t).Sub(bucketOrigin).Hours() / 24)
+	offset := elapsed % days
+	if offset < 0 {
+		offset += days
+	}
+	return truncateDay(t).AddDate(0, 0, -offset)
+}
+
+func sketchKey(kind string, day time.Time) string {
+	return fmt.Sprintf("hll:user_events:%s:%s", kind, day.UTC().Format("2006-01-02"))
+}
+
+func truncateDay(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
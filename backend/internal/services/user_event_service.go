@@ -1,13 +1,60 @@
 package services
 
 import (
+	"encoding/json"
+	"fmt"
+	"strings"
 	"time"
 
+	"github.com/KalebAsratemedhi/stock-dashboard/backend/internal/eventschema"
+	"github.com/KalebAsratemedhi/stock-dashboard/backend/internal/geo"
 	"github.com/KalebAsratemedhi/stock-dashboard/backend/internal/models"
 	"github.com/KalebAsratemedhi/stock-dashboard/backend/internal/repository"
 )
 
+const (
+	defaultPageReportLimit = 20
+	maxPageReportLimit     = 100
+	defaultBreakdownLimit  = 20
+	maxBreakdownLimit      = 500
+	defaultEventQueryLimit = 100
+	maxEventQueryLimit     = 1000
+
+	// MetadataPrefix marks event metadata keys in query expressions, as in
+	// meta.plan=pro or meta.cart.total>100.
+	MetadataPrefix = "meta."
+)
+
+// PageReportQuery selects, sorts and paginates the page report. Sort is a
+// key of repository.PageSortColumns and defaults to views, descending.
+type PageReportQuery struct {
+	Sort      string
+	Ascending bool
+	Limit     int
+	Offset    int
+	Interval  string
+}
+
+// PageReportEntry is one page of the report with its views sparkline.
+type PageReportEntry struct {
+	repository.PageReportRow
+	Trend []repository.PageTrendRow `json:"trend"`
+}
+
+// PageReport is the response of GetTopPages.
+type PageReport struct {
+	Range    TimeRange          `json:"range"`
+	Sort     string             `json:"sort"`
+	Order    string             `json:"order"`
+	Interval string             `json:"interval"`
+	Total    int64              `json:"total"`
+	Limit    int                `json:"limit"`
+	Offset   int                `json:"offset"`
+	Pages    []*PageReportEntry `json:"pages"`
+}
+
 type UserEventService interface {
+	IncludeBots() UserEventService
 	CreateEvent(event *models.UserEvent) error
 	BatchCreateEvents(events []*models.UserEvent) error
 	GetEventByID(id uint) (*models.UserEvent, error)
@@ -15,11 +62,16 @@ type UserEventService interface {
 	GetEventsByTimeRange(start, end time.Time) ([]*models.UserEvent, error)
 	GetEventsByType(eventType string, limit int) ([]*models.UserEvent, error)
 	GetEventsByUserID(userID string, limit int) ([]*models.UserEvent, error)
+	QueryEvents(query repository.EventQuery) ([]*models.UserEvent, error)
+	AggregateByMetadata(query repository.EventQuery, groupBy, value string) ([]repository.MetadataGroupRow, error)
 	GetEventCountsByType(start, end time.Time) ([]map[string]interface{}, error)
+	CompareEventCountsByType(start, end time.Time, mode CompareMode) (*PeriodComparison, error)
 	GetPageViewsByTimeRange(start, end time.Time, interval string) ([]map[string]interface{}, error)
 	GetUniqueUsers(start, end time.Time) (int64, error)
-	GetTopPages(start, end time.Time, limit int) ([]map[string]interface{}, error)
-	GetEventsByCountry(start, end time.Time) ([]map[string]interface{}, error)
+	GetTopPages(start, end time.Time, query PageReportQuery) (*PageReport, error)
+	GetEventsByCountry(start, end time.Time) ([]repository.GeoRow, error)
+	GetEventsByCity(start, end time.Time, country string) ([]repository.GeoRow, error)
+	GetEventsByDimension(start, end time.Time, dimension, eventType string, limit int) ([]repository.DimensionRow, error)
 }
 
 type userEventService struct {
@@ -30,6 +82,11 @@ func NewUserEventService(repo repository.UserEventRepository) UserEventService {
 	return &userEventService{repo: repo}
 }
 
+// IncludeBots returns a service whose queries also count bot traffic.
+func (s *userEventService) IncludeBots() UserEventService {
+	return &userEventService{repo: s.repo.IncludeBots()}
+}
+
 func (s *userEventService) CreateEvent(event *models.UserEvent) error {
 	if event.EventType == "" {
 		return ErrInvalidInput
@@ -40,6 +97,9 @@ func (s *userEventService) CreateEvent(event *models.UserEvent) error {
 	if event.Metadata == "" {
 		event.Metadata = "{}"
 	}
+	if err := validateMetadata(event.EventType, event.Metadata); err != nil {
+		return err
+	}
 	return s.repo.Create(event)
 }
 
@@ -54,10 +114,22 @@ func (s *userEventService) BatchCreateEvents(events []*models.UserEvent) error {
 		if event.Metadata == "" {
 			event.Metadata = "{}"
 		}
+		if err := validateMetadata(event.EventType, event.Metadata); err != nil {
+			return err
+		}
 	}
 	return s.repo.BatchCreate(events)
 }
 
+// validateMetadata checks metadata against the schema registered for the
+// event type, if any.
+func validateMetadata(eventType, metadata string) error {
+	if err := eventschema.Validate(eventType, metadata); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidInput, err)
+	}
+	return nil
+}
+
 func (s *userEventService) GetEventByID(id uint) (*models.UserEvent, error) {
 	return s.repo.GetByID(id)
 }
@@ -88,10 +160,148 @@ func (s *userEventService) GetEventsByUserID(userID string, limit int) ([]*model
 	return s.repo.GetByUserID(userID, limit)
 }
 
+// QueryEvents returns the newest events matching the query. A zero range
+// covers the last 7 days.
+func (s *userEventService) QueryEvents(query repository.EventQuery) ([]*models.UserEvent, error) {
+	query, err := normalizeEventQuery(query)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.FindEvents(query)
+}
+
+// AggregateByMetadata groups the events matching the query by the metadata
+// key groupBy, e.g. meta.plan, and sums the numeric key value when set.
+func (s *userEventService) AggregateByMetadata(query repository.EventQuery, groupBy, value string) ([]repository.MetadataGroupRow, error) {
+	query, err := normalizeEventQuery(query)
+	if err != nil {
+		return nil, err
+	}
+	group, err := ParseMetadataPath(groupBy)
+	if err != nil {
+		return nil, err
+	}
+	var amount []string
+	if value != "" {
+		if amount, err = ParseMetadataPath(value); err != nil {
+			return nil, err
+		}
+	}
+	return s.repo.GroupByMetadata(query, group, amount)
+}
+
+func normalizeEventQuery(query repository.EventQuery) (repository.EventQuery, error) {
+	if query.End.IsZero() {
+		query.End = time.Now()
+	}
+	if query.Start.IsZero() {
+		query.Start = query.End.AddDate(0, 0, -7)
+	}
+	if query.End.Before(query.Start) || query.Limit < 0 {
+		return query, ErrInvalidInput
+	}
+	if query.EventType != "" && !eventTypePattern.MatchString(query.EventType) {
+		return query, ErrInvalidInput
+	}
+	for _, predicate := range query.Metadata {
+		if !repository.ValidMetadataPath(predicate.Path) || !containsString(repository.MetadataOps, predicate.Op) {
+			return query, ErrInvalidInput
+		}
+	}
+	if query.Limit == 0 {
+		query.Limit = defaultEventQueryLimit
+	}
+	if query.Limit > maxEventQueryLimit {
+		query.Limit = maxEventQueryLimit
+	}
+	return query, nil
+}
+
+// ParseMetadataPath splits a key such as meta.cart.total into the metadata
+// path [cart total].
+func ParseMetadataPath(key string) ([]string, error) {
+	if !strings.HasPrefix(key, MetadataPrefix) {
+		return nil, fmt.Errorf("%w: metadata key %q must start with %q", ErrInvalidInput, key, MetadataPrefix)
+	}
+	path := strings.Split(strings.TrimPrefix(key, MetadataPrefix), ".")
+	if !repository.ValidMetadataPath(path) {
+		return nil, fmt.Errorf("%w: unsupported metadata key %q", ErrInvalidInput, key)
+	}
+	return path, nil
+}
+
+// ParseMetadataPredicate parses an expression such as meta.plan=pro,
+// meta.amount>=100 or, to test for presence, meta.coupon. Values are typed:
+// numbers, true, false and null are JSON literals, and anything else,
+// including double-quoted text such as "100", is a string.
+func ParseMetadataPredicate(expr string) (repository.MetadataPredicate, error) {
+	i := strings.IndexAny(expr, "=!<>")
+	if i < 0 {
+		path, err := ParseMetadataPath(expr)
+		return repository.MetadataPredicate{Path: path, Op: "exists"}, err
+	}
+	op := expr[i : i+1]
+	if i+1 < len(expr) && expr[i+1] == '=' && op != "=" {
+		op += "="
+	}
+	if op == "!" {
+		return repository.MetadataPredicate{}, fmt.Errorf("%w: invalid metadata predicate %q", ErrInvalidInput, expr)
+	}
+	path, err := ParseMetadataPath(expr[:i])
+	if err != nil {
+		return repository.MetadataPredicate{}, err
+	}
+	predicate := repository.MetadataPredicate{Path: path, Op: op, Value: parseMetadataValue(expr[i+len(op):])}
+	if op != "=" && op != "!=" {
+		switch predicate.Value.(type) {
+		case float64, string:
+		default:
+			return repository.MetadataPredicate{}, fmt.Errorf("%w: %s needs a number or string in %q", ErrInvalidInput, op, expr)
+		}
+	}
+	return predicate, nil
+}
+
+func parseMetadataValue(raw string) interface{} {
+	switch raw {
+	case "true":
+		return true
+	case "false":
+		return false
+	case "null":
+		return nil
+	}
+	if len(raw) >= 2 && raw[0] == '"' && raw[len(raw)-1] == '"' {
+		return raw[1 : len(raw)-1]
+	}
+	var n float64
+	if err := json.Unmarshal([]byte(raw), &n); err == nil {
+		return n
+	}
+	return raw
+}
+
 func (s *userEventService) GetEventCountsByType(start, end time.Time) ([]map[string]interface{}, error) {
 	return s.repo.GetEventCountsByType(start, end)
 }
 
+func (s *userEventService) CompareEventCountsByType(start, end time.Time, mode CompareMode) (*PeriodComparison, error) {
+	return ComparePeriods(start, end, mode, func(start, end time.Time) ([]GroupValue, error) {
+		rows, err := s.repo.GetEventCountsByType(start, end)
+		if err != nil {
+			return nil, err
+		}
+		values := make([]GroupValue, 0, len(rows))
+		for _, row := range rows {
+			values = append(values, GroupValue{
+				Key:   map[string]string{"event_type": stringValue(row["event_type"])},
+				Value: numericValue(row["count"]),
+			})
+		}
+		return values, nil
+	})
+}
+
 func (s *userEventService) GetPageViewsByTimeRange(start, end time.Time, interval string) ([]map[string]interface{}, error) {
 	if interval == "" {
 		interval = "1 hour"
@@ -100,13 +310,117 @@ func (s *userEventService) GetPageViewsByTimeRange(start, end time.Time, interva
 }
 
 func (s *userEventService) GetUniqueUsers(start, end time.Time) (int64, error) {
-	return 0, ErrInternal
+	counts, err := s.repo.CountDistinct(start, end)
+	if err != nil {
+		return 0, err
+	}
+	return counts.Users, nil
+}
+
+// GetTopPages returns the content performance report, one entry per page with
+// a sparkline of views bucketed by query.Interval (chosen from the range
+// length when empty).
+func (s *userEventService) GetTopPages(start, end time.Time, query PageReportQuery) (*PageReport, error) {
+	if end.Before(start) || query.Limit < 0 || query.Offset < 0 {
+		return nil, ErrInvalidInput
+	}
+	if query.Sort == "" {
+		query.Sort = "views"
+	}
+	if _, ok := repository.PageSortColumns[query.Sort]; !ok {
+		return nil, ErrInvalidInput
+	}
+	if query.Limit == 0 {
+		query.Limit = defaultPageReportLimit
+	}
+	if query.Limit > maxPageReportLimit {
+		query.Limit = maxPageReportLimit
+	}
+	if query.Interval == "" {
+		query.Interval = sparklineInterval(end.Sub(start))
+	}
+
+	rows, total, err := s.repo.GetPageReport(start, end, query.Sort, query.Ascending, query.Limit, query.Offset)
+	if err != nil {
+		return nil, err
+	}
+	report := &PageReport{
+		Range:    TimeRange{Start: start, End: end},
+		Sort:     query.Sort,
+		Order:    "desc",
+		Interval: query.Interval,
+		Total:    total,
+		Limit:    query.Limit,
+		Offset:   query.Offset,
+		Pages:    make([]*PageReportEntry, 0, len(rows)),
+	}
+	if query.Ascending {
+		report.Order = "asc"
+	}
+
+	pages := make([]string, 0, len(rows))
+	byPage := make(map[string]*PageReportEntry, len(rows))
+	for _, row := range rows {
+		entry := &PageReportEntry{PageReportRow: row, Trend: []repository.PageTrendRow{}}
+		report.Pages = append(report.Pages, entry)
+		pages = append(pages, row.Page)
+		byPage[row.Page] = entry
+	}
+
+	trends, err := s.repo.GetPageTrends(start, end, query.Interval, pages)
+	if err != nil {
+		return nil, err
+	}
+	for _, point := range trends {
+		if entry, ok := byPage[point.Page]; ok {
+			entry.Trend = append(entry.Trend, point)
+		}
+	}
+	return report, nil
 }
 
-func (s *userEventService) GetTopPages(start, end time.Time, limit int) ([]map[string]interface{}, error) {
-	return []map[string]interface{}{}, ErrInternal
+// sparklineInterval picks a bucket size giving a few dozen points per range.
+func sparklineInterval(span time.Duration) string {
+	switch {
+	case span <= 48*time.Hour:
+		return "1 hour"
+	case span <= 60*24*time.Hour:
+		return "1 day"
+	default:
+		return "1 week"
+	}
 }
 
-func (s *userEventService) GetEventsByCountry(start, end time.Time) ([]map[string]interface{}, error) {
-	return []map[string]interface{}{}, ErrInternal
-}
\ No newline at end of file
+func (s *userEventService) GetEventsByCountry(start, end time.Time) ([]repository.GeoRow, error) {
+	return s.repo.GetGeoBreakdown(start, end, "")
+}
+
+func (s *userEventService) GetEventsByCity(start, end time.Time, country string) ([]repository.GeoRow, error) {
+	if country == "" {
+		return nil, ErrInvalidInput
+	}
+	// Events store the code the collector resolved, so map aliases such as
+	// GB onto the bundled code before filtering.
+	if c, ok := geo.LookupCountry(country); ok {
+		country = c.Code
+	}
+	return s.repo.GetGeoBreakdown(start, end, country)
+}
+
+// GetEventsByDimension breaks events down by one of
+// repository.EventDimensionColumns, keeping the limit largest values.
+func (s *userEventService) GetEventsByDimension(start, end time.Time, dimension, eventType string, limit int) ([]repository.DimensionRow, error) {
+	if _, ok := repository.EventDimensionColumns[dimension]; !ok || end.Before(start) {
+		return nil, ErrInvalidInput
+	}
+	if eventType != "" && !eventTypePattern.MatchString(eventType) {
+		return nil, ErrInvalidInput
+	}
+	if limit <= 0 {
+		limit = defaultBreakdownLimit
+	}
+	if limit > maxBreakdownLimit {
+		limit = maxBreakdownLimit
+	}
+	return s.repo.GetDimensionBreakdown(start, end, dimension, eventType, limit)
+}
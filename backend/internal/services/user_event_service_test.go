@@ -0,0 +1,56 @@
+package services
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/KalebAsratemedhi/stock-dashboard/backend/internal/repository"
+)
+
+func TestParseMetadataPredicate(t *testing.T) {
+	tests := []struct {
+		expr    string
+		want    repository.MetadataPredicate
+		wantErr bool
+	}{
+		{expr: "meta.plan=pro", want: repository.MetadataPredicate{Path: []string{"plan"}, Op: "=", Value: "pro"}},
+		{expr: "meta.plan!=pro", want: repository.MetadataPredicate{Path: []string{"plan"}, Op: "!=", Value: "pro"}},
+		{expr: "meta.cart.total>=100", want: repository.MetadataPredicate{Path: []string{"cart", "total"}, Op: ">=", Value: 100.0}},
+		{expr: "meta.cart.total<2.5", want: repository.MetadataPredicate{Path: []string{"cart", "total"}, Op: "<", Value: 2.5}},
+		{expr: "meta.score>1e3", want: repository.MetadataPredicate{Path: []string{"score"}, Op: ">", Value: 1000.0}},
+		{expr: "meta.version<=v2", want: repository.MetadataPredicate{Path: []string{"version"}, Op: "<=", Value: "v2"}},
+		{expr: `meta.zip="100"`, want: repository.MetadataPredicate{Path: []string{"zip"}, Op: "=", Value: "100"}},
+		{expr: "meta.trial=true", want: repository.MetadataPredicate{Path: []string{"trial"}, Op: "=", Value: true}},
+		{expr: "meta.trial!=false", want: repository.MetadataPredicate{Path: []string{"trial"}, Op: "!=", Value: false}},
+		{expr: "meta.coupon=null", want: repository.MetadataPredicate{Path: []string{"coupon"}, Op: "=", Value: nil}},
+		{expr: "meta.coupon=", want: repository.MetadataPredicate{Path: []string{"coupon"}, Op: "=", Value: ""}},
+		{expr: "meta.coupon", want: repository.MetadataPredicate{Path: []string{"coupon"}, Op: "exists"}},
+		{expr: "meta.utm-source_1=ads", want: repository.MetadataPredicate{Path: []string{"utm-source_1"}, Op: "=", Value: "ads"}},
+		{expr: "meta.a!b", wantErr: true},
+		{expr: "meta.trial>true", wantErr: true},
+		{expr: "meta.coupon<null", wantErr: true},
+		{expr: "plan=pro", wantErr: true},
+		{expr: "meta.=pro", wantErr: true},
+		{expr: "meta.plan name=pro", wantErr: true},
+		{expr: "meta.a..b=1", wantErr: true},
+		{expr: "meta.a.b.c.d.e.f=1", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			got, err := ParseMetadataPredicate(tt.expr)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidInput) {
+					t.Errorf("ParseMetadataPredicate(%q) = %+v, %v, want ErrInvalidInput", tt.expr, got, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseMetadataPredicate(%q): %v", tt.expr, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseMetadataPredicate(%q) = %#v, want %#v", tt.expr, got, tt.want)
+			}
+		})
+	}
+}
@@ -25,6 +25,8 @@ func (rb *RedisBridge) Start() {
 		redis.SalesChannel,
 		redis.UserEventsChannel,
 		redis.FinancialChannel,
+		redis.SalesAttainmentChannel,
+		redis.RealtimeUsersChannel,
 	)
 	defer pubsub.Close()
 
@@ -0,0 +1,16 @@
+-- Sales Targets Table
+CREATE TABLE IF NOT EXISTS sales_targets (
+    id BIGSERIAL PRIMARY KEY,
+    region VARCHAR(100) NOT NULL DEFAULT '',
+    category VARCHAR(100) NOT NULL DEFAULT '',
+    period_start TIMESTAMPTZ NOT NULL,
+    period_end TIMESTAMPTZ NOT NULL,
+    target_revenue DECIMAL(14,2) NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW(),
+    CHECK (period_end > period_start)
+);
+
+-- Create indexes for sales_targets
+CREATE INDEX IF NOT EXISTS idx_sales_targets_period ON sales_targets(period_start, period_end);
+CREATE INDEX IF NOT EXISTS idx_sales_targets_region_category ON sales_targets(region, category);
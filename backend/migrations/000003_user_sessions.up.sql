@@ -0,0 +1,25 @@
+-- User Sessions Table
+-- Rolled up from user_events by the worker once a session has been idle for
+-- the inactivity timeout. Rows are upserted if late events extend a session.
+CREATE TABLE IF NOT EXISTS user_sessions (
+    session_id VARCHAR(100) PRIMARY KEY,
+    user_id VARCHAR(50),
+    started_at TIMESTAMPTZ NOT NULL,
+    ended_at TIMESTAMPTZ NOT NULL,
+    duration_seconds DOUBLE PRECISION NOT NULL DEFAULT 0,
+    event_count INTEGER NOT NULL,
+    page_views INTEGER NOT NULL DEFAULT 0,
+    entry_page VARCHAR(255),
+    exit_page VARCHAR(255),
+    is_bounce BOOLEAN NOT NULL DEFAULT FALSE,
+    device VARCHAR(50),
+    browser VARCHAR(50),
+    country VARCHAR(100),
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+-- Create indexes for user_sessions
+CREATE INDEX IF NOT EXISTS idx_user_sessions_started_at ON user_sessions(started_at DESC);
+CREATE INDEX IF NOT EXISTS idx_user_sessions_entry_page ON user_sessions(entry_page, started_at DESC);
+CREATE INDEX IF NOT EXISTS idx_user_sessions_user_id ON user_sessions(user_id);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_sales_region_country;
+ALTER TABLE sales DROP COLUMN IF EXISTS country;
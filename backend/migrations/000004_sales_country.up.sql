@@ -0,0 +1,5 @@
+-- Country within the sales region, used for the region -> country drilldown.
+-- Existing rows keep a NULL country and roll up as unknown.
+ALTER TABLE sales ADD COLUMN IF NOT EXISTS country VARCHAR(100);
+
+CREATE INDEX IF NOT EXISTS idx_sales_region_country ON sales(region, country, timestamp DESC);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_user_events_is_bot;
+ALTER TABLE user_sessions DROP COLUMN IF EXISTS is_bot;
+ALTER TABLE user_events DROP COLUMN IF EXISTS is_bot;
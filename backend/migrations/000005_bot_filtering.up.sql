@@ -0,0 +1,6 @@
+-- Bot and invalid-traffic flag, set during ingestion. Analytics exclude
+-- flagged events and the sessions containing them unless asked not to.
+ALTER TABLE user_events ADD COLUMN IF NOT EXISTS is_bot BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE user_sessions ADD COLUMN IF NOT EXISTS is_bot BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE INDEX IF NOT EXISTS idx_user_events_is_bot ON user_events(timestamp DESC) WHERE is_bot;
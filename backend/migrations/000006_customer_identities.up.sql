@@ -0,0 +1,13 @@
+-- Customer Identities Table
+-- Links tracked user IDs to the customer IDs used on sales, for attribution.
+-- A user may map to several customers and a customer to several users.
+CREATE TABLE IF NOT EXISTS customer_identities (
+    user_id VARCHAR(50) NOT NULL,
+    customer_id VARCHAR(50) NOT NULL,
+    first_seen_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    PRIMARY KEY (user_id, customer_id)
+);
+
+-- Create indexes for customer_identities
+CREATE INDEX IF NOT EXISTS idx_customer_identities_customer_id ON customer_identities(customer_id);
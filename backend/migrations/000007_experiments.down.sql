@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_user_events_experiments;
+DROP TABLE IF EXISTS experiments CASCADE;
@@ -0,0 +1,21 @@
+-- Experiments Table
+-- A/B tests analysed from user events. Events record the assignment in their
+-- metadata as {"experiments": {"<key>": "<variant>"}}.
+CREATE TABLE IF NOT EXISTS experiments (
+    id BIGSERIAL PRIMARY KEY,
+    key VARCHAR(100) NOT NULL UNIQUE,
+    name VARCHAR(255) NOT NULL,
+    description TEXT,
+    variants JSONB NOT NULL,
+    control VARCHAR(100) NOT NULL,
+    goal_event VARCHAR(50) NOT NULL,
+    identity VARCHAR(20) NOT NULL DEFAULT 'user',
+    start_at TIMESTAMPTZ NOT NULL,
+    end_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW(),
+    CHECK (end_at IS NULL OR end_at > start_at)
+);
+
+-- Assignment lookups filter on the experiment key inside metadata
+CREATE INDEX IF NOT EXISTS idx_user_events_experiments ON user_events USING GIN ((metadata -> 'experiments'));
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_user_events_metadata_customer_id;
+DROP TABLE IF EXISTS privacy_requests CASCADE;
@@ -0,0 +1,28 @@
+-- Privacy Requests Table
+-- Audit log of data subject exports and erasures. The subject is identified
+-- by the SHA-256 of its ID; the raw ID is kept only while an erasure is
+-- pending and cleared once it has run.
+CREATE TABLE IF NOT EXISTS privacy_requests (
+    id BIGSERIAL PRIMARY KEY,
+    kind VARCHAR(20) NOT NULL,
+    subject_type VARCHAR(20) NOT NULL,
+    subject_hash CHAR(64) NOT NULL,
+    subject VARCHAR(50),
+    requested_by VARCHAR(255) NOT NULL,
+    reason TEXT,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    result JSONB,
+    error TEXT,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    completed_at TIMESTAMPTZ,
+    CHECK (kind IN ('export', 'erasure')),
+    CHECK (subject_type IN ('user', 'customer')),
+    CHECK (status IN ('pending', 'completed', 'failed'))
+);
+
+-- Create indexes for privacy_requests
+CREATE INDEX IF NOT EXISTS idx_privacy_requests_subject_hash ON privacy_requests(subject_hash);
+CREATE INDEX IF NOT EXISTS idx_privacy_requests_pending ON privacy_requests(id) WHERE status = 'pending';
+
+-- Erasures look up purchases by the customer ID in their metadata
+CREATE INDEX IF NOT EXISTS idx_user_events_metadata_customer_id ON user_events ((metadata ->> 'customer_id'));
@@ -0,0 +1,3 @@
+ALTER TABLE user_events DROP COLUMN IF EXISTS os_version;
+ALTER TABLE user_events DROP COLUMN IF EXISTS os;
+ALTER TABLE user_events DROP COLUMN IF EXISTS browser_version;
@@ -0,0 +1,5 @@
+-- User-Agent details parsed at ingestion, alongside the existing device and
+-- browser columns. Versions are truncated to bound breakdown cardinality.
+ALTER TABLE user_events ADD COLUMN IF NOT EXISTS browser_version VARCHAR(50);
+ALTER TABLE user_events ADD COLUMN IF NOT EXISTS os VARCHAR(50);
+ALTER TABLE user_events ADD COLUMN IF NOT EXISTS os_version VARCHAR(50);
@@ -0,0 +1,3 @@
+-- Serves metadata containment predicates (metadata @> '{"plan": "pro"}'),
+-- which /api/events uses for meta.<key>=<value> filters.
+CREATE INDEX IF NOT EXISTS idx_user_events_metadata ON user_events USING GIN (metadata jsonb_path_ops);
@@ -0,0 +1,23 @@
+-- Budgets Table
+-- Budget lines per department, category and metric type for a financial
+-- period. Each line has an original version and optionally a revised one.
+-- Empty department or category lines apply across all values.
+CREATE TABLE IF NOT EXISTS budgets (
+    id BIGSERIAL PRIMARY KEY,
+    department VARCHAR(100) NOT NULL DEFAULT '',
+    category VARCHAR(100) NOT NULL DEFAULT '',
+    metric_type VARCHAR(50) NOT NULL,
+    period VARCHAR(20) NOT NULL,
+    period_start TIMESTAMPTZ NOT NULL,
+    period_end TIMESTAMPTZ NOT NULL,
+    version VARCHAR(20) NOT NULL DEFAULT 'original',
+    amount DECIMAL(14,2) NOT NULL,
+    notes TEXT,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW(),
+    CHECK (period_end > period_start),
+    CHECK (version IN ('original', 'revised')),
+    UNIQUE (department, category, metric_type, period, version)
+);
+
+CREATE INDEX IF NOT EXISTS idx_budgets_period ON budgets(period_start, period_end);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS cost_center_links CASCADE;
+DROP TABLE IF EXISTS cost_centers CASCADE;
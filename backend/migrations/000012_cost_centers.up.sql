@@ -0,0 +1,33 @@
+-- Cost Centers Table
+-- Nodes of the company -> division -> department -> team hierarchy. A node
+-- with a department owns the financial metrics of that department.
+CREATE TABLE IF NOT EXISTS cost_centers (
+    id BIGSERIAL PRIMARY KEY,
+    code VARCHAR(50) NOT NULL UNIQUE,
+    name VARCHAR(100) NOT NULL,
+    level VARCHAR(20) NOT NULL,
+    department VARCHAR(100) NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW(),
+    CHECK (level IN ('company', 'division', 'department', 'team'))
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_cost_centers_department ON cost_centers(department) WHERE department <> '';
+
+-- Cost Center Links Table
+-- Effective-dated parent links. A reorganization closes a node's open link
+-- and adds a new one, so history keeps rolling up to the old parent. A NULL
+-- effective_from is the beginning of time and a NULL effective_to is open.
+CREATE TABLE IF NOT EXISTS cost_center_links (
+    id BIGSERIAL PRIMARY KEY,
+    cost_center_id BIGINT NOT NULL REFERENCES cost_centers(id) ON DELETE CASCADE,
+    parent_id BIGINT REFERENCES cost_centers(id),
+    effective_from TIMESTAMPTZ,
+    effective_to TIMESTAMPTZ,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    CHECK (parent_id <> cost_center_id),
+    CHECK (effective_to > effective_from)
+);
+
+CREATE INDEX IF NOT EXISTS idx_cost_center_links_cost_center ON cost_center_links(cost_center_id, effective_from);
+CREATE INDEX IF NOT EXISTS idx_cost_center_links_parent ON cost_center_links(parent_id);
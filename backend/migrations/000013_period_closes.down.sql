@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS financial_adjustments CASCADE;
+DROP TABLE IF EXISTS period_closes CASCADE;
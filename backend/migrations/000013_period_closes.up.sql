@@ -0,0 +1,39 @@
+-- Period Closes Table
+-- Audit log of financial period closes. A period is locked while it has a
+-- close that has not been reopened; reopening fills in the reopened_*
+-- columns, and closing again adds a new row.
+CREATE TABLE IF NOT EXISTS period_closes (
+    id BIGSERIAL PRIMARY KEY,
+    period VARCHAR(20) NOT NULL,
+    period_start TIMESTAMPTZ NOT NULL,
+    period_end TIMESTAMPTZ NOT NULL,
+    closed_by VARCHAR(255) NOT NULL,
+    close_reason TEXT NOT NULL,
+    closed_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    reopened_by VARCHAR(255),
+    reopen_reason TEXT,
+    reopened_at TIMESTAMPTZ,
+    CHECK (period_end > period_start)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_period_closes_open_period ON period_closes(period) WHERE reopened_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_period_closes_open_range ON period_closes(period_start, period_end) WHERE reopened_at IS NULL;
+
+-- Financial Adjustments Table
+-- Financial metrics that arrived for a closed period. They are kept here,
+-- off the books, for finance to review instead of being inserted.
+CREATE TABLE IF NOT EXISTS financial_adjustments (
+    id BIGSERIAL PRIMARY KEY,
+    period_close_id BIGINT REFERENCES period_closes(id),
+    period VARCHAR(20) NOT NULL,
+    timestamp TIMESTAMPTZ NOT NULL,
+    metric_type VARCHAR(50) NOT NULL,
+    department VARCHAR(100),
+    category VARCHAR(100),
+    amount DECIMAL(12,2) NOT NULL,
+    metric JSONB NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_financial_adjustments_timestamp ON financial_adjustments(timestamp);
+CREATE INDEX IF NOT EXISTS idx_financial_adjustments_period_close ON financial_adjustments(period_close_id);
@@ -0,0 +1,4 @@
+-- Mixing standard deviation of an experiment's sequential test. It is fixed
+-- when the experiment is created so that every look runs the same test.
+ALTER TABLE experiments ADD COLUMN IF NOT EXISTS sequential_tau DOUBLE PRECISION NOT NULL DEFAULT 0.01
+    CHECK (sequential_tau > 0 AND sequential_tau <= 1);
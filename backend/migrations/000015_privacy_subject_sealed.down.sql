@@ -0,0 +1,2 @@
+UPDATE privacy_requests SET subject = NULL WHERE length(subject) > 50;
+ALTER TABLE privacy_requests ALTER COLUMN subject TYPE VARCHAR(50);
@@ -0,0 +1,4 @@
+-- Pending erasure subjects are now sealed under the pseudonym key rather than
+-- stored raw. Erasures still pending from before this change cannot be opened
+-- and fail when they run; request them again.
+ALTER TABLE privacy_requests ALTER COLUMN subject TYPE TEXT;
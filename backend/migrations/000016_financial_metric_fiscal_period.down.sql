@@ -0,0 +1 @@
+ALTER TABLE financial_metrics DROP COLUMN IF EXISTS fiscal_period;
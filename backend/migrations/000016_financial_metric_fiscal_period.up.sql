@@ -0,0 +1,5 @@
+-- Fiscal month of each metric, such as FY2026-M07, stamped at ingest with the
+-- configured fiscal calendar. period keeps whatever the source reported.
+-- Existing rows are stamped by the worker when it starts, since the fiscal
+-- calendar is configured outside the database.
+ALTER TABLE financial_metrics ADD COLUMN IF NOT EXISTS fiscal_period VARCHAR(20);
@@ -0,0 +1 @@
+CREATE INDEX IF NOT EXISTS idx_user_events_experiments ON user_events USING GIN ((metadata -> 'experiments'));
@@ -0,0 +1,4 @@
+-- Assignment lookups read metadata->'experiments'->>key, which this index
+-- cannot serve; the key existence operator it supports clashes with query
+-- placeholders. The lookups are bounded by the experiment's time range.
+DROP INDEX IF EXISTS idx_user_events_experiments;
@@ -52,14 +52,6 @@ func (fg *FinancialGenerator) GenerateMetric(timestamp time.Time) *models.Financ
 		variancePct = (variance / budget) * 100
 	}
 
-	period := "daily"
-	if timestamp.Weekday() == time.Monday {
-		period = "weekly"
-	}
-	if timestamp.Day() == 1 {
-		period = "monthly"
-	}
-
 	return &models.FinancialMetric{
 		Timestamp:   timestamp,
 		MetricType:  metricType,
@@ -69,7 +61,6 @@ func (fg *FinancialGenerator) GenerateMetric(timestamp time.Time) *models.Financ
 		Budget:      budget,
 		Variance:    variance,
 		VariancePct: variancePct,
-		Period:      period,
 	}
 }
 
@@ -4,6 +4,7 @@ import (
 	"math/rand"
 	"time"
 
+	"github.com/KalebAsratemedhi/stock-dashboard/backend/internal/geo"
 	"github.com/KalebAsratemedhi/stock-dashboard/backend/internal/models"
 )
 
@@ -45,6 +46,10 @@ func NewSalesGenerator() *SalesGenerator {
 func (sg *SalesGenerator) GenerateSale(timestamp time.Time) *models.Sale {
 	product := sg.products[sg.rng.Intn(len(sg.products))]
 	region := sg.regions[sg.rng.Intn(len(sg.regions))]
+	country := ""
+	if countries := geo.CountriesInRegion(region); len(countries) > 0 {
+		country = countries[sg.rng.Intn(len(countries))]
+	}
 	
 	quantity := 1 + sg.rng.Intn(5)
 	priceVariation := 0.8 + sg.rng.Float64()*0.4
@@ -56,7 +61,7 @@ func (sg *SalesGenerator) GenerateSale(timestamp time.Time) *models.Sale {
 	}
 	
 	revenue := float64(quantity) * unitPrice * (1 - discount/100)
-	customerID := "CUST" + generateRandomID(6)
+	customerID := generateCustomerID()
 
 	return &models.Sale{
 		Timestamp:   timestamp,
@@ -65,6 +70,7 @@ func (sg *SalesGenerator) GenerateSale(timestamp time.Time) *models.Sale {
 		Category:    product.Category,
 		CustomerID:  customerID,
 		Region:      region,
+		Country:     country,
 		Quantity:    quantity,
 		UnitPrice:   unitPrice,
 		Discount:    discount,
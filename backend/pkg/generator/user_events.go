@@ -1,63 +1,307 @@
 package generator
 
 import (
+	"encoding/json"
+	"fmt"
+	"math"
 	"math/rand"
+	"os"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/KalebAsratemedhi/stock-dashboard/backend/internal/models"
 )
 
+const (
+	sessionTimeout    = 30 * time.Minute
+	maxActiveSessions = 200
+	newSessionRate    = 0.3
+	clickRate         = 0.2
+	campaignRate      = 0.25
+)
+
+type pageWeight struct {
+	page   string
+	weight int
+}
+
+// pageTransitions drives navigation within a session. An empty page ends the
+// session.
+var pageTransitions = map[string][]pageWeight{
+	"/":          {{"/products", 5}, {"/about", 1}, {"/login", 2}, {"/signup", 2}, {"", 2}},
+	"/products":  {{"/products", 2}, {"/cart", 3}, {"/", 1}, {"", 2}},
+	"/cart":      {{"/checkout", 4}, {"/products", 2}, {"", 2}},
+	"/checkout":  {{"/profile", 1}, {"/products", 1}, {"", 3}},
+	"/login":     {{"/dashboard", 5}, {"/", 1}, {"", 1}},
+	"/signup":    {{"/dashboard", 4}, {"", 2}},
+	"/dashboard": {{"/profile", 2}, {"/products", 2}, {"", 2}},
+	"/profile":   {{"/dashboard", 2}, {"", 2}},
+	"/about":     {{"/contact", 2}, {"/", 1}, {"", 2}},
+	"/contact":   {{"/", 1}, {"", 3}},
+}
+
+var entryPages = []pageWeight{
+	{"/", 6}, {"/products", 3}, {"/login", 2}, {"/signup", 1}, {"/about", 1},
+}
+
+// pageActions are conversion events a visitor may fire after viewing a page.
+var pageActions = map[string]struct {
+	eventType string
+	rate      float64
+}{
+	"/signup":   {"signup", 0.5},
+	"/login":    {"login", 0.8},
+	"/checkout": {"purchase", 0.6},
+}
+
+type visitorSession struct {
+	userID     string
+	customerID string
+	sessionID  string
+	page       string
+	userAgent  string
+	country    string
+	city       string
+	referrer   string
+	campaign   map[string]string
+	variant    string
+	pending    string
+	lastSeen   time.Time
+}
+
 type UserEventGenerator struct {
 	pages      []string
-	devices    []string
-	browsers   []string
+	userAgents []string
 	countries  []string
 	eventTypes []string
+	sessions   []*visitorSession
+	experiment *Experiment
 	rng        *rand.Rand
 }
 
+// Experiment assigns each simulated session uniformly to one of Variants and
+// records the assignment in event metadata as {"experiments": {Key: variant}}.
+// Effects is the true relative lift of a variant's purchase rate, e.g. 0.1
+// for +10%; variants without an effect convert at the base rate.
+type Experiment struct {
+	Key      string
+	Variants []string
+	Effects  map[string]float64
+}
+
+// ExperimentFromEnv reads a simulated experiment from SIM_EXPERIMENT_KEY,
+// SIM_EXPERIMENT_VARIANTS (comma separated, control first; defaults to
+// "control,treatment") and SIM_EXPERIMENT_LIFT, the true relative lift of
+// every non-control variant. It returns nil when SIM_EXPERIMENT_KEY is unset.
+func ExperimentFromEnv() (*Experiment, error) {
+	key := os.Getenv("SIM_EXPERIMENT_KEY")
+	if key == "" {
+		return nil, nil
+	}
+	variants := []string{"control", "treatment"}
+	if value := os.Getenv("SIM_EXPERIMENT_VARIANTS"); value != "" {
+		variants = strings.Split(value, ",")
+	}
+	if len(variants) < 2 {
+		return nil, fmt.Errorf("SIM_EXPERIMENT_VARIANTS needs at least two variants")
+	}
+	lift := 0.0
+	if value := os.Getenv("SIM_EXPERIMENT_LIFT"); value != "" {
+		var err error
+		lift, err = strconv.ParseFloat(value, 64)
+		if err != nil || lift <= -1 {
+			return nil, fmt.Errorf("invalid SIM_EXPERIMENT_LIFT: %q", value)
+		}
+	}
+	experiment := &Experiment{Key: key, Variants: variants, Effects: map[string]float64{}}
+	for _, variant := range variants[1:] {
+		experiment.Effects[variant] = lift
+	}
+	return experiment, nil
+}
+
 func NewUserEventGenerator() *UserEventGenerator {
 	return &UserEventGenerator{
 		pages: []string{
 			"/", "/products", "/about", "/contact", "/dashboard",
 			"/login", "/signup", "/cart", "/checkout", "/profile",
 		},
-		devices: []string{"desktop", "mobile", "tablet"},
-		browsers: []string{"Chrome", "Firefox", "Safari", "Edge"},
-		countries: []string{"US", "UK", "CA", "DE", "FR", "JP", "AU", "BR"},
+		userAgents: sampleUserAgents,
+		countries:  []string{"US", "UK", "CA", "DE", "FR", "JP", "AU", "BR"},
 		eventTypes: []string{"page_view", "click", "purchase", "signup", "login"},
-		rng: rand.New(rand.NewSource(time.Now().UnixNano())),
+		rng:        rand.New(rand.NewSource(time.Now().UnixNano())),
 	}
 }
 
+// SetExperiment starts assigning new sessions to the experiment's variants.
+// A nil experiment stops assignment.
+func (ueg *UserEventGenerator) SetExperiment(experiment *Experiment) {
+	ueg.experiment = experiment
+}
+
+// GenerateEvent produces the next event of a simulated visitor. Visitors keep
+// a session across calls and move between pages following pageTransitions, so
+// consecutive events of a session form a plausible navigation path. Calls are
+// expected in roughly increasing timestamp order.
 func (ueg *UserEventGenerator) GenerateEvent(timestamp time.Time) *models.UserEvent {
-	eventType := ueg.eventTypes[ueg.rng.Intn(len(ueg.eventTypes))]
-	page := ueg.pages[ueg.rng.Intn(len(ueg.pages))]
-	device := ueg.devices[ueg.rng.Intn(len(ueg.devices))]
-	browser := ueg.browsers[ueg.rng.Intn(len(ueg.browsers))]
-	country := ueg.countries[ueg.rng.Intn(len(ueg.countries))]
+	ueg.expireSessions(timestamp)
 
-	userID := ""
-	sessionID := ""
-	if ueg.rng.Float64() < 0.7 {
-		userID = "USER" + generateRandomID(8)
-		sessionID = "SESS" + generateRandomID(12)
+	var session *visitorSession
+	var eventType, page string
+	for {
+		if len(ueg.sessions) == 0 || len(ueg.sessions) < maxActiveSessions && ueg.rng.Float64() < newSessionRate {
+			session = ueg.startSession()
+		} else {
+			session = ueg.sessions[ueg.rng.Intn(len(ueg.sessions))]
+		}
+		var ok bool
+		if eventType, page, ok = ueg.advance(session); ok {
+			break
+		}
 	}
-
-	city := generateCity(country)
+	session.lastSeen = timestamp
 
 	return &models.UserEvent{
 		Timestamp: timestamp,
 		EventType: eventType,
-		UserID:    userID,
-		SessionID: sessionID,
+		UserID:    session.userID,
+		SessionID: session.sessionID,
 		Page:      page,
-		Device:    device,
-		Browser:   browser,
-		Country:   country,
-		City:      city,
-		Referrer:  generateReferrer(),
-		Metadata:  "{}",
+		UserAgent: session.userAgent,
+		Country:   session.country,
+		City:      session.city,
+		Referrer:  session.referrer,
+		Metadata:  ueg.metadata(session, eventType),
+	}
+}
+
+// metadata carries the session's campaign on its landing page view and the
+// customer ID on purchases, which is what links events to sales. Every event
+// of a session in an experiment carries its variant.
+func (ueg *UserEventGenerator) metadata(session *visitorSession, eventType string) string {
+	fields := map[string]interface{}{}
+	switch {
+	case eventType == "page_view" && session.campaign != nil:
+		for k, v := range session.campaign {
+			fields[k] = v
+		}
+		session.campaign = nil
+	case eventType == "purchase" && session.customerID != "":
+		fields["customer_id"] = session.customerID
+	}
+	if session.variant != "" && ueg.experiment != nil {
+		fields["experiments"] = map[string]string{ueg.experiment.Key: session.variant}
+	}
+	if len(fields) == 0 {
+		return "{}"
 	}
+	encoded, err := json.Marshal(fields)
+	if err != nil {
+		return "{}"
+	}
+	return string(encoded)
+}
+
+func (ueg *UserEventGenerator) startSession() *visitorSession {
+	country := ueg.countries[ueg.rng.Intn(len(ueg.countries))]
+	session := &visitorSession{
+		sessionID: "SESS" + generateRandomID(12),
+		userAgent: ueg.userAgents[ueg.rng.Intn(len(ueg.userAgents))],
+		country:   country,
+		city:      generateCity(country),
+		referrer:  generateReferrer(),
+	}
+	// Anonymous visitors still get a session, just no user ID.
+	if ueg.rng.Float64() < 0.7 {
+		session.userID = "USER" + generateRandomID(8)
+		session.customerID = generateCustomerID()
+	}
+	if ueg.rng.Float64() < campaignRate {
+		session.campaign = campaigns[ueg.rng.Intn(len(campaigns))]
+	}
+	if ueg.experiment != nil {
+		session.variant = ueg.experiment.Variants[ueg.rng.Intn(len(ueg.experiment.Variants))]
+	}
+	ueg.sessions = append(ueg.sessions, session)
+	return session
+}
+
+// advance returns the next event type and page for a session and updates its
+// position. A session with no page yet lands on an entry page. It returns
+// false when the visitor leaves instead, ending the session.
+func (ueg *UserEventGenerator) advance(session *visitorSession) (string, string, bool) {
+	if session.page == "" {
+		session.page = ueg.pick(entryPages)
+		ueg.queueAction(session)
+		return "page_view", session.page, true
+	}
+	if session.pending != "" {
+		eventType := session.pending
+		session.pending = ""
+		return eventType, session.page, true
+	}
+	if ueg.rng.Float64() < clickRate {
+		return "click", session.page, true
+	}
+
+	next := ueg.pick(pageTransitions[session.page])
+	if next == "" {
+		ueg.endSession(session)
+		return "", "", false
+	}
+	session.page = next
+	ueg.queueAction(session)
+	return "page_view", session.page, true
 }
 
+func (ueg *UserEventGenerator) queueAction(session *visitorSession) {
+	action, ok := pageActions[session.page]
+	if !ok {
+		return
+	}
+	rate := action.rate
+	if action.eventType == "purchase" && session.variant != "" && ueg.experiment != nil {
+		rate = math.Min(1, rate*(1+ueg.experiment.Effects[session.variant]))
+	}
+	if ueg.rng.Float64() < rate {
+		session.pending = action.eventType
+	}
+}
+
+func (ueg *UserEventGenerator) pick(options []pageWeight) string {
+	total := 0
+	for _, o := range options {
+		total += o.weight
+	}
+	if total == 0 {
+		return ""
+	}
+	n := ueg.rng.Intn(total)
+	for _, o := range options {
+		if n < o.weight {
+			return o.page
+		}
+		n -= o.weight
+	}
+	return ""
+}
+
+func (ueg *UserEventGenerator) endSession(session *visitorSession) {
+	for i, s := range ueg.sessions {
+		if s == session {
+			ueg.sessions = append(ueg.sessions[:i], ueg.sessions[i+1:]...)
+			return
+		}
+	}
+}
+
+func (ueg *UserEventGenerator) expireSessions(now time.Time) {
+	active := ueg.sessions[:0]
+	for _, s := range ueg.sessions {
+		if s.lastSeen.IsZero() || now.Sub(s.lastSeen) < sessionTimeout {
+			active = append(active, s)
+		}
+	}
+	ueg.sessions = active
+}
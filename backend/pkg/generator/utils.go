@@ -1,9 +1,14 @@
 package generator
 
 import (
+	"fmt"
 	"math/rand"
 )
 
+// customerPoolSize bounds the customer IDs shared by the sales and user
+// event generators, so generated purchases can be linked to sales.
+const customerPoolSize = 2000
+
 var referrers = []string{
 	"https://google.com",
 	"https://bing.com",
@@ -13,6 +18,26 @@ var referrers = []string{
 	"",
 }
 
+// sampleUserAgents are parsed like collected events so that generated data
+// covers the browser, OS and device breakdowns.
+var sampleUserAgents = []string{
+	"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36",
+	"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/119.0.0.0 Safari/537.36",
+	"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36 Edg/120.0.2210.91",
+	"Mozilla/5.0 (Windows NT 10.0; Win64; x64; rv:121.0) Gecko/20100101 Firefox/121.0",
+	"Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.1 Safari/605.1.15",
+	"Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36",
+	"Mozilla/5.0 (X11; Linux x86_64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36",
+	"Mozilla/5.0 (X11; Ubuntu; Linux x86_64; rv:121.0) Gecko/20100101 Firefox/121.0",
+	"Mozilla/5.0 (iPhone; CPU iPhone OS 17_2 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.2 Mobile/15E148 Safari/604.1",
+	"Mozilla/5.0 (iPhone; CPU iPhone OS 16_6 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/16.6 Mobile/15E148 Safari/604.1",
+	"Mozilla/5.0 (iPhone; CPU iPhone OS 17_2 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) CriOS/120.0.6099.119 Mobile/15E148 Safari/604.1",
+	"Mozilla/5.0 (Linux; Android 14; Pixel 8) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.6099.144 Mobile Safari/537.36",
+	"Mozilla/5.0 (Linux; Android 13; SM-S911B) AppleWebKit/537.36 (KHTML, like Gecko) SamsungBrowser/23.0 Chrome/115.0.0.0 Mobile Safari/537.36",
+	"Mozilla/5.0 (iPad; CPU OS 17_2 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.2 Mobile/15E148 Safari/604.1",
+	"Mozilla/5.0 (Linux; Android 13; SM-X710) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36",
+}
+
 var cityMap = map[string][]string{
 	"US": {"New York", "Los Angeles", "Chicago", "Houston", "Phoenix"},
 	"UK": {"London", "Manchester", "Birmingham", "Liverpool", "Leeds"},
@@ -33,6 +58,18 @@ func generateRandomID(length int) string {
 	return string(b)
 }
 
+// campaigns are UTM parameter sets attached to some landing page views.
+var campaigns = []map[string]string{
+	{"utm_source": "google", "utm_medium": "cpc", "utm_campaign": "brand_search"},
+	{"utm_source": "newsletter", "utm_medium": "email", "utm_campaign": "weekly_digest"},
+	{"utm_source": "facebook", "utm_medium": "paid_social", "utm_campaign": "spring_sale"},
+	{"utm_source": "partner_blog", "utm_medium": "affiliate", "utm_campaign": "partner_program"},
+}
+
+func generateCustomerID() string {
+	return fmt.Sprintf("CUST%06d", 1+rand.Intn(customerPoolSize))
+}
+
 func generateReferrer() string {
 	return referrers[rand.Intn(len(referrers))]
 }